@@ -0,0 +1,110 @@
+// Package apperror berisi error aplikasi bertipe yang diterjemahkan oleh ErrorHandler
+// Fiber menjadi respons application/problem+json (RFC 7807).
+//
+// Handler cukup mengembalikan error, misalnya:
+//
+//	return apperror.NotFound("User not found")
+//
+// Code adalah kode stabil yang boleh dipakai klien untuk percabangan, sedangkan
+// Detail adalah pesan untuk manusia dan bisa berubah.
+package apperror
+
+import (
+	"net/http"
+)
+
+// Kode error umum. Kode yang lebih spesifik didefinisikan dekat tempat pemakaiannya.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeInvalidBody        = "invalid_body"
+	CodeInvalidID          = "invalid_id"
+	CodeInvalidQuery       = "invalid_query"
+	CodeInvalidCursor      = "invalid_cursor"
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodePreconditionFailed = "precondition_failed"
+	CodeValidation         = "validation_failed"
+	CodeInternal           = "internal_error"
+)
+
+// Error adalah error aplikasi yang membawa status HTTP dan kode stabil.
+// Err menyimpan penyebab internal, hanya ditulis ke log dan tidak pernah dikirim ke klien.
+type Error struct {
+	Status     int
+	Code       string
+	Detail     string
+	Extensions map[string]interface{}
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Code + ": " + e.Detail + ": " + e.Err.Error()
+	}
+	return e.Code + ": " + e.Detail
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// With menambahkan member tambahan pada problem, misalnya daftar dokumen yang masih merujuk
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Extensions == nil {
+		e.Extensions = map[string]interface{}{}
+	}
+	e.Extensions[key] = value
+	return e
+}
+
+// Wrap menyimpan penyebab internal pada error
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+// New membuat Error dengan status, kode dan pesan tertentu
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+func BadRequest(code, detail string) *Error {
+	return New(http.StatusBadRequest, code, detail)
+}
+
+func Unauthorized(code, detail string) *Error {
+	return New(http.StatusUnauthorized, code, detail)
+}
+
+func Forbidden(code, detail string) *Error {
+	return New(http.StatusForbidden, code, detail)
+}
+
+func NotFound(detail string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, detail)
+}
+
+func Conflict(code, detail string) *Error {
+	return New(http.StatusConflict, code, detail)
+}
+
+func PreconditionFailed(detail string) *Error {
+	return New(http.StatusPreconditionFailed, CodePreconditionFailed, detail)
+}
+
+func TooManyRequests(code, detail string) *Error {
+	return New(http.StatusTooManyRequests, code, detail)
+}
+
+// Internal membuat error 500 dengan pesan tetap. Pesan diganti pesan umum di production.
+func Internal(detail string) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, detail)
+}
+
+// Wrap membungkus error tak terduga (misalnya dari database) menjadi error 500
+func Wrap(err error) *Error {
+	return Internal(err.Error()).Wrap(err)
+}
@@ -0,0 +1,101 @@
+package apperror
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"project-crud/validation"
+)
+
+// ContentType adalah media type untuk respons problem+json
+const ContentType = "application/problem+json"
+
+// TraceIDLocal adalah key c.Locals tempat middleware menyimpan trace ID request
+const TraceIDLocal = "trace_id"
+
+// Handler mengembalikan ErrorHandler Fiber yang menulis semua error sebagai problem+json.
+// Jika hideInternal true (profil production), detail error 5xx diganti pesan umum;
+// detail aslinya tetap ditulis ke log bersama trace ID.
+func Handler(hideInternal bool) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		appErr := From(err)
+		traceID, _ := c.Locals(TraceIDLocal).(string)
+
+		if appErr.Status >= http.StatusInternalServerError {
+			log.Printf("[%s] %s %s: %v", traceID, c.Method(), c.Path(), appErr)
+			if hideInternal {
+				appErr = &Error{Status: appErr.Status, Code: appErr.Code, Detail: "An unexpected error occurred, please contact support with the trace ID"}
+			}
+		}
+
+		// Member standar RFC 7807 ditambah code dan trace_id, extension tidak boleh menimpanya
+		problem := fiber.Map{}
+		for key, value := range appErr.Extensions {
+			problem[key] = value
+		}
+		problem["type"] = "/problems/" + strings.ReplaceAll(appErr.Code, "_", "-")
+		problem["title"] = http.StatusText(appErr.Status)
+		problem["status"] = appErr.Status
+		problem["detail"] = appErr.Detail
+		problem["instance"] = c.OriginalURL()
+		problem["code"] = appErr.Code
+		if traceID != "" {
+			problem["trace_id"] = traceID
+		}
+
+		c.Set(fiber.HeaderContentType, ContentType)
+		body, err := c.App().Config().JSONEncoder(problem)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).SendString(http.StatusText(http.StatusInternalServerError))
+		}
+		return c.Status(appErr.Status).Send(body)
+	}
+}
+
+// From menerjemahkan error apa pun menjadi *Error. Error Fiber (misalnya route tidak
+// ditemukan) dan error validasi dipetakan ke status masing-masing, sisanya menjadi 500.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var fields validation.Errors
+	if errors.As(err, &fields) {
+		return New(http.StatusUnprocessableEntity, CodeValidation, "One or more fields are invalid").With("errors", fields)
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return New(fiberErr.Code, statusCode(fiberErr.Code), fiberErr.Message)
+	}
+	return Wrap(err)
+}
+
+// statusCode memberi kode stabil untuk error yang hanya membawa status HTTP
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case http.StatusUnprocessableEntity:
+		return CodeValidation
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
@@ -0,0 +1,575 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/yaml.v3"
+)
+
+// Profil environment yang didukung
+const (
+	EnvDev     = "dev"
+	EnvStaging = "staging"
+	EnvProd    = "prod"
+)
+
+// Driver penyimpanan data
+const (
+	StorageMongo  = "mongo"
+	StorageMemory = "memory"
+)
+
+// Driver pengiriman email
+const (
+	MailSMTP = "smtp"
+	MailFile = "file" // Simpan sebagai file .eml, untuk pengembangan lokal
+	MailLog  = "log"  // Tulis ke log aplikasi, untuk pengembangan lokal
+)
+
+// Algoritma hash untuk password baru
+const (
+	HashArgon2id = "argon2id"
+	HashBcrypt   = "bcrypt"
+)
+
+// Config adalah seluruh konfigurasi aplikasi
+type Config struct {
+	Env      string      `yaml:"env"`
+	Port     string      `yaml:"port"`
+	Timezone string      `yaml:"timezone"`
+	Storage  string      `yaml:"storage"` // "mongo" atau "memory"
+	Mongo    MongoConfig `yaml:"mongo"`
+	JWT      JWTConfig   `yaml:"jwt"`
+	Trash    TrashConfig `yaml:"trash"`
+	Login    LoginConfig `yaml:"login"`
+	Mail     MailConfig  `yaml:"mail"`
+
+	PasswordReset PasswordResetConfig `yaml:"password_reset"`
+	Password      PasswordConfig      `yaml:"password"`
+	OIDC          OIDCConfig          `yaml:"oidc"`
+
+	// AdminRole adalah ID role yang selalu diberi permission "*" saat startup agar admin
+	// tidak terkunci dari API permission. Kosongkan untuk mematikan.
+	AdminRole string `yaml:"admin_role"`
+}
+
+// MongoConfig adalah konfigurasi koneksi MongoDB
+type MongoConfig struct {
+	URI      string        `yaml:"uri"`
+	Database string        `yaml:"database"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// JWTConfig adalah konfigurasi penerbitan dan verifikasi token
+type JWTConfig struct {
+	Issuer      string        `yaml:"issuer"`
+	Algorithm   string        `yaml:"algorithm"`
+	KeyDir      string        `yaml:"key_dir"`
+	KeyRotation time.Duration `yaml:"key_rotation"`
+	AccessTTL   time.Duration `yaml:"access_ttl"`
+	RefreshTTL  time.Duration `yaml:"refresh_ttl"`
+	MFATTL      time.Duration `yaml:"mfa_ttl"` // Masa berlaku token mfa_pending di antara langkah password dan kode
+	ClockSkew   time.Duration `yaml:"clock_skew"`
+}
+
+// TrashConfig adalah konfigurasi penghapusan permanen data yang sudah di-soft delete
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention"`      // Lama data disimpan di trash sebelum dihapus permanen
+	PurgeInterval time.Duration `yaml:"purge_interval"` // Jeda antar pemeriksaan trash
+}
+
+// LoginConfig adalah konfigurasi proteksi brute-force pada login
+type LoginConfig struct {
+	MaxFailures     int           `yaml:"max_failures"`     // Gagal berturut-turut per username sebelum akun dikunci
+	IPMaxFailures   int           `yaml:"ip_max_failures"`  // Gagal per IP (semua username) sebelum IP diblokir
+	DelayAfter      int           `yaml:"delay_after"`      // Jeda progresif dimulai setelah gagal sebanyak ini
+	BaseDelay       time.Duration `yaml:"base_delay"`       // Jeda pertama, berlipat dua setiap kegagalan berikutnya
+	MaxDelay        time.Duration `yaml:"max_delay"`        // Batas atas jeda progresif
+	Window          time.Duration `yaml:"window"`           // Penghitung direset jika tidak ada kegagalan selama ini
+	LockoutDuration time.Duration `yaml:"lockout_duration"` // Lama akun atau IP terkunci
+	RecordRetention time.Duration `yaml:"record_retention"` // Lama catatan login disimpan
+}
+
+// MailConfig adalah konfigurasi pengiriman email
+type MailConfig struct {
+	Driver string     `yaml:"driver"` // "smtp", "file" atau "log"
+	From   string     `yaml:"from"`
+	Dir    string     `yaml:"dir"` // Folder tujuan untuk driver file
+	SMTP   SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig adalah alamat dan kredensial server SMTP
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// PasswordResetConfig adalah konfigurasi alur lupa password
+type PasswordResetConfig struct {
+	URL      string        `yaml:"url"`       // Halaman frontend untuk memasukkan password baru, token ditambahkan sebagai ?token=
+	TokenTTL time.Duration `yaml:"token_ttl"` // Masa berlaku tautan reset
+	Cooldown time.Duration `yaml:"cooldown"`  // Jeda minimal antar email reset untuk user yang sama
+}
+
+// PasswordConfig adalah policy kekuatan password untuk create user, reset dan ganti password
+type PasswordConfig struct {
+	MinLength            int    `yaml:"min_length"`
+	RequireUpper         bool   `yaml:"require_upper"`
+	RequireLower         bool   `yaml:"require_lower"`
+	RequireDigit         bool   `yaml:"require_digit"`
+	RequireSymbol        bool   `yaml:"require_symbol"`
+	DisallowPersonalInfo bool   `yaml:"disallow_personal_info"` // Tolak password yang memuat username, nama atau email
+	History              int    `yaml:"history"`                // Jumlah password terakhir yang tidak boleh dipakai ulang, 0 untuk mematikan
+	BreachedCheck        bool   `yaml:"breached_check"`         // Periksa terhadap daftar password bocor bawaan
+	BreachedFile         string `yaml:"breached_file"`          // Daftar tambahan berformat Pwned Passwords (HASH atau HASH:JUMLAH per baris)
+
+	Hash PasswordHashConfig `yaml:"hash"`
+}
+
+// OIDCConfig adalah konfigurasi provider OpenID Connect untuk aplikasi modul
+type OIDCConfig struct {
+	Issuer     string        `yaml:"issuer"`      // URL publik backend ini, menjadi iss dan dasar URL pada discovery
+	ConsentURL string        `yaml:"consent_url"` // Halaman portal untuk login dan persetujuan, permintaan ditambahkan sebagai ?request=
+	RequestTTL time.Duration `yaml:"request_ttl"` // Batas waktu user menyelesaikan login dan persetujuan
+	CodeTTL    time.Duration `yaml:"code_ttl"`    // Masa berlaku authorization code
+	AccessTTL  time.Duration `yaml:"access_ttl"`
+	IDTokenTTL time.Duration `yaml:"id_token_ttl"`
+}
+
+// PasswordHashConfig adalah algoritma dan parameter hash untuk password baru. Hash yang
+// tersimpan dengan algoritma atau parameter lain diganti otomatis saat login berhasil.
+type PasswordHashConfig struct {
+	Algorithm         string `yaml:"algorithm"` // "argon2id" atau "bcrypt"
+	BcryptCost        int    `yaml:"bcrypt_cost"`
+	Argon2Memory      int    `yaml:"argon2_memory"` // Dalam KiB
+	Argon2Iterations  int    `yaml:"argon2_iterations"`
+	Argon2Parallelism int    `yaml:"argon2_parallelism"`
+}
+
+var (
+	loadOnce sync.Once
+	current  *Config
+	location *time.Location
+)
+
+// Get mengembalikan konfigurasi aplikasi. Konfigurasi dimuat sekali saat pertama kali dipanggil.
+func Get() *Config {
+	loadOnce.Do(func() {
+		cfg, err := Load()
+		if err != nil {
+			log.Fatal("Invalid configuration: ", err)
+		}
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			log.Fatal("Invalid timezone: ", err)
+		}
+		current, location = cfg, loc
+	})
+	return current
+}
+
+// Location mengembalikan zona waktu aplikasi untuk created_at/updated_at
+func Location() *time.Location {
+	Get()
+	return location
+}
+
+// Load membaca konfigurasi dengan urutan prioritas:
+// default profil < file YAML (CONFIG_FILE atau config.<env>.yaml) < environment variable
+func Load() (*Config, error) {
+	env := strings.ToLower(getenv("APP_ENV", EnvDev))
+	cfg := defaults(env)
+
+	file := os.Getenv("CONFIG_FILE")
+	required := file != ""
+	if file == "" {
+		file = "config." + env + ".yaml"
+	}
+	if err := loadFile(cfg, file, required); err != nil {
+		return nil, err
+	}
+
+	if err := loadEnv(cfg); err != nil {
+		return nil, err
+	}
+	cfg.Env = env
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// defaults mengembalikan nilai bawaan untuk tiap profil
+func defaults(env string) *Config {
+	cfg := &Config{
+		Env:      env,
+		Port:     "3000",
+		Timezone: "Asia/Jakarta",
+		Storage:  StorageMongo,
+		// Role admin yang sebelumnya ditulis langsung di route
+		AdminRole: "675d1cd023322aa0cdbdfdbd",
+		Mongo: MongoConfig{
+			Database: "unairsatu",
+			Timeout:  10 * time.Second,
+		},
+		JWT: JWTConfig{
+			Issuer:      "unairsatu",
+			Algorithm:   "EdDSA",
+			KeyDir:      "storage/keys",
+			KeyRotation: 24 * time.Hour,
+			AccessTTL:   time.Hour,
+			RefreshTTL:  7 * 24 * time.Hour,
+			MFATTL:      5 * time.Minute,
+			ClockSkew:   30 * time.Second,
+		},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Login: LoginConfig{
+			MaxFailures:     5,
+			IPMaxFailures:   50,
+			DelayAfter:      3,
+			BaseDelay:       time.Second,
+			MaxDelay:        30 * time.Second,
+			Window:          15 * time.Minute,
+			LockoutDuration: 15 * time.Minute,
+			RecordRetention: 90 * 24 * time.Hour,
+		},
+		Mail: MailConfig{
+			Driver: MailLog,
+			From:   "UNAIR SATU <no-reply@unairsatu.local>",
+			Dir:    "storage/mail",
+			SMTP:   SMTPConfig{Port: "587"},
+		},
+		PasswordReset: PasswordResetConfig{
+			URL:      "http://localhost:3000/reset-password",
+			TokenTTL: 30 * time.Minute,
+			Cooldown: time.Minute,
+		},
+		Password: PasswordConfig{
+			MinLength:            8,
+			RequireUpper:         true,
+			RequireLower:         true,
+			RequireDigit:         true,
+			DisallowPersonalInfo: true,
+			History:              5,
+			BreachedCheck:        true,
+			Hash: PasswordHashConfig{
+				Algorithm:         HashArgon2id,
+				BcryptCost:        10,
+				Argon2Memory:      19 * 1024,
+				Argon2Iterations:  2,
+				Argon2Parallelism: 1,
+			},
+		},
+		OIDC: OIDCConfig{
+			Issuer:     "http://localhost:3000",
+			ConsentURL: "http://localhost:3000/oauth/consent",
+			RequestTTL: 10 * time.Minute,
+			CodeTTL:    time.Minute,
+			AccessTTL:  time.Hour,
+			IDTokenTTL: time.Hour,
+		},
+	}
+	// Hanya profil dev yang boleh memakai mongod lokal tanpa konfigurasi
+	if env == EnvDev {
+		cfg.Mongo.URI = "mongodb://localhost:27017"
+	}
+	return cfg
+}
+
+// loadFile menimpa konfigurasi dengan isi file YAML
+func loadFile(cfg *Config, file string, required bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil
+		}
+		return fmt.Errorf("read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("parse config file %s: %w", file, err)
+	}
+	log.Println("Loaded config file", file)
+	return nil
+}
+
+// loadEnv menimpa konfigurasi dengan environment variable yang diisi
+func loadEnv(cfg *Config) error {
+	setString(&cfg.Port, "APP_PORT")
+	setString(&cfg.Timezone, "APP_TIMEZONE")
+	setString(&cfg.Storage, "STORAGE_DRIVER")
+	setString(&cfg.AdminRole, "ADMIN_ROLE_ID")
+	setString(&cfg.Mongo.URI, "MONGO_URI")
+	setString(&cfg.Mongo.Database, "MONGO_DATABASE")
+	setString(&cfg.JWT.Issuer, "JWT_ISSUER")
+	setString(&cfg.JWT.Algorithm, "JWT_ALGORITHM")
+	setString(&cfg.JWT.KeyDir, "JWT_KEY_DIR")
+	setString(&cfg.Mail.Driver, "MAIL_DRIVER")
+	setString(&cfg.Mail.From, "MAIL_FROM")
+	setString(&cfg.Mail.Dir, "MAIL_DIR")
+	setString(&cfg.Mail.SMTP.Host, "SMTP_HOST")
+	setString(&cfg.Mail.SMTP.Port, "SMTP_PORT")
+	setString(&cfg.Mail.SMTP.Username, "SMTP_USERNAME")
+	setString(&cfg.Mail.SMTP.Password, "SMTP_PASSWORD")
+	setString(&cfg.PasswordReset.URL, "PASSWORD_RESET_URL")
+	setString(&cfg.Password.BreachedFile, "PASSWORD_BREACHED_FILE")
+	setString(&cfg.Password.Hash.Algorithm, "PASSWORD_HASH_ALGORITHM")
+	setString(&cfg.OIDC.Issuer, "OIDC_ISSUER")
+	setString(&cfg.OIDC.ConsentURL, "OIDC_CONSENT_URL")
+
+	durations := map[string]*time.Duration{
+		"MONGO_TIMEOUT":           &cfg.Mongo.Timeout,
+		"JWT_KEY_ROTATION":        &cfg.JWT.KeyRotation,
+		"JWT_ACCESS_TTL":          &cfg.JWT.AccessTTL,
+		"JWT_REFRESH_TTL":         &cfg.JWT.RefreshTTL,
+		"JWT_MFA_TTL":             &cfg.JWT.MFATTL,
+		"JWT_CLOCK_SKEW":          &cfg.JWT.ClockSkew,
+		"TRASH_RETENTION":         &cfg.Trash.Retention,
+		"TRASH_PURGE_INTERVAL":    &cfg.Trash.PurgeInterval,
+		"LOGIN_BASE_DELAY":        &cfg.Login.BaseDelay,
+		"LOGIN_MAX_DELAY":         &cfg.Login.MaxDelay,
+		"LOGIN_WINDOW":            &cfg.Login.Window,
+		"LOGIN_LOCKOUT_DURATION":  &cfg.Login.LockoutDuration,
+		"LOGIN_RECORD_RETENTION":  &cfg.Login.RecordRetention,
+		"PASSWORD_RESET_TTL":      &cfg.PasswordReset.TokenTTL,
+		"PASSWORD_RESET_COOLDOWN": &cfg.PasswordReset.Cooldown,
+		"OIDC_REQUEST_TTL":        &cfg.OIDC.RequestTTL,
+		"OIDC_CODE_TTL":           &cfg.OIDC.CodeTTL,
+		"OIDC_ACCESS_TTL":         &cfg.OIDC.AccessTTL,
+		"OIDC_ID_TOKEN_TTL":       &cfg.OIDC.IDTokenTTL,
+	}
+	for name, target := range durations {
+		if err := setDuration(target, name); err != nil {
+			return err
+		}
+	}
+
+	ints := map[string]*int{
+		"LOGIN_MAX_FAILURES":    &cfg.Login.MaxFailures,
+		"LOGIN_IP_MAX_FAILURES": &cfg.Login.IPMaxFailures,
+		"LOGIN_DELAY_AFTER":     &cfg.Login.DelayAfter,
+		"PASSWORD_MIN_LENGTH":   &cfg.Password.MinLength,
+		"PASSWORD_HISTORY":      &cfg.Password.History,
+
+		"PASSWORD_BCRYPT_COST":        &cfg.Password.Hash.BcryptCost,
+		"PASSWORD_ARGON2_MEMORY":      &cfg.Password.Hash.Argon2Memory,
+		"PASSWORD_ARGON2_ITERATIONS":  &cfg.Password.Hash.Argon2Iterations,
+		"PASSWORD_ARGON2_PARALLELISM": &cfg.Password.Hash.Argon2Parallelism,
+	}
+	for name, target := range ints {
+		if err := setInt(target, name); err != nil {
+			return err
+		}
+	}
+
+	bools := map[string]*bool{
+		"PASSWORD_REQUIRE_UPPER":          &cfg.Password.RequireUpper,
+		"PASSWORD_REQUIRE_LOWER":          &cfg.Password.RequireLower,
+		"PASSWORD_REQUIRE_DIGIT":          &cfg.Password.RequireDigit,
+		"PASSWORD_REQUIRE_SYMBOL":         &cfg.Password.RequireSymbol,
+		"PASSWORD_DISALLOW_PERSONAL_INFO": &cfg.Password.DisallowPersonalInfo,
+		"PASSWORD_BREACHED_CHECK":         &cfg.Password.BreachedCheck,
+	}
+	for name, target := range bools {
+		if err := setBool(target, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate memastikan semua nilai wajib terisi dan valid
+func (c *Config) Validate() error {
+	var problems []string
+
+	switch c.Env {
+	case EnvDev, EnvStaging, EnvProd:
+	default:
+		problems = append(problems, fmt.Sprintf("APP_ENV must be one of dev, staging, prod (got %q)", c.Env))
+	}
+	if c.Port == "" {
+		problems = append(problems, "APP_PORT is required")
+	}
+	switch c.Storage {
+	case StorageMongo:
+		if c.Mongo.URI == "" {
+			problems = append(problems, "MONGO_URI is required")
+		}
+	case StorageMemory:
+		if c.Env == EnvProd {
+			problems = append(problems, "STORAGE_DRIVER=memory is not allowed in prod")
+		}
+	default:
+		problems = append(problems, "STORAGE_DRIVER must be mongo or memory")
+	}
+	if c.Mongo.Database == "" {
+		problems = append(problems, "MONGO_DATABASE is required")
+	}
+	if c.Mongo.Timeout <= 0 {
+		problems = append(problems, "MONGO_TIMEOUT must be positive")
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		problems = append(problems, fmt.Sprintf("APP_TIMEZONE %q is invalid", c.Timezone))
+	}
+	if c.JWT.Algorithm != "RS256" && c.JWT.Algorithm != "EdDSA" {
+		problems = append(problems, "JWT_ALGORITHM must be RS256 or EdDSA")
+	}
+	if c.JWT.Issuer == "" {
+		problems = append(problems, "JWT_ISSUER is required")
+	}
+	if c.JWT.KeyDir == "" {
+		problems = append(problems, "JWT_KEY_DIR is required")
+	}
+	if c.JWT.KeyRotation <= 0 || c.JWT.AccessTTL <= 0 || c.JWT.RefreshTTL <= 0 || c.JWT.MFATTL <= 0 || c.JWT.ClockSkew < 0 {
+		problems = append(problems, "JWT durations must be positive")
+	}
+	if c.AdminRole != "" && !primitive.IsValidObjectID(c.AdminRole) {
+		problems = append(problems, "ADMIN_ROLE_ID must be a role ObjectID")
+	}
+	if c.Trash.Retention <= 0 || c.Trash.PurgeInterval <= 0 {
+		problems = append(problems, "TRASH_RETENTION and TRASH_PURGE_INTERVAL must be positive")
+	}
+	if c.Login.MaxFailures <= 0 || c.Login.IPMaxFailures <= 0 || c.Login.DelayAfter < 0 {
+		problems = append(problems, "LOGIN_MAX_FAILURES and LOGIN_IP_MAX_FAILURES must be positive")
+	}
+	if c.Login.BaseDelay < 0 || c.Login.MaxDelay < c.Login.BaseDelay {
+		problems = append(problems, "LOGIN_MAX_DELAY must not be less than LOGIN_BASE_DELAY")
+	}
+	if c.Login.Window <= 0 || c.Login.LockoutDuration <= 0 || c.Login.RecordRetention <= 0 {
+		problems = append(problems, "LOGIN_WINDOW, LOGIN_LOCKOUT_DURATION and LOGIN_RECORD_RETENTION must be positive")
+	}
+	switch c.Mail.Driver {
+	case MailSMTP:
+		if c.Mail.SMTP.Host == "" || c.Mail.SMTP.Port == "" {
+			problems = append(problems, "SMTP_HOST and SMTP_PORT are required for MAIL_DRIVER=smtp")
+		}
+	case MailFile, MailLog:
+		// Tautan reset password tidak boleh berakhir di disk atau log server production
+		if c.Env == EnvProd {
+			problems = append(problems, "MAIL_DRIVER="+c.Mail.Driver+" is not allowed in prod")
+		}
+		if c.Mail.Driver == MailFile && c.Mail.Dir == "" {
+			problems = append(problems, "MAIL_DIR is required for MAIL_DRIVER=file")
+		}
+	default:
+		problems = append(problems, "MAIL_DRIVER must be smtp, file or log")
+	}
+	if c.Mail.From == "" {
+		problems = append(problems, "MAIL_FROM is required")
+	}
+	if c.PasswordReset.URL == "" {
+		problems = append(problems, "PASSWORD_RESET_URL is required")
+	}
+	if c.PasswordReset.TokenTTL <= 0 || c.PasswordReset.Cooldown < 0 {
+		problems = append(problems, "PASSWORD_RESET_TTL must be positive")
+	}
+	// Batas atas mengikuti bcrypt yang hanya memakai 72 byte pertama
+	if c.Password.MinLength < 1 || c.Password.MinLength > 72 {
+		problems = append(problems, "PASSWORD_MIN_LENGTH must be between 1 and 72")
+	}
+	if c.Password.History < 0 {
+		problems = append(problems, "PASSWORD_HISTORY must not be negative")
+	}
+	if c.Password.BreachedFile != "" && !c.Password.BreachedCheck {
+		problems = append(problems, "PASSWORD_BREACHED_FILE requires PASSWORD_BREACHED_CHECK")
+	}
+	if c.Password.Hash.Algorithm != HashArgon2id && c.Password.Hash.Algorithm != HashBcrypt {
+		problems = append(problems, "PASSWORD_HASH_ALGORITHM must be argon2id or bcrypt")
+	}
+	// Parameter kedua algoritma tetap divalidasi karena hash lama dari algoritma lain ikut diverifikasi
+	if c.Password.Hash.BcryptCost < 4 || c.Password.Hash.BcryptCost > 31 {
+		problems = append(problems, "PASSWORD_BCRYPT_COST must be between 4 and 31")
+	}
+	if c.Password.Hash.Argon2Iterations < 1 || c.Password.Hash.Argon2Parallelism < 1 || c.Password.Hash.Argon2Parallelism > 255 {
+		problems = append(problems, "PASSWORD_ARGON2_ITERATIONS must be positive and PASSWORD_ARGON2_PARALLELISM between 1 and 255")
+	}
+	// Argon2 membutuhkan minimal 8 KiB per thread
+	if c.Password.Hash.Argon2Memory < 8*c.Password.Hash.Argon2Parallelism {
+		problems = append(problems, "PASSWORD_ARGON2_MEMORY must be at least 8 KiB per thread")
+	}
+	// Issuer dibandingkan apa adanya oleh aplikasi modul, jadi harus URL tanpa query, fragment atau garis miring akhir
+	issuer, err := url.Parse(c.OIDC.Issuer)
+	switch {
+	case err != nil || issuer.Host == "" || issuer.RawQuery != "" || issuer.Fragment != "" || strings.HasSuffix(c.OIDC.Issuer, "/"):
+		problems = append(problems, "OIDC_ISSUER must be an absolute URL without query, fragment or trailing slash")
+	case issuer.Scheme != "https" && (c.Env == EnvProd || issuer.Scheme != "http"):
+		problems = append(problems, "OIDC_ISSUER must be an http or https URL (https in prod)")
+	}
+	if consent, err := url.Parse(c.OIDC.ConsentURL); err != nil || consent.Host == "" {
+		problems = append(problems, "OIDC_CONSENT_URL must be an absolute URL")
+	}
+	if c.OIDC.RequestTTL <= 0 || c.OIDC.CodeTTL <= 0 || c.OIDC.AccessTTL <= 0 || c.OIDC.IDTokenTTL <= 0 {
+		problems = append(problems, "OIDC durations must be positive")
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func getenv(name, fallback string) string {
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func setString(target *string, name string) {
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		*target = value
+	}
+}
+
+func setDuration(target *time.Duration, name string) error {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	*target = d
+	return nil
+}
+
+func setInt(target *int, name string) error {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	*target = n
+	return nil
+}
+
+func setBool(target *bool, name string) error {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	*target = b
+	return nil
+}
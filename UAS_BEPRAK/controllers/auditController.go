@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"project-crud/apperror"
+	"project-crud/config"
+	"project-crud/models"
+	"project-crud/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Field yang tidak dicatat pada diff karena sudah tercermin di audit log itu sendiri
+var auditIgnoredFields = map[string]bool{"updated_at": true, "updated_by": true, "version": true}
+
+// Field yang perubahannya dicatat tanpa nilai
+var auditRedactedFields = map[string]bool{
+	"pass":               true,
+	"mfa.secret":         true,
+	"mfa.pending_secret": true,
+	"mfa.recovery_codes": true,
+	"password_history":   true,
+	"secret_hash":        true,
+}
+
+const auditRedacted = "[redacted]"
+
+// auditListSpec adalah filter dan sort untuk GET /api/admin/audit
+var auditListSpec = listSpec{
+	Filters: map[string]filterType{
+		"actor":     filterString,
+		"actor_id":  filterObjectID,
+		"action":    filterString,
+		"entity":    filterString,
+		"entity_id": filterObjectID,
+		"route":     filterString,
+	},
+	Sorts: []string{"created_at", "actor", "entity"},
+}
+
+// audit mencatat perubahan entitas oleh user yang sedang login. before dan after adalah dokumen
+// sebelum dan sesudah perubahan (nil untuk create/delete). Kegagalan mencatat hanya di-log
+// karena perubahan datanya sudah tersimpan.
+func (ctrl *Controller) audit(c *fiber.Ctx, ctx context.Context, action, entity string, id primitive.ObjectID, before, after interface{}, meta fiber.Map) {
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		log.Println("Audit diff failed:", err)
+	}
+
+	actor, _ := c.Locals("username").(string)
+	actorID, _ := c.Locals("user_id").(primitive.ObjectID)
+	entry := models.AuditLog{
+		ID:        primitive.NewObjectID(),
+		Actor:     actor,
+		ActorID:   actorID,
+		IP:        c.IP(),
+		Method:    c.Method(),
+		Route:     c.Route().Path,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  id,
+		Changes:   changes,
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now().In(config.Location())),
+	}
+	if len(meta) > 0 {
+		entry.Meta = meta
+	}
+	if err := ctrl.Audit.Record(ctx, &entry); err != nil {
+		log.Println("Failed to record audit log:", err)
+	}
+}
+
+// auditUserUpdate mencatat perubahan user dengan membaca ulang kondisi setelah update
+func (ctrl *Controller) auditUserUpdate(c *fiber.Ctx, ctx context.Context, id primitive.ObjectID, before *models.User) {
+	after, err := ctrl.Users.FindByID(ctx, id)
+	if err != nil {
+		log.Println("Audit skipped, failed to fetch updated user:", err)
+		return
+	}
+	ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityUser, id, before, after, nil)
+}
+
+// auditDiff membandingkan before dan after per field bson dan mengembalikan field yang berbeda
+func auditDiff(before, after interface{}) ([]models.AuditChange, error) {
+	old, err := flattenDocument(before)
+	if err != nil {
+		return []models.AuditChange{}, err
+	}
+	current, err := flattenDocument(after)
+	if err != nil {
+		return []models.AuditChange{}, err
+	}
+
+	fields := []string{}
+	for field := range old {
+		fields = append(fields, field)
+	}
+	for field := range current {
+		if _, ok := old[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []models.AuditChange{}
+	for _, field := range fields {
+		if auditIgnoredFields[field] || reflect.DeepEqual(old[field], current[field]) {
+			continue
+		}
+		change := models.AuditChange{Field: field, Before: old[field], After: current[field]}
+		if auditRedactedFields[field] {
+			change.Before, change.After = redact(change.Before), redact(change.After)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// flattenDocument mengubah dokumen menjadi map field bson. Dokumen bertingkat diratakan
+// dengan nama "induk.anak", sedangkan array dibandingkan utuh.
+func flattenDocument(doc interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if doc == nil {
+		return fields, nil
+	}
+	if v := reflect.ValueOf(doc); v.Kind() == reflect.Ptr && v.IsNil() {
+		return fields, nil
+	}
+
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var m bson.M
+	if err := bson.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+
+	var walk func(prefix string, m bson.M)
+	walk = func(prefix string, m bson.M) {
+		for key, value := range m {
+			if nested, ok := value.(bson.M); ok {
+				walk(prefix+key+".", nested)
+				continue
+			}
+			fields[prefix+key] = normalizeValue(value)
+		}
+	}
+	walk("", m)
+	return fields, nil
+}
+
+// normalizeValue mengubah dokumen bson.D (termasuk di dalam array) menjadi map agar tampil sebagai objek di JSON
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.A:
+		items := make(bson.A, len(v))
+		for i, item := range v {
+			items[i] = normalizeValue(item)
+		}
+		return items
+	case bson.D:
+		doc := bson.M{}
+		for _, e := range v {
+			doc[e.Key] = normalizeValue(e.Value)
+		}
+		return doc
+	case bson.M:
+		doc := bson.M{}
+		for key, item := range v {
+			doc[key] = normalizeValue(item)
+		}
+		return doc
+	}
+	return value
+}
+
+func redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return auditRedacted
+}
+
+// parseAuditRange membaca from dan to (RFC3339 atau YYYY-MM-DD pada zona waktu aplikasi).
+// Tanggal pada to ikut disertakan seluruhnya.
+func parseAuditRange(c *fiber.Ctx) (repository.AuditRange, error) {
+	var r repository.AuditRange
+	parse := func(name string, endOfDay bool) (time.Time, error) {
+		raw := c.Query(name)
+		if raw == "" {
+			return time.Time{}, nil
+		}
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		t, err := time.ParseInLocation("2006-01-02", raw, config.Location())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s must be RFC3339 or YYYY-MM-DD", name)
+		}
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	var err error
+	if r.From, err = parse("from", false); err != nil {
+		return r, err
+	}
+	if r.To, err = parse("to", true); err != nil {
+		return r, err
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return r, fmt.Errorf("from must be before to")
+	}
+	return r, nil
+}
+
+
+// GetAuditLogs menampilkan audit log, terbaru lebih dulu jika sort tidak diisi.
+// Filter: actor, actor_id, action, entity, entity_id, route, from, to.
+func (ctrl *Controller) GetAuditLogs(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	q, err := parseListQuery(c, auditListSpec)
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+	}
+	if c.Query("sort") == "" {
+		q.Desc = true
+	}
+
+	r, err := parseAuditRange(c)
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+	}
+
+	entries, err := ctrl.Audit.List(ctx, r, q)
+	if err != nil {
+		if err == repository.ErrInvalidCursor {
+			return apperror.BadRequest(apperror.CodeInvalidCursor, "Invalid cursor")
+		}
+		return apperror.Wrap(err)
+	}
+
+	// Nilai bertipe bebas dibaca kembali dari BSON sebagai bson.D, ubah ke objek untuk JSON
+	for i := range entries.Items {
+		entry := &entries.Items[i]
+		for j := range entry.Changes {
+			entry.Changes[j].Before = normalizeValue(entry.Changes[j].Before)
+			entry.Changes[j].After = normalizeValue(entry.Changes[j].After)
+		}
+		for key, value := range entry.Meta {
+			entry.Meta[key] = normalizeValue(value)
+		}
+	}
+	return listResponse(c, q, entries)
+}
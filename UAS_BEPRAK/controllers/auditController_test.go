@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"project-crud/models"
+)
+
+func TestAuditDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   []models.AuditChange
+	}{
+		{
+			name:   "tidak ada perubahan",
+			before: bson.M{"name": "Alpha", "tags": bson.A{"a", "b"}},
+			after:  bson.M{"name": "Alpha", "tags": bson.A{"a", "b"}},
+			want:   []models.AuditChange{},
+		},
+		{
+			name:   "field berubah, urut berdasarkan nama",
+			before: bson.M{"name": "Alpha", "description": "lama", "alamat_url": "/a"},
+			after:  bson.M{"name": "Beta", "description": "baru", "alamat_url": "/a"},
+			want: []models.AuditChange{
+				{Field: "description", Before: "lama", After: "baru"},
+				{Field: "name", Before: "Alpha", After: "Beta"},
+			},
+		},
+		{
+			name:   "field ditambah dan dihapus",
+			before: bson.M{"phone": "0812"},
+			after:  bson.M{"email": "budi@unair.ac.id"},
+			want: []models.AuditChange{
+				{Field: "email", Before: nil, After: "budi@unair.ac.id"},
+				{Field: "phone", Before: "0812", After: nil},
+			},
+		},
+		{
+			name:   "field metadata diabaikan",
+			before: bson.M{"name": "Alpha", "updated_at": "kemarin", "updated_by": "budi", "version": "1"},
+			after:  bson.M{"name": "Alpha", "updated_at": "hari ini", "updated_by": "cici", "version": "2"},
+			want:   []models.AuditChange{},
+		},
+		{
+			name:   "password disamarkan",
+			before: bson.M{"pass": "$argon2id$lama", "password_history": bson.A{}},
+			after:  bson.M{"pass": "$argon2id$baru", "password_history": bson.A{"$argon2id$lama"}},
+			want: []models.AuditChange{
+				{Field: "pass", Before: auditRedacted, After: auditRedacted},
+				{Field: "password_history", Before: auditRedacted, After: auditRedacted},
+			},
+		},
+		{
+			name:   "dokumen bertingkat diratakan, secret MFA disamarkan",
+			before: bson.M{"mfa": bson.M{"enabled": false, "pending_secret": "ABC"}},
+			after:  bson.M{"mfa": bson.M{"enabled": true, "secret": "ABC"}},
+			want: []models.AuditChange{
+				{Field: "mfa.enabled", Before: false, After: true},
+				{Field: "mfa.pending_secret", Before: auditRedacted, After: nil},
+				{Field: "mfa.secret", Before: nil, After: auditRedacted},
+			},
+		},
+		{
+			name:   "array dibandingkan utuh",
+			before: bson.M{"tags": bson.A{"a", "b"}},
+			after:  bson.M{"tags": bson.A{"b", "a"}},
+			want: []models.AuditChange{
+				{Field: "tags", Before: bson.A{"a", "b"}, After: bson.A{"b", "a"}},
+			},
+		},
+		{
+			name:   "dokumen di dalam array menjadi objek",
+			before: bson.M{"user_modul": bson.A{}},
+			after:  bson.M{"user_modul": bson.A{bson.D{{Key: "nm_modul", Value: "Alpha"}}}},
+			want: []models.AuditChange{
+				{Field: "user_modul", Before: bson.A{}, After: bson.A{bson.M{"nm_modul": "Alpha"}}},
+			},
+		},
+		{
+			name:   "create tanpa before",
+			before: nil,
+			after:  bson.M{"name": "Alpha", "version": "1"},
+			want: []models.AuditChange{
+				{Field: "name", Before: nil, After: "Alpha"},
+			},
+		},
+		{
+			name:   "delete dengan after pointer nil",
+			before: bson.M{"name": "Alpha"},
+			after:  (*models.Modul)(nil),
+			want: []models.AuditChange{
+				{Field: "name", Before: "Alpha", After: nil},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auditDiff(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("auditDiff() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("auditDiff() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditDiffStruct(t *testing.T) {
+	before := models.Modul{Name: "Alpha", Description: "lama", Version: 1}
+	after := before
+	after.Description, after.Version = "baru", 2
+
+	got, err := auditDiff(&before, &after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.AuditChange{{Field: "description", Before: "lama", After: "baru"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("auditDiff() = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,354 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
+	"project-crud/middleware"
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// LoginMFA adalah langkah kedua login: menukar token mfa_pending dan kode TOTP (atau recovery code)
+// dengan access token. Untuk token dengan enroll=true, kode dipakai untuk mengaktifkan secret yang
+// didaftarkan lewat LoginMFAEnroll dan recovery code ikut dikembalikan sekali.
+func (ctrl *Controller) LoginMFA(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var request struct {
+        MFAToken     string `json:"mfa_token"`
+        Code         string `json:"code"`
+        RecoveryCode string `json:"recovery_code"`
+    }
+    if err := c.BodyParser(&request); err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+    }
+    if request.MFAToken == "" {
+        return apperror.BadRequest(apperror.CodeBadRequest, "MFA token is required")
+    }
+    if request.Code == "" && request.RecoveryCode == "" {
+        return apperror.BadRequest(apperror.CodeBadRequest, "Code or recovery code is required")
+    }
+
+    claims, userID, err := ctrl.Auth.ParseMFAToken(request.MFAToken)
+    if err != nil {
+        return err
+    }
+
+    // Tebakan kode dihitung bersama tebakan password
+    ip := c.IP()
+    if err := ctrl.checkLoginBlocked(c, ctx, claims.Subject, ip); err != nil {
+        return err
+    }
+
+    user, err := ctrl.mfaTokenUser(ctx, userID)
+    if err != nil {
+        return err
+    }
+
+    var recoveryCodes []string
+    if claims.Enroll {
+        recoveryCodes, err = ctrl.Auth.ConfirmMFAEnrollment(ctx, *user, request.Code)
+    } else {
+        err = ctrl.Auth.VerifyMFA(ctx, *user, request.Code, request.RecoveryCode)
+    }
+    if err != nil {
+        switch err {
+        case middleware.ErrInvalidMFACode:
+            ctrl.recordLogin(c, ctx, claims.Subject, user.ID, models.LoginResultInvalidMFACode)
+            if err := ctrl.Auth.LoginFailed(ctx, claims.Subject, ip); err != nil {
+                return apperror.Wrap(err)
+            }
+            return apperror.Unauthorized(middleware.ErrCodeMFAInvalidCode, err.Error())
+        case middleware.ErrMFANoPendingSecret:
+            return apperror.BadRequest(middleware.ErrCodeMFANotPending, "Start two-factor enrollment first")
+        case middleware.ErrMFANotEnabled, middleware.ErrMFAAlreadyEnabled:
+            // Status MFA user berubah sejak token diterbitkan, ulangi login
+            return apperror.Unauthorized(middleware.ErrCodeTokenInvalid, "MFA token is no longer valid")
+        }
+        return apperror.Wrap(err)
+    }
+
+    role, err := ctrl.Roles.FindByID(ctx, user.RoleID)
+    if err != nil {
+        return apperror.Internal("Failed to retrieve role").Wrap(err)
+    }
+    jenisUser, err := ctrl.JenisUsers.FindByID(ctx, user.JenisUserID)
+    if err != nil {
+        return apperror.Internal("Failed to retrieve jenis user").Wrap(err)
+    }
+
+    var extra fiber.Map
+    if recoveryCodes != nil {
+        extra = fiber.Map{"recovery_codes": recoveryCodes}
+    }
+    return ctrl.completeLogin(c, ctx, claims.Subject, user, role, jenisUser, extra)
+}
+
+
+// LoginMFAEnroll membuat secret TOTP untuk user yang role-nya mewajibkan MFA tetapi belum
+// mendaftarkan authenticator. Hanya menerima token mfa_pending dengan enroll=true.
+func (ctrl *Controller) LoginMFAEnroll(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var request struct {
+        MFAToken string `json:"mfa_token"`
+    }
+    if err := c.BodyParser(&request); err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+    }
+
+    claims, userID, err := ctrl.Auth.ParseMFAToken(request.MFAToken)
+    if err != nil {
+        return err
+    }
+    if !claims.Enroll {
+        return apperror.BadRequest(middleware.ErrCodeMFANotPending, "Two-factor enrollment is not required")
+    }
+
+    user, err := ctrl.mfaTokenUser(ctx, userID)
+    if err != nil {
+        return err
+    }
+
+    secret, uri, err := ctrl.Auth.StartMFAEnrollment(ctx, *user)
+    if err != nil {
+        if err == middleware.ErrMFAAlreadyEnabled {
+            return apperror.Unauthorized(middleware.ErrCodeTokenInvalid, "MFA token is no longer valid")
+        }
+        return apperror.Wrap(err)
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{"secret": secret, "otpauth_uri": uri})
+}
+
+// mfaTokenUser mengambil user pemilik token mfa_pending
+func (ctrl *Controller) mfaTokenUser(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
+    user, err := ctrl.Users.FindByID(ctx, userID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return nil, apperror.Unauthorized(middleware.ErrCodeSessionRevoked, middleware.ErrUserNotFound.Error())
+        }
+        return nil, apperror.Wrap(err)
+    }
+    return user, nil
+}
+
+
+// RefreshToken menukar refresh token dengan pasangan access token dan refresh token baru
+func (ctrl *Controller) RefreshToken(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var request struct {
+        RefreshToken string `json:"refresh_token"`
+    }
+    if err := c.BodyParser(&request); err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+    }
+    if request.RefreshToken == "" {
+        return apperror.BadRequest(apperror.CodeBadRequest, "Refresh token is required")
+    }
+
+    tokens, err := ctrl.Auth.RotateSession(ctx, request.RefreshToken)
+    if err != nil {
+        switch err {
+        case middleware.ErrInvalidRefreshToken:
+            return apperror.Unauthorized(middleware.ErrCodeTokenInvalid, err.Error())
+        case middleware.ErrRefreshTokenReused:
+            return apperror.Unauthorized(middleware.ErrCodeRefreshTokenReused, err.Error())
+        case middleware.ErrSessionRevoked, middleware.ErrUserNotFound:
+            return apperror.Unauthorized(middleware.ErrCodeSessionRevoked, err.Error())
+        }
+        return apperror.Internal("Failed to refresh token")
+    }
+
+    return c.Status(fiber.StatusOK).JSON(tokens)
+}
+
+
+// Logout mencabut sesi yang sedang dipakai, atau semua sesi user jika "all" bernilai true
+func (ctrl *Controller) Logout(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    var request struct {
+        All bool `json:"all"`
+    }
+    // Body bersifat opsional
+    _ = c.BodyParser(&request)
+
+    sessionID, ok := c.Locals("session_id").(primitive.ObjectID)
+    if !ok {
+        return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+    }
+
+    var err error
+    if request.All {
+        userID, _ := c.Locals("user_id").(primitive.ObjectID)
+        err = ctrl.Auth.RevokeUserSessions(ctx, userID)
+    } else {
+        err = ctrl.Auth.RevokeSession(ctx, sessionID)
+    }
+    if err != nil {
+        return apperror.Internal("Failed to logout").Wrap(err)
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Logged out successfully"})
+}
+
+
+// JWKS menerbitkan public key penandatangan token agar aplikasi modul bisa memverifikasi token
+func (ctrl *Controller) JWKS(c *fiber.Ctx) error {
+    c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+    return c.Status(fiber.StatusOK).JSON(ctrl.Auth.JWKS())
+}
+
+
+// loginRecordListSpec adalah filter dan sort untuk GET /api/admin/login-records
+var loginRecordListSpec = listSpec{
+    Filters: map[string]filterType{
+        "username": filterString,
+        "user_id":  filterObjectID,
+        "ip":       filterString,
+        "result":   filterString,
+    },
+    Sorts: []string{"created_at", "username", "ip"},
+}
+
+// recordLogin mencatat percobaan login. Sama seperti audit, kegagalan mencatat hanya di-log.
+func (ctrl *Controller) recordLogin(c *fiber.Ctx, ctx context.Context, username string, userID primitive.ObjectID, result string) {
+    record := models.LoginRecord{
+        Username:  username,
+        IP:        c.IP(),
+        UserAgent: c.Get(fiber.HeaderUserAgent),
+        Result:    result,
+    }
+    if !userID.IsZero() {
+        record.UserID = &userID
+    }
+    if err := ctrl.Auth.RecordLogin(ctx, record); err != nil {
+        log.Println("Failed to record login attempt:", err)
+    }
+}
+
+// checkLoginBlocked menolak login dengan 429 jika username atau IP sedang dikunci
+func (ctrl *Controller) checkLoginBlocked(c *fiber.Ctx, ctx context.Context, username, ip string) error {
+    block, err := ctrl.Auth.LoginBlocked(ctx, username, ip)
+    if err != nil {
+        return apperror.Wrap(err)
+    }
+    if block == nil {
+        return nil
+    }
+    result := models.LoginResultTooManyAttempts
+    if block.Code == middleware.ErrCodeAccountLocked {
+        result = models.LoginResultAccountLocked
+    }
+    ctrl.recordLogin(c, ctx, username, primitive.NilObjectID, result)
+    return loginBlockedError(c, block)
+}
+
+// completeLogin membuat sesi baru setelah semua faktor login terverifikasi. extra ikut
+// ditambahkan ke respons, misalnya recovery code saat MFA baru didaftarkan.
+func (ctrl *Controller) completeLogin(c *fiber.Ctx, ctx context.Context, username string, user *models.User, role *models.Role, jenisUser *models.JenisUser, extra fiber.Map) error {
+    tokens, err := ctrl.Auth.CreateSession(ctx, *user, c.Get(fiber.HeaderUserAgent), c.IP())
+    if err != nil {
+        return apperror.Internal("Failed to generate token").Wrap(err)
+    }
+
+    // Login berhasil, reset penghitung gagal milik username
+    if err := ctrl.Auth.LoginSucceeded(ctx, username); err != nil {
+        log.Println("Failed to reset login failures:", err)
+    }
+    ctrl.recordLogin(c, ctx, username, user.ID, models.LoginResultSuccess)
+
+    response := fiber.Map{
+        "token":         tokens.AccessToken,
+        "refresh_token": tokens.RefreshToken,
+        "expires_in":    tokens.ExpiresIn,
+        "role":          role.Name,
+        "jenis_user":    jenisUser.NmJenisUser,
+    }
+    for key, value := range extra {
+        response[key] = value
+    }
+    return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// loginBlockedError membuat respons 429 dengan header Retry-After dalam detik (dibulatkan ke atas)
+func loginBlockedError(c *fiber.Ctx, block *middleware.LoginBlock) error {
+    seconds := int64((block.RetryAfter + time.Second - 1) / time.Second)
+    c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(seconds, 10))
+
+    detail := "Too many failed login attempts, please try again later"
+    if block.Code == middleware.ErrCodeAccountLocked {
+        detail = "Account is temporarily locked because of too many failed login attempts"
+    }
+    return apperror.TooManyRequests(block.Code, detail).With("retry_after", seconds)
+}
+
+
+// UnlockUser membuka kunci login user dan mereset penghitung login gagalnya
+func (ctrl *Controller) UnlockUser(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    objID, err := primitive.ObjectIDFromHex(c.Params("id"))
+    if err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid user ID")
+    }
+
+    user, err := ctrl.Users.FindByID(ctx, objID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Wrap(err)
+    }
+
+    if err := ctrl.Auth.UnlockLogin(ctx, user.Username); err != nil {
+        return apperror.Internal("Failed to unlock user").Wrap(err)
+    }
+    ctrl.audit(c, ctx, models.AuditActionUnlock, models.AuditEntityUser, objID, nil, nil, fiber.Map{"username": user.Username})
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "User unlocked successfully"})
+}
+
+
+// GetLoginRecords menampilkan catatan percobaan login, terbaru lebih dulu jika sort tidak diisi.
+// Filter: username, user_id, ip, result, from, to.
+func (ctrl *Controller) GetLoginRecords(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    q, err := parseListQuery(c, loginRecordListSpec)
+    if err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+    }
+    if c.Query("sort") == "" {
+        q.Desc = true
+    }
+
+    r, err := parseAuditRange(c)
+    if err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+    }
+
+    records, err := ctrl.Auth.LoginRecords.List(ctx, r, q)
+    if err != nil {
+        if err == repository.ErrInvalidCursor {
+            return apperror.BadRequest(apperror.CodeInvalidCursor, "Invalid cursor")
+        }
+        return apperror.Wrap(err)
+    }
+    return listResponse(c, q, records)
+}
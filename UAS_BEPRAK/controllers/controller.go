@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"project-crud/mailer"
+	"project-crud/middleware"
+	"project-crud/models"
+	"project-crud/password"
+	"project-crud/repository"
+	"project-crud/validation"
+)
+
+// Controller menampung dependency yang dipakai oleh semua handler.
+// Dibuat sekali di main lalu didaftarkan ke route.
+type Controller struct {
+	Users          repository.UserRepository
+	Roles          repository.RoleRepository
+	Moduls         repository.ModulRepository
+	KategoriModuls repository.KategoriModulRepository
+	JenisUsers     repository.JenisUserRepository
+	Portal         repository.PortalRepository
+	Audit          repository.AuditRepository
+	Tx             repository.Transactor
+
+	ModulRevisions     repository.RevisionRepository[models.Modul]
+	JenisUserRevisions repository.RevisionRepository[models.JenisUser]
+
+	Auth           *middleware.Auth
+	Validator      *validation.Validator
+
+	// Pengiriman tautan reset password, diganti di main sesuai konfigurasi
+	Mailer           mailer.Mailer
+	PasswordResetURL string
+
+	// Policy dan algoritma hash untuk setiap password baru, diganti di main sesuai konfigurasi
+	PasswordPolicy *password.Policy
+	Hasher         *password.Hasher
+
+	// Provider OpenID Connect untuk aplikasi modul
+	OAuthClients   repository.OAuthClientRepository
+	OAuthConsents  repository.OAuthConsentRepository
+	OIDCConsentURL string
+}
+
+// NewController membuat Controller dari Store dan middleware Auth
+func NewController(store *repository.Store, auth *middleware.Auth) *Controller {
+	ctrl := &Controller{
+		Users:          store.Users,
+		Roles:          store.Roles,
+		Moduls:         store.Moduls,
+		KategoriModuls: store.KategoriModuls,
+		JenisUsers:     store.JenisUsers,
+		Portal:         store.Portal,
+		Audit:          store.Audit,
+		Tx:             store.Tx,
+
+		ModulRevisions:     store.ModulRevisions,
+		JenisUserRevisions: store.JenisUserRevisions,
+
+		Auth:           auth,
+		Mailer:         mailer.Log{},
+		PasswordPolicy: password.DefaultPolicy(),
+		Hasher:         password.DefaultHasher(),
+
+		OAuthClients:   store.OAuthClients,
+		OAuthConsents:  store.OAuthConsents,
+		OIDCConsentURL: "http://localhost:3000/oauth/consent",
+	}
+	ctrl.Validator = ctrl.newValidator()
+	return ctrl
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
+	"project-crud/middleware"
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// newTestController membuat Controller di atas repository memori
+func newTestController(t *testing.T) (*Controller, *repository.Store) {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	return NewController(store, middleware.NewAuth(store, middleware.DefaultConfig())), store
+}
+
+// newTestApp membuat app fiber dengan error handler aplikasi. Username dan permission role
+// pemanggil diisi seperti setelah JWTAuth dan CheckPermission, default "*".
+func newTestApp(permissions ...string) *fiber.App {
+	if len(permissions) == 0 {
+		permissions = []string{models.PermAll}
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: apperror.Handler(false)})
+	app.Use(middleware.TraceID, func(c *fiber.Ctx) error {
+		c.Locals("username", "admin")
+		c.Locals("permissions", permissions)
+		return c.Next()
+	})
+	return app
+}
+
+// doRequest mengirim request ke app dan mengembalikan status serta body JSON
+func doRequest(t *testing.T, app *fiber.App, method, path string) (int, map[string]interface{}) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(method, path, nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body := map[string]interface{}{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body
+}
+
+// seedModul menyimpan modul baru dengan nama tertentu
+func seedModul(t *testing.T, store *repository.Store, name string) primitive.ObjectID {
+	t.Helper()
+	modul := models.Modul{ID: primitive.NewObjectID(), Name: name}
+	if err := store.Moduls.Create(context.Background(), &modul); err != nil {
+		t.Fatal(err)
+	}
+	return modul.ID
+}
+
+// seedJenisUser menyimpan jenis user baru dengan template modul tertentu
+func seedJenisUser(t *testing.T, store *repository.Store, name string, moduls ...primitive.ObjectID) primitive.ObjectID {
+	t.Helper()
+	jenisUser := models.JenisUser{ID: primitive.NewObjectID(), NmJenisUser: name, TemplateModul: []models.TemplateModul{}}
+	for _, id := range moduls {
+		jenisUser.TemplateModul = append(jenisUser.TemplateModul, models.TemplateModul{ModulID: id})
+	}
+	if err := store.JenisUsers.Create(context.Background(), &jenisUser); err != nil {
+		t.Fatal(err)
+	}
+	return jenisUser.ID
+}
+
+// seedUser menyimpan user baru dengan jenis user dan user_modul tertentu
+func seedUser(t *testing.T, store *repository.Store, username string, jenisUserID primitive.ObjectID, userModuls ...models.UserModul) primitive.ObjectID {
+	t.Helper()
+	user := models.User{
+		ID:          primitive.NewObjectID(),
+		Username:    username,
+		Email:       username + "@unair.ac.id",
+		RoleID:      primitive.NewObjectID(),
+		JenisUserID: jenisUserID,
+		UserModul:   append([]models.UserModul{}, userModuls...),
+	}
+	if err := store.Users.Create(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+// findUser membaca user atau menggagalkan test jika tidak ada
+func findUser(t *testing.T, store *repository.Store, id primitive.ObjectID) *models.User {
+	t.Helper()
+	user, err := store.Users.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("find user %s: %v", id.Hex(), err)
+	}
+	return user
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// Policy penghapusan terhadap dokumen lain yang masih mereferensikan (query param "policy")
+const (
+	policyRestrict = "restrict" // Tolak (409) jika masih ada dependent
+	policyCascade  = "cascade"  // Kosongkan/cabut referensi pada dependent
+	policyReassign = "reassign" // Pindahkan dependent ke ID pengganti ("reassign_to")
+)
+
+// deletePolicy adalah policy yang diminta client
+type deletePolicy struct {
+	Policy     string
+	ReassignTo primitive.ObjectID
+}
+
+// dependent adalah koleksi yang mereferensikan dokumen yang akan dihapus
+type dependent struct {
+	Name  string              // Nama koleksi untuk response, misalnya "users"
+	Repo  repository.Referrer // Repository koleksi tersebut
+	Ref   repository.RefField // Field yang menyimpan ID
+	Extra repository.Fields   // Field tambahan yang ikut di-$set saat reassign
+
+	// RevokeSessions mencabut sesi dependent (user) yang diubah oleh cascade atau reassign,
+	// karena field yang diubah ikut tersimpan sebagai claim di token
+	RevokeSessions bool
+}
+
+// errHasDependents membatalkan transaksi pada policy restrict
+var errHasDependents = errors.New("document still has dependents")
+
+// codeHasDependents adalah kode error saat penghapusan ditolak oleh policy restrict
+const codeHasDependents = "has_dependents"
+
+// parseDeletePolicy membaca policy dan reassign_to dari query string, default restrict
+func parseDeletePolicy(c *fiber.Ctx, id primitive.ObjectID) (deletePolicy, error) {
+	p := deletePolicy{Policy: c.Query("policy", policyRestrict)}
+	switch p.Policy {
+	case policyRestrict, policyCascade:
+		return p, nil
+	case policyReassign:
+		target, err := primitive.ObjectIDFromHex(c.Query("reassign_to"))
+		if err != nil {
+			return p, fmt.Errorf("reassign_to must be a valid ID when policy is reassign")
+		}
+		if target == id {
+			return p, fmt.Errorf("reassign_to must be different from the deleted ID")
+		}
+		p.ReassignTo = target
+		return p, nil
+	}
+	return p, fmt.Errorf("policy must be one of: %s, %s, %s", policyRestrict, policyCascade, policyReassign)
+}
+
+// deleteWithPolicy menghapus dokumen id dengan remove lalu menangani dependents sesuai policy.
+// Cascade dan reassign mengubah dokumen lain sehingga dijalankan dalam satu transaksi; restrict
+// hanya membaca dependents lalu menghapus satu dokumen sehingga tidak membutuhkan transaksi.
+// remove mengembalikan dokumen sebelum dihapus untuk audit log, atau repository.ErrNotFound
+// jika dokumen tidak ada.
+func (ctrl *Controller) deleteWithPolicy(c *fiber.Ctx, ctx context.Context, p deletePolicy, id primitive.ObjectID, entity string, dependents []dependent, remove func(ctx context.Context) (interface{}, error), notFoundMessage, successMessage string) error {
+	var found, affected fiber.Map
+	var removed interface{}
+	run := func(ctx context.Context) error {
+		// Transaksi bisa diulang oleh driver, jadi hasil selalu dihitung ulang
+		found, affected, removed = fiber.Map{}, fiber.Map{}, nil
+
+		for _, d := range dependents {
+			var ids []primitive.ObjectID
+			var err error
+			switch p.Policy {
+			case policyRestrict:
+				ids, err = d.Repo.FindReferencing(ctx, d.Ref, id)
+			case policyCascade:
+				ids, err = d.Repo.ClearReference(ctx, d.Ref, id)
+			case policyReassign:
+				ids, err = d.Repo.ReplaceReference(ctx, d.Ref, id, p.ReassignTo, d.Extra)
+			}
+			if err != nil {
+				return err
+			}
+			if len(ids) > 0 {
+				found[d.Name] = ids
+			}
+		}
+
+		if p.Policy == policyRestrict && len(found) > 0 {
+			return errHasDependents
+		}
+		affected = found
+		var err error
+		removed, err = remove(ctx)
+		return err
+	}
+
+	var err error
+	if p.Policy == policyRestrict {
+		err = run(ctx)
+	} else {
+		err = ctrl.Tx.WithTransaction(ctx, run)
+	}
+
+	if err != nil {
+		switch err {
+		case errHasDependents:
+			return apperror.Conflict(codeHasDependents, "Cannot delete, still referenced by other documents").With("dependents", found)
+		case repository.ErrNotFound:
+			return apperror.NotFound(notFoundMessage)
+		}
+		return apperror.Wrap(err)
+	}
+
+	for _, d := range dependents {
+		if !d.RevokeSessions || p.Policy == policyRestrict {
+			continue
+		}
+		ids, _ := affected[d.Name].([]primitive.ObjectID)
+		for _, userID := range ids {
+			if err := ctrl.Auth.RevokeUserSessions(ctx, userID); err != nil {
+				return apperror.Internal("Failed to revoke user sessions").Wrap(err)
+			}
+		}
+	}
+
+	if removed != nil {
+		ctrl.audit(c, ctx, models.AuditActionDelete, entity, id, removed, nil, fiber.Map{
+			"policy":   p.Policy,
+			"affected": affected,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message":  successMessage,
+		"policy":   p.Policy,
+		"affected": affected,
+	})
+}
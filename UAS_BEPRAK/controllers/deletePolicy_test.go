@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// seedSession menyimpan sesi aktif untuk user
+func seedSession(t *testing.T, store *repository.Store, userID primitive.ObjectID) primitive.ObjectID {
+	t.Helper()
+	session := models.Session{
+		ID:             primitive.NewObjectID(),
+		UserID:         userID,
+		PreviousHashes: []string{},
+		ExpiresAt:      primitive.NewDateTimeFromTime(time.Now().Add(time.Hour)),
+	}
+	if err := store.Sessions.Create(context.Background(), &session); err != nil {
+		t.Fatal(err)
+	}
+	return session.ID
+}
+
+func TestDeleteJenisUserPolicy(t *testing.T) {
+	// Jenis user "mhs" dipakai budi, "dosen" tidak dipakai siapa pun dan "pegawai" menjadi pengganti
+	type fixture struct {
+		mhs, dosen, pegawai primitive.ObjectID
+	}
+	tests := []struct {
+		name          string
+		target        func(f fixture) primitive.ObjectID
+		query         func(f fixture) string
+		wantStatus    int
+		wantDeleted   bool
+		wantJenisUser func(f fixture) primitive.ObjectID
+		wantRevoked   bool
+	}{
+		{
+			name:          "restrict ditolak jika masih dipakai",
+			target:        func(f fixture) primitive.ObjectID { return f.mhs },
+			query:         func(f fixture) string { return "" },
+			wantStatus:    http.StatusConflict,
+			wantJenisUser: func(f fixture) primitive.ObjectID { return f.mhs },
+		},
+		{
+			name:          "restrict tanpa dependent",
+			target:        func(f fixture) primitive.ObjectID { return f.dosen },
+			query:         func(f fixture) string { return "?policy=restrict" },
+			wantStatus:    http.StatusOK,
+			wantDeleted:   true,
+			wantJenisUser: func(f fixture) primitive.ObjectID { return f.mhs },
+		},
+		{
+			name:          "cascade mengosongkan jenis user",
+			target:        func(f fixture) primitive.ObjectID { return f.mhs },
+			query:         func(f fixture) string { return "?policy=cascade" },
+			wantStatus:    http.StatusOK,
+			wantDeleted:   true,
+			wantJenisUser: func(f fixture) primitive.ObjectID { return primitive.NilObjectID },
+			wantRevoked:   true,
+		},
+		{
+			name:          "reassign memindahkan user",
+			target:        func(f fixture) primitive.ObjectID { return f.mhs },
+			query:         func(f fixture) string { return "?policy=reassign&reassign_to=" + f.pegawai.Hex() },
+			wantStatus:    http.StatusOK,
+			wantDeleted:   true,
+			wantJenisUser: func(f fixture) primitive.ObjectID { return f.pegawai },
+			wantRevoked:   true,
+		},
+		{
+			name:          "reassign ke jenis user yang tidak ada",
+			target:        func(f fixture) primitive.ObjectID { return f.mhs },
+			query:         func(f fixture) string { return "?policy=reassign&reassign_to=" + primitive.NewObjectID().Hex() },
+			wantStatus:    http.StatusBadRequest,
+			wantJenisUser: func(f fixture) primitive.ObjectID { return f.mhs },
+		},
+		{
+			name:          "reassign ke dirinya sendiri",
+			target:        func(f fixture) primitive.ObjectID { return f.mhs },
+			query:         func(f fixture) string { return "?policy=reassign&reassign_to=" + f.mhs.Hex() },
+			wantStatus:    http.StatusBadRequest,
+			wantJenisUser: func(f fixture) primitive.ObjectID { return f.mhs },
+		},
+		{
+			name:          "policy tidak dikenal",
+			target:        func(f fixture) primitive.ObjectID { return f.mhs },
+			query:         func(f fixture) string { return "?policy=force" },
+			wantStatus:    http.StatusBadRequest,
+			wantJenisUser: func(f fixture) primitive.ObjectID { return f.mhs },
+		},
+		{
+			name:          "jenis user tidak ada",
+			target:        func(f fixture) primitive.ObjectID { return primitive.NewObjectID() },
+			query:         func(f fixture) string { return "?policy=cascade" },
+			wantStatus:    http.StatusNotFound,
+			wantJenisUser: func(f fixture) primitive.ObjectID { return f.mhs },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, store := newTestController(t)
+			ctx := context.Background()
+			app := newTestApp()
+			app.Delete("/jenis-users/:id", ctrl.DeleteJenisUser)
+
+			f := fixture{
+				mhs:     seedJenisUser(t, store, "mhs"),
+				dosen:   seedJenisUser(t, store, "dosen"),
+				pegawai: seedJenisUser(t, store, "pegawai"),
+			}
+			budi := seedUser(t, store, "budi", f.mhs)
+			session := seedSession(t, store, budi)
+
+			target := tt.target(f)
+			status, body := doRequest(t, app, http.MethodDelete, "/jenis-users/"+target.Hex()+tt.query(f))
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, body)
+			}
+
+			if status != http.StatusNotFound {
+				_, err := store.JenisUsers.FindByID(ctx, target)
+				if deleted := err == repository.ErrNotFound; deleted != tt.wantDeleted {
+					t.Errorf("jenis user deleted = %v, want %v", deleted, tt.wantDeleted)
+				}
+			}
+			if got := findUser(t, store, budi).JenisUserID; got != tt.wantJenisUser(f) {
+				t.Errorf("jenis_user_id = %s, want %s", got.Hex(), tt.wantJenisUser(f).Hex())
+			}
+			s, err := store.Sessions.FindByID(ctx, session)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked := s.RevokedAt != nil; revoked != tt.wantRevoked {
+				t.Errorf("session revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+func TestDeleteModulPolicy(t *testing.T) {
+	// Modul A ada di template "mhs" dan user_modul budi, modul B menjadi pengganti
+	tests := []struct {
+		name         string
+		query        func(b primitive.ObjectID) string
+		wantStatus   int
+		wantTemplate func(a, b primitive.ObjectID) []primitive.ObjectID
+		wantNames    []string
+	}{
+		{
+			name:         "restrict ditolak",
+			query:        func(b primitive.ObjectID) string { return "" },
+			wantStatus:   http.StatusConflict,
+			wantTemplate: func(a, b primitive.ObjectID) []primitive.ObjectID { return []primitive.ObjectID{a} },
+			wantNames:    []string{"A"},
+		},
+		{
+			name:         "cascade mencabut dari template dan user",
+			query:        func(b primitive.ObjectID) string { return "?policy=cascade" },
+			wantStatus:   http.StatusOK,
+			wantTemplate: func(a, b primitive.ObjectID) []primitive.ObjectID { return []primitive.ObjectID{} },
+			wantNames:    []string{},
+		},
+		{
+			name:         "reassign mengganti modul dan nama modul",
+			query:        func(b primitive.ObjectID) string { return "?policy=reassign&reassign_to=" + b.Hex() },
+			wantStatus:   http.StatusOK,
+			wantTemplate: func(a, b primitive.ObjectID) []primitive.ObjectID { return []primitive.ObjectID{b} },
+			wantNames:    []string{"B"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, store := newTestController(t)
+			ctx := context.Background()
+			app := newTestApp()
+			app.Delete("/moduls/:id", ctrl.DeleteModul)
+
+			a, b := seedModul(t, store, "A"), seedModul(t, store, "B")
+			mhs := seedJenisUser(t, store, "mhs", a)
+			budi := seedUser(t, store, "budi", mhs, models.UserModul{ModulID: a, NamaModul: "A", Source: models.UserModulSourceTemplate})
+
+			status, body := doRequest(t, app, http.MethodDelete, "/moduls/"+a.Hex()+tt.query(b))
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, body)
+			}
+
+			jenisUser, err := store.JenisUsers.FindByID(ctx, mhs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			template := []primitive.ObjectID{}
+			for _, tmpl := range jenisUser.TemplateModul {
+				template = append(template, tmpl.ModulID)
+			}
+			if want := tt.wantTemplate(a, b); !reflect.DeepEqual(template, want) {
+				t.Errorf("template_modul = %v, want %v", template, want)
+			}
+
+			names := []string{}
+			for _, modul := range findUser(t, store, budi).UserModul {
+				names = append(names, modul.NamaModul)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("user_modul = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestDeleteRoleReassignGrantable(t *testing.T) {
+	// Pemanggil hanya memegang role:delete dan user:read, sehingga user dari role yang dihapus
+	// tidak boleh dipindah ke role dengan permission di luar itu
+	tests := []struct {
+		name        string
+		permissions []string
+		wantStatus  int
+	}{
+		{"role pengganti dengan permission yang dimiliki", []string{models.PermUserRead}, http.StatusOK},
+		{"role pengganti super admin", []string{models.PermAll}, http.StatusForbidden},
+		{"role pengganti dengan permission lain", []string{models.PermUserRead, models.PermUserDelete}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, store := newTestController(t)
+			ctx := context.Background()
+			app := newTestApp(models.PermRoleDelete, models.PermUserRead)
+			app.Delete("/roles/:id", ctrl.DeleteRole)
+
+			oldRole := models.Role{ID: primitive.NewObjectID(), Name: "lama", Permissions: []string{}}
+			replacement := models.Role{ID: primitive.NewObjectID(), Name: "pengganti", Permissions: tt.permissions}
+			for _, role := range []*models.Role{&oldRole, &replacement} {
+				if err := store.Roles.Create(ctx, role); err != nil {
+					t.Fatal(err)
+				}
+			}
+			budi := seedUser(t, store, "budi", primitive.NewObjectID())
+			if err := store.Users.Update(ctx, budi, repository.AnyVersion, repository.Fields{"role_id": oldRole.ID}); err != nil {
+				t.Fatal(err)
+			}
+
+			status, body := doRequest(t, app, http.MethodDelete, "/roles/"+oldRole.ID.Hex()+"?policy=reassign&reassign_to="+replacement.ID.Hex())
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, body)
+			}
+
+			wantRole := oldRole.ID
+			if tt.wantStatus == http.StatusOK {
+				wantRole = replacement.ID
+			}
+			if got := findUser(t, store, budi).RoleID; got != wantRole {
+				t.Errorf("role_id = %s, want %s", got.Hex(), wantRole.Hex())
+			}
+		})
+	}
+}
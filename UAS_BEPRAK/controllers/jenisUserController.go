@@ -3,37 +3,45 @@ package controllers
 import (
 	"context"
 	"net/http"
+	"project-crud/apperror"
 	"project-crud/config"
 	"project-crud/models"
+	"project-crud/repository"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var jenisUserCollection *mongo.Collection = config.GetCollection("jenis_users")
-
 // CreateJenisUser untuk membuat jenis user baru dengan template modul
-func CreateJenisUser(c *fiber.Ctx) error {
+func (ctrl *Controller) CreateJenisUser(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
     // Ambil username dari user yang sedang login
     loggedInUsername, ok := c.Locals("username").(string)
     if !ok || loggedInUsername == "" {
-        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized access"})
+        return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+    }
+
+    var input createJenisUserInput
+    if err := c.BodyParser(&input); err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
     }
 
-    var jenisUser models.JenisUser
-    if err := c.BodyParser(&jenisUser); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    // Validasi input, setiap modul di template_modul harus ada
+    if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+        return err
+    }
+    jenisUser := models.JenisUser{NmJenisUser: input.NmJenisUser, TemplateModul: []models.TemplateModul{}}
+    for _, modul := range input.TemplateModul {
+        modulID, _ := primitive.ObjectIDFromHex(modul.ModulID)
+        jenisUser.TemplateModul = append(jenisUser.TemplateModul, models.TemplateModul{ModulID: modulID})
     }
 
     // Set data tambahan untuk jenis user
     jenisUser.ID = primitive.NewObjectID()
-    loc, _ := time.LoadLocation("Asia/Jakarta")
+    loc := config.Location()
     now := primitive.NewDateTimeFromTime(time.Now().In(loc))
     jenisUser.CreatedAt = now
     jenisUser.UpdatedAt = now
@@ -41,56 +49,43 @@ func CreateJenisUser(c *fiber.Ctx) error {
      jenisUser.CreatedBy = loggedInUsername
      jenisUser.UpdatedBy = loggedInUsername
 
-    // Validasi dan simpan modul
-    for _, templateModul := range jenisUser.TemplateModul {
-        if templateModul.ModulID.IsZero() {
-            return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Modul ID in template_modul"})
-        }
-    }
-
     // Simpan ke database
-    _, err := jenisUserCollection.InsertOne(ctx, jenisUser)
+    err := ctrl.JenisUsers.Create(ctx, &jenisUser)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.Wrap(err)
     }
+    ctrl.audit(c, ctx, models.AuditActionCreate, models.AuditEntityJenisUser, jenisUser.ID, nil, jenisUser, nil)
 
     return c.Status(http.StatusCreated).JSON(jenisUser)
 }
 
 
 // GetAllJenisUser untuk mendapatkan semua jenis user
-func GetAllJenisUser(c *fiber.Ctx) error {
+func (ctrl *Controller) GetAllJenisUser(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    // Ambil semua jenis user dari koleksi
-    var jenisUsers []models.JenisUser
-    cursor, err := jenisUserCollection.Find(ctx, bson.M{})
+    // Ambil satu halaman jenis user sesuai sort dan paginasi
+    q, err := parseListQuery(c, jenisUserListSpec)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
     }
-    defer cursor.Close(ctx)
 
-    // Decode hasil query ke dalam slice jenisUsers
-    for cursor.Next(ctx) {
-        var jenisUser models.JenisUser
-        if err := cursor.Decode(&jenisUser); err != nil {
-            return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+    jenisUsers, err := ctrl.JenisUsers.List(ctx, q)
+    if err != nil {
+        if err == repository.ErrInvalidCursor {
+            return apperror.BadRequest(apperror.CodeInvalidCursor, "Invalid cursor")
         }
-        jenisUsers = append(jenisUsers, jenisUser)
-    }
-
-    if err := cursor.Err(); err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.Wrap(err)
     }
 
-    // Kembalikan response
-    return c.Status(http.StatusOK).JSON(jenisUsers)
+    // Kembalikan response beserta meta paginasi
+    return listResponse(c, q, jenisUsers)
 }
 
 
 // GetJenisUserByID untuk mendapatkan jenis user berdasarkan ID
-func GetJenisUserByID(c *fiber.Ctx) error {
+func (ctrl *Controller) GetJenisUserByID(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -98,26 +93,28 @@ func GetJenisUserByID(c *fiber.Ctx) error {
     id := c.Params("id")
     objectID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
     }
 
     // Cari jenis user berdasarkan ID
-    var jenisUser models.JenisUser
-    err = jenisUserCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&jenisUser)
+    jenisUser, err := ctrl.JenisUsers.FindByID(ctx, objectID)
     if err != nil {
-        if err == mongo.ErrNoDocuments {
-            return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Jenis user not found"})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Jenis user not found")
         }
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.Wrap(err)
     }
 
     // Kembalikan response
+    setVersionETag(c, jenisUser.Version)
     return c.Status(http.StatusOK).JSON(jenisUser)
 }
 
 
-// EditJenisUser untuk mengedit jenis user berdasarkan ID
-func EditJenisUser(c *fiber.Ctx) error {
+// EditJenisUser untuk mengedit jenis user berdasarkan ID.
+// Dengan ?sync=apply modul baru pada template juga ditambahkan ke user yang sudah ada,
+// dengan ?sync=dry_run hanya ditampilkan user yang akan terdampak tanpa mengubah data.
+func (ctrl *Controller) EditJenisUser(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -125,27 +122,41 @@ func EditJenisUser(c *fiber.Ctx) error {
     id := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
     }
 
     // Parse input dari request body
-    var input models.JenisUser
+    var input editJenisUserInput
     if err := c.BodyParser(&input); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
+    }
+
+    // Validasi input, setiap modul di template_modul harus ada
+    if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+        return err
+    }
+    templateModul := []models.TemplateModul{}
+    for _, modul := range input.TemplateModul {
+        modulID, _ := primitive.ObjectIDFromHex(modul.ModulID)
+        templateModul = append(templateModul, models.TemplateModul{ModulID: modulID})
+    }
+
+    // Mode sinkronisasi ke user yang sudah ada
+    mode, err := parseSyncMode(c, syncNone)
+    if err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
     }
 
     // Ambil username dari middleware JWT
     updatedBy := c.Locals("username").(string)
 
     // Set data tambahan
-    loc, err := time.LoadLocation("Asia/Jakarta")
-    if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load time location"})
-    }
+    loc := config.Location()
+    now := primitive.NewDateTimeFromTime(time.Now().In(loc))
 
-    updateFields := bson.M{
+    updateFields := repository.Fields{
         "updated_by": updatedBy,
-        "updated_at": primitive.NewDateTimeFromTime(time.Now().In(loc)),
+        "updated_at": now,
     }
 
     // Update hanya field yang diisi
@@ -153,46 +164,93 @@ func EditJenisUser(c *fiber.Ctx) error {
         updateFields["nm_jenis_user"] = input.NmJenisUser
     }
 
-    // Query update utama
-    updateQuery := bson.M{"$set": updateFields}
+    // Modul harus ada sebelum masuk template maupun disalin ke user
+    plan := templateSyncPlan{Add: templateModul}
+    if err := ctrl.checkTemplateModuls(ctx, templateModul); err != nil {
+        return syncError(c, err)
+    }
 
-    // Jika ada template_modul, tambahkan tanpa menghapus yang lama
-    if len(input.TemplateModul) > 0 {
-        for _, modul := range input.TemplateModul {
-            if modul.ModulID.IsZero() || !primitive.IsValidObjectID(modul.ModulID.Hex()) {
-                return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid modul_id format"})
+    // Dry-run tidak mengubah template maupun user
+    if mode == syncDryRun {
+        jenisUser, err := ctrl.JenisUsers.FindByID(ctx, objID)
+        if err != nil {
+            if err == repository.ErrNotFound {
+                return apperror.NotFound("Jenis user not found")
             }
+            return apperror.Wrap(err)
         }
 
-        updateQuery["$addToSet"] = bson.M{
-            "template_modul": bson.M{"$each": input.TemplateModul},
+        result, err := ctrl.syncTemplateModul(ctx, objID, plan, mode, updatedBy, now)
+        if err != nil {
+            return syncError(c, err)
         }
+        return c.Status(http.StatusOK).JSON(fiber.Map{"data": jenisUser, "sync": result})
     }
 
-    // Update data di database
-    result, err := jenisUserCollection.UpdateOne(ctx, bson.M{"_id": objID}, updateQuery)
+    // Versi dari If-Match, update ditolak jika dokumen sudah diubah pihak lain
+    version, ok := ifMatchVersion(c)
+    if !ok {
+        return preconditionFailed()
+    }
+
+    // Simpan kondisi sebelum diubah untuk audit log dan riwayat revisi
+    before, err := ctrl.JenisUsers.FindByID(ctx, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Jenis user not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if !versionMatches(version, before.Version) {
+        return preconditionFailed()
     }
 
-    if result.MatchedCount == 0 {
-        return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Jenis user not found"})
+    // Update data di database
+    // Update data di database bersama revisinya. Jika ada template_modul,
+    // tambahkan tanpa menghapus yang lama
+    err = ctrl.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+        if err := ctrl.JenisUsers.Update(ctx, objID, version, updateFields, templateModul); err != nil {
+            return err
+        }
+        return recordRevision(c, ctx, ctrl.JenisUserRevisions, objID, *before, models.RevisionActionUpdate, 0)
+    })
+    if err != nil {
+        if err == repository.ErrVersionConflict {
+            return preconditionFailed()
+        }
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Jenis user not found")
+        }
+        return apperror.Wrap(err)
     }
 
     // Ambil data terbaru setelah update
-    var updatedJenisUser models.JenisUser
-    err = jenisUserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&updatedJenisUser)
+    updatedJenisUser, err := ctrl.JenisUsers.FindByID(ctx, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch updated data"})
+        return apperror.Internal("Failed to fetch updated data").Wrap(err)
+    }
+    ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityJenisUser, objID, before, updatedJenisUser, nil)
+
+    // Terapkan modul baru ke user yang sudah ada
+    if mode == syncApply {
+        result, err := ctrl.syncTemplateModul(ctx, objID, plan, mode, updatedBy, now)
+        if err != nil {
+            return syncError(c, err)
+        }
+        ctrl.auditSync(c, ctx, objID, result)
+        setVersionETag(c, updatedJenisUser.Version)
+        return c.Status(http.StatusOK).JSON(fiber.Map{"data": updatedJenisUser, "sync": result})
     }
 
     // Kembalikan response
+    setVersionETag(c, updatedJenisUser.Version)
     return c.Status(http.StatusOK).JSON(updatedJenisUser)
 }
 
 
-// DeleteTemplateModul untuk menguraangi modul tertentu dari template_modul pada jenis user
-func DeleteTemplateModul(c *fiber.Ctx) error {
+// DeleteTemplateModul untuk menguraangi modul tertentu dari template_modul pada jenis user.
+// Mode ?sync sama seperti EditJenisUser, modul yang diberikan manual ke user tetap dipertahankan.
+func (ctrl *Controller) DeleteTemplateModul(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -200,7 +258,7 @@ func DeleteTemplateModul(c *fiber.Ctx) error {
     id := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
     }
 
     // Parse request body
@@ -208,52 +266,157 @@ func DeleteTemplateModul(c *fiber.Ctx) error {
         ModulID string `json:"modul_id"`
     }
     if err := c.BodyParser(&input); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
     }
 
     // Validasi modul_id
     if input.ModulID == "" || !primitive.IsValidObjectID(input.ModulID) {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid modul_id format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid modul_id format")
     }
     modulObjID, _ := primitive.ObjectIDFromHex(input.ModulID)
 
-    // Query update untuk menghapus modul dari template_modul
-    updateQuery := bson.M{
-        "$pull": bson.M{
-            "template_modul": bson.M{"modul_id": modulObjID},
-        },
+    // Mode sinkronisasi ke user yang sudah ada
+    mode, err := parseSyncMode(c, syncNone)
+    if err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+    }
+    username, _ := c.Locals("username").(string)
+    loc := config.Location()
+    now := primitive.NewDateTimeFromTime(time.Now().In(loc))
+    plan := templateSyncPlan{Remove: []primitive.ObjectID{modulObjID}}
+
+    // Dry-run tidak mengubah template maupun user
+    if mode == syncDryRun {
+        jenisUser, err := ctrl.JenisUsers.FindByID(ctx, objID)
+        if err != nil {
+            if err == repository.ErrNotFound {
+                return apperror.NotFound("Jenis user not found")
+            }
+            return apperror.Wrap(err)
+        }
+        if !hasTemplateModul(jenisUser.TemplateModul, modulObjID) {
+            return apperror.NotFound("Modul not found in template_modul")
+        }
+
+        result, err := ctrl.syncTemplateModul(ctx, objID, plan, mode, username, now)
+        if err != nil {
+            return syncError(c, err)
+        }
+        return c.Status(http.StatusOK).JSON(fiber.Map{
+            "message": "Dry run, nothing was changed",
+            "data":    jenisUser,
+            "sync":    result,
+        })
     }
 
-    // Lakukan update di database
-    result, err := jenisUserCollection.UpdateOne(ctx, bson.M{"_id": objID}, updateQuery)
+    // Simpan kondisi sebelum diubah untuk audit log dan riwayat revisi
+    before, err := ctrl.JenisUsers.FindByID(ctx, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Jenis user not found")
+        }
+        return apperror.Wrap(err)
     }
 
-    if result.MatchedCount == 0 {
-        return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Jenis user not found"})
+    // Hapus modul dari template_modul di database bersama revisinya
+    var removed bool
+    err = ctrl.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+        var err error
+        removed, err = ctrl.JenisUsers.RemoveTemplateModul(ctx, objID, modulObjID)
+        if err != nil || !removed {
+            return err
+        }
+        return recordRevision(c, ctx, ctrl.JenisUserRevisions, objID, *before, models.RevisionActionUpdate, 0)
+    })
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Jenis user not found")
+        }
+        return apperror.Wrap(err)
     }
-    if result.ModifiedCount == 0 {
-        return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Modul not found in template_modul"})
+    if !removed {
+        return apperror.NotFound("Modul not found in template_modul")
     }
 
     // Ambil data terbaru setelah update
-    var updatedJenisUser models.JenisUser
-    err = jenisUserCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&updatedJenisUser)
+    updatedJenisUser, err := ctrl.JenisUsers.FindByID(ctx, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch updated data"})
+        return apperror.Internal("Failed to fetch updated data").Wrap(err)
     }
+    ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityJenisUser, objID, before, updatedJenisUser, nil)
 
-    // Kembalikan response
-    return c.Status(http.StatusOK).JSON(fiber.Map{
+    response := fiber.Map{
         "message": "Modul removed successfully",
         "data":    updatedJenisUser,
-    })
+    }
+
+    // Cabut modul dari user yang sudah ada
+    if mode == syncApply {
+        result, err := ctrl.syncTemplateModul(ctx, objID, plan, mode, username, now)
+        if err != nil {
+            return syncError(c, err)
+        }
+        ctrl.auditSync(c, ctx, objID, result)
+        response["sync"] = result
+    }
+
+    // Kembalikan response
+    return c.Status(http.StatusOK).JSON(response)
+}
+
+
+// SyncJenisUser menyamakan user_modul semua user dengan template_modul jenis user saat ini.
+// Modul template yang belum dimiliki ditambahkan dan modul bersumber template yang sudah tidak
+// ada di template dicabut. Default ?sync=apply, gunakan ?sync=dry_run untuk melihat dampaknya.
+func (ctrl *Controller) SyncJenisUser(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+
+    // Ambil ID dari parameter
+    id := c.Params("id")
+    objID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
+    }
+
+    mode, err := parseSyncMode(c, syncApply)
+    if err != nil || mode == syncNone {
+        return apperror.BadRequest(apperror.CodeBadRequest, "sync must be \"apply\" or \"dry_run\"")
+    }
+
+    jenisUser, err := ctrl.JenisUsers.FindByID(ctx, objID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Jenis user not found")
+        }
+        return apperror.Wrap(err)
+    }
+
+    keep := []primitive.ObjectID{}
+    for _, tmpl := range jenisUser.TemplateModul {
+        keep = append(keep, tmpl.ModulID)
+    }
+
+    username, _ := c.Locals("username").(string)
+    loc := config.Location()
+    now := primitive.NewDateTimeFromTime(time.Now().In(loc))
+
+    result, err := ctrl.syncTemplateModul(ctx, objID, templateSyncPlan{
+        Add:   jenisUser.TemplateModul,
+        Prune: true,
+        Keep:  keep,
+    }, mode, username, now)
+    if err != nil {
+        return syncError(c, err)
+    }
+    ctrl.auditSync(c, ctx, objID, result)
+
+    return c.Status(http.StatusOK).JSON(fiber.Map{"data": jenisUser, "sync": result})
 }
 
 
 // DeleteJenisUser untuk menghapus jenis user berdasarkan ID
-func DeleteJenisUser(c *fiber.Ctx) error {
+func (ctrl *Controller) DeleteJenisUser(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -261,15 +424,34 @@ func DeleteJenisUser(c *fiber.Ctx) error {
     id := c.Params("id")
     objectID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
     }
 
-    // Hapus jenis user berdasarkan ID
-    _, err = jenisUserCollection.DeleteOne(ctx, bson.M{"_id": objectID})
+    // Policy untuk user yang masih memakai jenis user ini.
+    // Setelah reassign, jalankan sync-jenis-user pada jenis user pengganti untuk menyamakan modulnya.
+    policy, err := parseDeletePolicy(c, objectID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+    }
+    if policy.Policy == policyReassign {
+        if _, err := ctrl.JenisUsers.FindByID(ctx, policy.ReassignTo); err != nil {
+            if err == repository.ErrNotFound {
+                return apperror.BadRequest(apperror.CodeBadRequest, "Replacement jenis user not found")
+            }
+            return apperror.Wrap(err)
+        }
     }
 
-    // Kembalikan response
-    return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Jenis user deleted successfully"})
+    // Pindahkan jenis user ke trash
+    username, _ := c.Locals("username").(string)
+    dependents := []dependent{
+        {Name: "users", Repo: ctrl.Users, Ref: repository.RefField{Field: "jenis_user_id"}, RevokeSessions: true},
+    }
+    return ctrl.deleteWithPolicy(c, ctx, policy, objectID, models.AuditEntityJenisUser, dependents, func(ctx context.Context) (interface{}, error) {
+        jenisUser, err := ctrl.JenisUsers.FindByID(ctx, objectID)
+        if err != nil {
+            return nil, err
+        }
+        return jenisUser, ctrl.JenisUsers.SoftDelete(ctx, objectID, username)
+    }, "Jenis user not found", "Jenis user deleted successfully")
 }
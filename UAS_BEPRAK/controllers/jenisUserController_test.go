@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEditJenisUserValidation(t *testing.T) {
+	// modulID "alpha" diganti dengan ID modul yang ada di store
+	tests := []struct {
+		name       string
+		nmJenis    string
+		modulID    string
+		query      string
+		wantStatus int
+		wantModuls int
+	}{
+		{"modul ada", "", "alpha", "", fiber.StatusOK, 1},
+		{"modul tidak ada", "", primitive.NewObjectID().Hex(), "", fiber.StatusUnprocessableEntity, 0},
+		{"modul tidak ada dengan sync", "", primitive.NewObjectID().Hex(), "?sync=apply", fiber.StatusUnprocessableEntity, 0},
+		{"modul_id bukan object id", "", "abc", "", fiber.StatusUnprocessableEntity, 0},
+		{"modul_id kosong", "", "", "", fiber.StatusUnprocessableEntity, 0},
+		{"nama terlalu panjang", strings.Repeat("a", 101), "alpha", "", fiber.StatusUnprocessableEntity, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, store := newTestController(t)
+			alpha := seedModul(t, store, "Alpha")
+			id := seedJenisUser(t, store, "mhs")
+
+			modulID := tt.modulID
+			if modulID == "alpha" {
+				modulID = alpha.Hex()
+			}
+			body := fiber.Map{"nm_jenis_user": tt.nmJenis, "template_modul": []fiber.Map{{"modul_id": modulID}}}
+
+			app := newTestApp()
+			app.Put("/jenis-user/:id", ctrl.EditJenisUser)
+			status, _ := doRequestIfMatch(t, app, "PUT", "/jenis-user/"+id.Hex()+tt.query, "", body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+
+			jenisUser, err := store.JenisUsers.FindByID(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(jenisUser.TemplateModul) != tt.wantModuls {
+				t.Errorf("template_modul = %v, want %d moduls", jenisUser.TemplateModul, tt.wantModuls)
+			}
+		})
+	}
+}
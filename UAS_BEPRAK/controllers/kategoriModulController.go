@@ -6,42 +6,38 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 
+	"project-crud/apperror"
 	"project-crud/config"
 	"project-crud/models"
+	"project-crud/repository"
 )
 
-var kategoriModulCollection *mongo.Collection = config.GetCollection("kategori_modul")// Pastikan ini sudah diinisialisasi di main.go
-
 // CreateKategoriModul membuat kategori modul baru
-func CreateKategoriModul(c *fiber.Ctx) error {
+func (ctrl *Controller) CreateKategoriModul(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
      // Ambil username dari user yang sedang login
      loggedInUsername, ok := c.Locals("username").(string)
      if !ok || loggedInUsername == "" {
-         return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized access"})
+         return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
      }
 
-    var kategoriModul models.KategoriModul
-    if err := c.BodyParser(&kategoriModul); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    var input kategoriModulInput
+    if err := c.BodyParser(&input); err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
     }
 
     // Validasi input
-    if kategoriModul.Name == "" {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Name is required"})
+    if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+        return err
     }
+    kategoriModul := models.KategoriModul{Name: input.Name}
 
     // Set data tambahan
-    loc, err := time.LoadLocation("Asia/Jakarta")
-    if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load time location"})
-    }
+    loc := config.Location()
     kategoriModul.ID = primitive.NewObjectID()
     // Isi CreatedBy dan UpdatedBy dengan logged-in username
     kategoriModul.CreatedBy = loggedInUsername
@@ -50,48 +46,42 @@ func CreateKategoriModul(c *fiber.Ctx) error {
     kategoriModul.UpdatedAt = kategoriModul.CreatedAt
 
     // Insert ke database
-    _, err = kategoriModulCollection.InsertOne(ctx, kategoriModul)
+    err := ctrl.KategoriModuls.Create(ctx, &kategoriModul)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create kategori modul"})
+        return apperror.Internal("Failed to create kategori modul").Wrap(err)
     }
+    ctrl.audit(c, ctx, models.AuditActionCreate, models.AuditEntityKategoriModul, kategoriModul.ID, nil, kategoriModul, nil)
 
     return c.Status(http.StatusCreated).JSON(kategoriModul)
 }
 
 
 //  Get semua kategori modul
-func GetAllKategoriModul(c *fiber.Ctx) error {
+func (ctrl *Controller) GetAllKategoriModul(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    // Query semua kategori modul dari koleksi
-    cursor, err := kategoriModulCollection.Find(ctx, bson.M{})
+    // Query satu halaman kategori modul sesuai sort dan paginasi
+    q, err := parseListQuery(c, kategoriModulListSpec)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch kategori modul"})
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
     }
-    defer cursor.Close(ctx)
 
-    // Iterasi hasil query
-    var kategoriModul []models.KategoriModul
-    for cursor.Next(ctx) {
-        var modul models.KategoriModul
-        if err := cursor.Decode(&modul); err != nil {
-            return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode kategori modul"})
+    kategoriModul, err := ctrl.KategoriModuls.List(ctx, q)
+    if err != nil {
+        if err == repository.ErrInvalidCursor {
+            return apperror.BadRequest(apperror.CodeInvalidCursor, "Invalid cursor")
         }
-        kategoriModul = append(kategoriModul, modul)
-    }
-
-    if err := cursor.Err(); err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Cursor error: " + err.Error()})
+        return apperror.Internal("Failed to fetch kategori modul")
     }
 
-    // Kembalikan daftar kategori modul
-    return c.Status(http.StatusOK).JSON(kategoriModul)
+    // Kembalikan daftar kategori modul beserta meta paginasi
+    return listResponse(c, q, kategoriModul)
 }
 
 
 //  Get kategori modul by ID
-func GetKategoriModulByID(c *fiber.Ctx) error {
+func (ctrl *Controller) GetKategoriModulByID(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -99,26 +89,26 @@ func GetKategoriModulByID(c *fiber.Ctx) error {
     kategoriID := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(kategoriID)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid kategori modul ID"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid kategori modul ID")
     }
 
     // Cari kategori modul berdasarkan ID
-    var kategoriModul models.KategoriModul
-    err = kategoriModulCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&kategoriModul)
+    kategoriModul, err := ctrl.KategoriModuls.FindByID(ctx, objID)
     if err != nil {
-        if err == mongo.ErrNoDocuments {
-            return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Kategori modul not found"})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Kategori modul not found")
         }
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.Wrap(err)
     }
 
-    // Kembalikan kategori modul
+    // Kembalikan kategori modul beserta versinya
+    setVersionETag(c, kategoriModul.Version)
     return c.Status(http.StatusOK).JSON(kategoriModul)
 }
 
 
 // Edit Kategori by ID
-func EditKategoriModul(c *fiber.Ctx) error {
+func (ctrl *Controller) EditKategoriModul(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -126,44 +116,62 @@ func EditKategoriModul(c *fiber.Ctx) error {
     kategoriID := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(kategoriID)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid kategori modul ID"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid kategori modul ID")
     }
 
     // Parsing input
-    var kategoriInput models.KategoriModul
+    var kategoriInput kategoriModulInput
     if err := c.BodyParser(&kategoriInput); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
     }
 
     // Validasi input
-    if kategoriInput.Name == "" {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Name is required"})
+    if err := ctrl.Validator.Struct(ctx, &kategoriInput); err != nil {
+        return err
     }
 
     // Ambil username dari middleware
     updatedBy := c.Locals("username").(string)
 
-    // Update data
-    loc, err := time.LoadLocation("Asia/Jakarta")
+    // Versi dari If-Match, update ditolak jika dokumen sudah diubah pihak lain
+    version, ok := ifMatchVersion(c)
+    if !ok {
+        return preconditionFailed()
+    }
+
+    // Simpan kondisi sebelum diubah untuk audit log
+    before, err := ctrl.KategoriModuls.FindByID(ctx, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Kategori modul not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if !versionMatches(version, before.Version) {
+        return preconditionFailed()
     }
 
-    update := bson.M{
-        "$set": bson.M{
-            "name":       kategoriInput.Name,
-            "updated_at": primitive.NewDateTimeFromTime(time.Now().In(loc)),
-            "updated_by": updatedBy,
-        },
+    // Update data
+    loc := config.Location()
+
+    update := repository.Fields{
+        "name":       kategoriInput.Name,
+        "updated_at": primitive.NewDateTimeFromTime(time.Now().In(loc)),
+        "updated_by": updatedBy,
     }
 
-    result, err := kategoriModulCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+    err = ctrl.KategoriModuls.Update(ctx, objID, version, update)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        if err == repository.ErrVersionConflict {
+            return preconditionFailed()
+        }
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Kategori modul not found")
+        }
+        return apperror.Wrap(err)
     }
-
-    if result.MatchedCount == 0 {
-        return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Kategori modul not found"})
+    if after, err := ctrl.KategoriModuls.FindByID(ctx, objID); err == nil {
+        ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityKategoriModul, objID, before, after, nil)
     }
 
     return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Kategori modul updated successfully"})
@@ -171,7 +179,7 @@ func EditKategoriModul(c *fiber.Ctx) error {
 
 
 // Delete Kategori Modul by ID
-func DeleteKategoriModul(c *fiber.Ctx) error {
+func (ctrl *Controller) DeleteKategoriModul(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -179,18 +187,33 @@ func DeleteKategoriModul(c *fiber.Ctx) error {
     kategoriID := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(kategoriID)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid kategori modul ID"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid kategori modul ID")
     }
 
-    // Hapus kategori modul dari koleksi
-    result, err := kategoriModulCollection.DeleteOne(ctx, bson.M{"_id": objID})
+    // Policy untuk modul yang masih berada di kategori ini
+    policy, err := parseDeletePolicy(c, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+    }
+    if policy.Policy == policyReassign {
+        if _, err := ctrl.KategoriModuls.FindByID(ctx, policy.ReassignTo); err != nil {
+            if err == repository.ErrNotFound {
+                return apperror.BadRequest(apperror.CodeBadRequest, "Replacement kategori modul not found")
+            }
+            return apperror.Wrap(err)
+        }
     }
 
-    if result.DeletedCount == 0 {
-        return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Kategori modul not found"})
+    // Pindahkan kategori modul ke trash
+    username, _ := c.Locals("username").(string)
+    dependents := []dependent{
+        {Name: "moduls", Repo: ctrl.Moduls, Ref: repository.RefField{Field: "kategori_modul"}},
     }
-
-    return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Kategori modul deleted successfully"})
+    return ctrl.deleteWithPolicy(c, ctx, policy, objID, models.AuditEntityKategoriModul, dependents, func(ctx context.Context) (interface{}, error) {
+        kategori, err := ctrl.KategoriModuls.FindByID(ctx, objID)
+        if err != nil {
+            return nil, err
+        }
+        return kategori, ctrl.KategoriModuls.SoftDelete(ctx, objID, username)
+    }, "Kategori modul not found", "Kategori modul deleted successfully")
 }
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"project-crud/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tipe nilai filter pada query string
+type filterType int
+
+const (
+	filterString filterType = iota
+	filterObjectID
+)
+
+// listSpec menentukan filter dan field sort yang diizinkan untuk satu endpoint daftar.
+// Nama query param filter sama dengan nama field bson.
+type listSpec struct {
+	Filters map[string]filterType
+	Sorts   []string
+}
+
+// Spesifikasi query untuk setiap endpoint daftar
+var (
+	userListSpec = listSpec{
+		Filters: map[string]filterType{
+			"role_id":       filterObjectID,
+			"jenis_user_id": filterObjectID,
+			"jenis_kelamin": filterString,
+		},
+		Sorts: []string{"username", "nm_user", "email", "created_at", "updated_at"},
+	}
+	modulListSpec = listSpec{
+		Filters: map[string]filterType{"kategori_modul": filterObjectID},
+		Sorts:   []string{"name", "created_at", "updated_at"},
+	}
+	roleListSpec          = listSpec{Sorts: []string{"name", "created_at", "updated_at"}}
+	kategoriModulListSpec = listSpec{Sorts: []string{"name", "created_at", "updated_at"}}
+	jenisUserListSpec     = listSpec{Sorts: []string{"nm_jenis_user", "created_at", "updated_at"}}
+)
+
+// parseListQuery membaca page, limit, cursor, sort dan filter dari query string.
+// Sort memakai format "field" (naik) atau "-field" (turun).
+func parseListQuery(c *fiber.Ctx, spec listSpec) (repository.ListQuery, error) {
+	q := repository.ListQuery{
+		Page:   1,
+		Limit:  repository.DefaultLimit,
+		Cursor: c.Query("cursor"),
+	}
+
+	if raw := c.Query("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			return q, fmt.Errorf("page must be a positive integer")
+		}
+		q.Page = page
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > repository.MaxLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d", repository.MaxLimit)
+		}
+		q.Limit = limit
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		q.Desc = strings.HasPrefix(sort, "-")
+		q.Sort = strings.TrimPrefix(sort, "-")
+		if !containsString(spec.Sorts, q.Sort) {
+			return q, fmt.Errorf("sort must be one of: %s", strings.Join(spec.Sorts, ", "))
+		}
+	}
+
+	filter, err := parseFilters(c, spec)
+	if err != nil {
+		return q, err
+	}
+	q.Filter = filter
+
+	return q, nil
+}
+
+// parseFilters membaca filter yang diizinkan spec dari query string
+func parseFilters(c *fiber.Ctx, spec listSpec) (repository.Fields, error) {
+	filter := repository.Fields{}
+	for field, kind := range spec.Filters {
+		raw := c.Query(field)
+		if raw == "" {
+			continue
+		}
+		switch kind {
+		case filterObjectID:
+			id, err := primitive.ObjectIDFromHex(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s", field)
+			}
+			filter[field] = id
+		default:
+			filter[field] = raw
+		}
+	}
+	return filter, nil
+}
+
+// listResponse membungkus satu halaman data dalam envelope data, meta dan links
+func listResponse[T any](c *fiber.Ctx, q repository.ListQuery, page *repository.Page[T]) error {
+	meta := fiber.Map{
+		"total":    page.Total,
+		"limit":    q.Limit,
+		"has_more": page.HasMore,
+	}
+	if q.Cursor == "" {
+		meta["page"] = q.Page
+	}
+
+	links := fiber.Map{"self": c.OriginalURL()}
+	if page.HasMore {
+		meta["next_cursor"] = page.NextCursor
+
+		// Link berikutnya selalu memakai cursor agar tetap stabil walaupun data bertambah
+		args := fiber.AcquireArgs()
+		defer fiber.ReleaseArgs(args)
+		c.Context().QueryArgs().CopyTo(args)
+		args.Del("page")
+		args.Set("cursor", page.NextCursor)
+		links["next"] = c.Path() + "?" + args.String()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data":  page.Items,
+		"meta":  meta,
+		"links": links,
+	})
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
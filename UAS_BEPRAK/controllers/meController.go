@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
+	"project-crud/config"
+	"project-crud/models"
+	"project-crud/repository"
+	"project-crud/validation"
+)
+
+// Field profil yang boleh diubah sendiri oleh civitas
+var selfEditableFields = map[string]bool{"phone": true, "photo": true}
+
+// currentUserID mengambil ID user yang sedang login dari context JWTAuth
+func currentUserID(c *fiber.Ctx) (primitive.ObjectID, bool) {
+	userID, ok := c.Locals("user_id").(primitive.ObjectID)
+	return userID, ok && !userID.IsZero()
+}
+
+
+// GetMe mengembalikan profil user yang sedang login
+func (ctrl *Controller) GetMe(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+	}
+
+	// Password tidak ikut karena field Pass tidak di-serialize ke JSON
+	user, err := ctrl.Users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("User not found")
+		}
+		return apperror.Wrap(err)
+	}
+
+	return c.Status(http.StatusOK).JSON(user)
+}
+
+
+// UpdateMe mengubah profil user yang sedang login, hanya phone dan photo
+func (ctrl *Controller) UpdateMe(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+	}
+	username, _ := c.Locals("username").(string)
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+	}
+
+	// Tolak field di luar phone dan photo agar user tidak bisa mengubah role atau modulnya sendiri
+	update := repository.Fields{}
+	for field, raw := range body {
+		if !selfEditableFields[field] {
+			return apperror.BadRequest(apperror.CodeBadRequest, "Field " + field + " cannot be updated")
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return apperror.BadRequest(apperror.CodeBadRequest, "Field " + field + " must be a string")
+		}
+		update[field] = value
+	}
+	if len(update) == 0 {
+		return apperror.BadRequest(apperror.CodeBadRequest, "No fields to update (phone, photo)")
+	}
+
+	if phone, ok := update["phone"].(string); ok && phone != "" && !validation.ValidPhone(phone) {
+		return apperror.BadRequest(apperror.CodeBadRequest, "Invalid phone number")
+	}
+	if photo, ok := update["photo"].(string); ok && len(photo) > 500 {
+		return apperror.BadRequest(apperror.CodeBadRequest, "Photo must be at most 500 characters")
+	}
+
+	loc := config.Location()
+	update["updated_at"] = primitive.NewDateTimeFromTime(time.Now().In(loc))
+	update["updated_by"] = username
+
+	before, err := ctrl.Users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("User not found")
+		}
+		return apperror.Wrap(err)
+	}
+
+	if err := ctrl.Users.Update(ctx, userID, repository.AnyVersion, update); err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("User not found")
+		}
+		return apperror.Wrap(err)
+	}
+
+	user, err := ctrl.Users.FindByID(ctx, userID)
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+	ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityUser, userID, before, user, nil)
+
+	return c.Status(http.StatusOK).JSON(user)
+}
+
+
+// ChangeMyPassword mengganti password setelah password lama diverifikasi.
+// Sesi lain milik user dicabut, sesi yang sedang dipakai tetap berlaku.
+func (ctrl *Controller) ChangeMyPassword(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+	}
+	username, _ := c.Locals("username").(string)
+	sessionID, _ := c.Locals("session_id").(primitive.ObjectID)
+
+	var input struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+	}
+
+	if input.NewPassword == input.CurrentPassword {
+		return apperror.BadRequest(apperror.CodeBadRequest, "New password must be different from the current password")
+	}
+
+	user, err := ctrl.Users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("User not found")
+		}
+		return apperror.Wrap(err)
+	}
+
+	// Verifikasi password lama
+	if err := ctrl.verifyCurrentPassword(c, ctx, user, input.CurrentPassword); err != nil {
+		return err
+	}
+	if err := ctrl.checkPassword("new_password", input.NewPassword, user); err != nil {
+		return err
+	}
+
+	hashedPassword, err := ctrl.Hasher.Hash(input.NewPassword)
+	if err != nil {
+		return apperror.Internal("Failed to hash password").Wrap(err)
+	}
+
+	loc := config.Location()
+	update := ctrl.passwordUpdate(user, hashedPassword)
+	update["updated_at"] = primitive.NewDateTimeFromTime(time.Now().In(loc))
+	update["updated_by"] = username
+	err = ctrl.Users.Update(ctx, userID, repository.AnyVersion, update)
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+	ctrl.auditUserUpdate(c, ctx, userID, user)
+
+	if err := ctrl.Auth.RevokeOtherSessions(ctx, userID, sessionID); err != nil {
+		return apperror.Internal("Failed to revoke other sessions").Wrap(err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Password updated successfully"})
+}
+
+
+// GetMyModules mengembalikan modul yang bisa diakses user yang sedang login
+// lengkap dengan alamat_url dan gbr_icon, sesuai urutan user_modul
+func (ctrl *Controller) GetMyModules(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+	}
+
+	user, err := ctrl.Users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("User not found")
+		}
+		return apperror.Wrap(err)
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(user.UserModul))
+	for _, userModul := range user.UserModul {
+		ids = append(ids, userModul.ModulID)
+	}
+
+	// Modul yang sudah dihapus tidak ikut dikembalikan
+	moduls, err := ctrl.Moduls.FindByIDs(ctx, ids)
+	if err != nil {
+		return apperror.Internal("Failed to fetch moduls").Wrap(err)
+	}
+
+	return c.Status(http.StatusOK).JSON(moduls)
+}
@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
+	"project-crud/config"
+	"project-crud/middleware"
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// Kode error saat user mencoba mematikan MFA yang diwajibkan role-nya
+const codeMFARequiredByRole = "mfa_required_by_role"
+
+// mfaCodeInput adalah body endpoint MFA yang hanya membutuhkan kode TOTP
+type mfaCodeInput struct {
+	Code string `json:"code"`
+}
+
+// currentUser mengambil dokumen user yang sedang login
+func (ctrl *Controller) currentUser(c *fiber.Ctx, ctx context.Context) (*models.User, error) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+	}
+	user, err := ctrl.Users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, apperror.NotFound("User not found")
+		}
+		return nil, apperror.Wrap(err)
+	}
+	return user, nil
+}
+
+// mfaError menerjemahkan error pengelolaan MFA menjadi respons
+func mfaError(err error) error {
+	switch err {
+	case middleware.ErrInvalidMFACode:
+		return apperror.BadRequest(middleware.ErrCodeMFAInvalidCode, err.Error())
+	case middleware.ErrMFANoPendingSecret:
+		return apperror.BadRequest(middleware.ErrCodeMFANotPending, "Start two-factor enrollment first")
+	case middleware.ErrMFANotEnabled, middleware.ErrMFAAlreadyEnabled:
+		return apperror.Conflict(apperror.CodeConflict, err.Error())
+	case repository.ErrVersionConflict:
+		return apperror.Conflict(apperror.CodeConflict, "User was modified by another request, please retry")
+	}
+	return apperror.Wrap(err)
+}
+
+
+// GetMyMFA mengembalikan status autentikasi dua faktor user yang sedang login
+func (ctrl *Controller) GetMyMFA(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := ctrl.currentUser(c, ctx)
+	if err != nil {
+		return err
+	}
+	required, err := ctrl.Auth.MFARequired(ctx, *user)
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+
+	status := fiber.Map{"enabled": user.MFAEnabled(), "required": required, "recovery_codes_left": 0}
+	if user.MFAEnabled() {
+		status["enabled_at"] = user.MFA.EnabledAt
+		status["recovery_codes_left"] = len(user.MFA.RecoveryCodes)
+	}
+	return c.Status(http.StatusOK).JSON(status)
+}
+
+
+// StartMyMFAEnrollment membuat secret TOTP baru untuk didaftarkan ke aplikasi authenticator.
+// Secret baru aktif setelah dikonfirmasi lewat ConfirmMyMFA.
+func (ctrl *Controller) StartMyMFAEnrollment(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := ctrl.currentUser(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	secret, uri, err := ctrl.Auth.StartMFAEnrollment(ctx, *user)
+	if err != nil {
+		return mfaError(err)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"secret": secret, "otpauth_uri": uri})
+}
+
+
+// ConfirmMyMFA mengaktifkan MFA dengan kode dari authenticator, mengembalikan recovery code
+// (hanya ditampilkan sekali) dan mencabut sesi lain yang dibuat tanpa faktor kedua
+func (ctrl *Controller) ConfirmMyMFA(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input mfaCodeInput
+	if err := c.BodyParser(&input); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+	}
+
+	user, err := ctrl.currentUser(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	codes, err := ctrl.Auth.ConfirmMFAEnrollment(ctx, *user, input.Code)
+	if err != nil {
+		return mfaError(err)
+	}
+	ctrl.auditUserUpdate(c, ctx, user.ID, user)
+
+	sessionID, _ := c.Locals("session_id").(primitive.ObjectID)
+	if err := ctrl.Auth.RevokeOtherSessions(ctx, user.ID, sessionID); err != nil {
+		return apperror.Internal("Failed to revoke other sessions").Wrap(err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+
+// RegenerateMyRecoveryCodes mengganti semua recovery code, recovery code lama tidak berlaku lagi
+func (ctrl *Controller) RegenerateMyRecoveryCodes(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input mfaCodeInput
+	if err := c.BodyParser(&input); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+	}
+
+	user, err := ctrl.currentUser(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	codes, err := ctrl.Auth.RegenerateRecoveryCodes(ctx, *user, input.Code)
+	if err != nil {
+		return mfaError(err)
+	}
+	ctrl.auditUserUpdate(c, ctx, user.ID, user)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"recovery_codes": codes})
+}
+
+
+// DisableMyMFA mematikan MFA setelah password dan kode TOTP diverifikasi.
+// Ditolak jika role user mewajibkan MFA.
+func (ctrl *Controller) DisableMyMFA(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		CurrentPassword string `json:"current_password"`
+		Code            string `json:"code"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+	}
+
+	user, err := ctrl.currentUser(c, ctx)
+	if err != nil {
+		return err
+	}
+	username, _ := c.Locals("username").(string)
+
+	required, err := ctrl.Auth.MFARequired(ctx, *user)
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+	if required {
+		return apperror.Forbidden(codeMFARequiredByRole, "Two-factor authentication is required for your role")
+	}
+
+	if err := ctrl.verifyCurrentPassword(c, ctx, user, input.CurrentPassword); err != nil {
+		return err
+	}
+	if err := ctrl.Auth.VerifyMFA(ctx, *user, input.Code, ""); err != nil {
+		if err == middleware.ErrInvalidMFACode {
+			if err := ctrl.Auth.LoginFailed(ctx, user.Username, c.IP()); err != nil {
+				return apperror.Wrap(err)
+			}
+		}
+		return mfaError(err)
+	}
+
+	err = ctrl.Users.Update(ctx, user.ID, repository.AnyVersion, repository.Fields{
+		"mfa":        nil,
+		"updated_at": primitive.NewDateTimeFromTime(time.Now().In(config.Location())),
+		"updated_by": username,
+	})
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+	ctrl.auditUserUpdate(c, ctx, user.ID, user)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Two-factor authentication disabled"})
+}
+
+
+// ResetUserMFA menghapus pengaturan MFA user yang kehilangan authenticator dan recovery code-nya.
+// Semua sesi user dicabut; jika role-nya mewajibkan MFA, user harus mendaftar ulang saat login.
+func (ctrl *Controller) ResetUserMFA(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidID, "Invalid user ID")
+	}
+	updatedBy, _ := c.Locals("username").(string)
+
+	before, err := ctrl.Users.FindByID(ctx, objID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("User not found")
+		}
+		return apperror.Wrap(err)
+	}
+
+	err = ctrl.Users.Update(ctx, objID, repository.AnyVersion, repository.Fields{
+		"mfa":        nil,
+		"updated_at": primitive.NewDateTimeFromTime(time.Now().In(config.Location())),
+		"updated_by": updatedBy,
+	})
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+	ctrl.auditUserUpdate(c, ctx, objID, before)
+
+	if err := ctrl.Auth.RevokeUserSessions(ctx, objID); err != nil {
+		return apperror.Internal("Failed to revoke user sessions").Wrap(err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Two-factor authentication reset successfully"})
+}
@@ -5,66 +5,47 @@ import (
 	"net/http"
 	"time"
 
+	"project-crud/apperror"
 	"project-crud/config" // Ganti dengan nama modul Anda
 	"project-crud/models" // Ganti dengan nama modul Anda
+	"project-crud/repository"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var modulCollection *mongo.Collection = config.GetCollection("moduls") 
-
 // CreateModul membuat modul baru
-func CreateModul(c *fiber.Ctx) error {
+func (ctrl *Controller) CreateModul(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
     // Ambil username dari user yang sedang login
     loggedInUsername, ok := c.Locals("username").(string)
     if !ok || loggedInUsername == "" {
-        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized access"})
+        return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
     }
 
     // Parse input dari request body
-    var modul models.Modul
-    if err := c.BodyParser(&modul); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    var input createModulInput
+    if err := c.BodyParser(&input); err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
     }
 
-    // Validasi input
-    if modul.Name == "" {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Name is required"})
-    }
-    if modul.Description == "" {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Description is required"})
-    }
-    if modul.KategoriModul.IsZero() {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "KategoriModul ID is required"})
-    }
-    if modul.AlamatURL == "" {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "AlamatURL is required"})
+    // Validasi input, termasuk keberadaan KategoriModul dan format alamat_url
+    if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+        return err
     }
-    if modul.GbrIcon == "" {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "GbrIcon is required"})
-    }
-
-    // Validasi keberadaan KategoriModul
-    var kategoriModul models.KategoriModul
-    err := kategoriModulCollection.FindOne(ctx, bson.M{"_id": modul.KategoriModul}).Decode(&kategoriModul)
-    if err != nil {
-        if err == mongo.ErrNoDocuments {
-            return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid KategoriModul ID"})
-        }
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+    kategoriID, _ := primitive.ObjectIDFromHex(input.KategoriModul)
+    modul := models.Modul{
+        Name:          input.Name,
+        Description:   input.Description,
+        KategoriModul: kategoriID,
+        AlamatURL:     input.AlamatURL,
+        GbrIcon:       input.GbrIcon,
     }
 
     // Set data tambahan
-    loc, err := time.LoadLocation("Asia/Jakarta")
-    if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load time location"})
-    }
+    loc := config.Location()
     modul.ID = primitive.NewObjectID()
     // Isi CreatedBy dan UpdatedBy dengan logged-in username
     modul.CreatedBy = loggedInUsername
@@ -73,10 +54,11 @@ func CreateModul(c *fiber.Ctx) error {
     modul.UpdatedAt = modul.CreatedAt
 
     // Masukkan data ke database
-    _, err = modulCollection.InsertOne(ctx, modul)
+    err := ctrl.Moduls.Create(ctx, &modul)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create modul"})
+        return apperror.Internal("Failed to create modul").Wrap(err)
     }
+    ctrl.audit(c, ctx, models.AuditActionCreate, models.AuditEntityModul, modul.ID, nil, modul, nil)
 
     // Kembalikan response
     return c.Status(http.StatusCreated).JSON(modul)
@@ -84,27 +66,31 @@ func CreateModul(c *fiber.Ctx) error {
 
 
 // GetAllModul mendapatkan semua modul
-func GetAllModul(c *fiber.Ctx) error {
+func (ctrl *Controller) GetAllModul(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    // Ambil data dari database
-    cursor, err := modulCollection.Find(ctx, bson.M{})
+    // Ambil satu halaman modul sesuai filter, sort dan paginasi
+    q, err := parseListQuery(c, modulListSpec)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
     }
-    var moduls []models.Modul
-    if err := cursor.All(ctx, &moduls); err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+
+    moduls, err := ctrl.Moduls.List(ctx, q)
+    if err != nil {
+        if err == repository.ErrInvalidCursor {
+            return apperror.BadRequest(apperror.CodeInvalidCursor, "Invalid cursor")
+        }
+        return apperror.Wrap(err)
     }
 
-    // Kembalikan response
-    return c.Status(http.StatusOK).JSON(moduls)
+    // Kembalikan response beserta meta paginasi
+    return listResponse(c, q, moduls)
 }
 
 
 // GetModulByID mendapatkan modul berdasarkan ID
-func GetModulByID(c *fiber.Ctx) error {
+func (ctrl *Controller) GetModulByID(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -112,26 +98,26 @@ func GetModulByID(c *fiber.Ctx) error {
     id := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
     }
 
     // Cari modul di database
-    var modul models.Modul
-    err = modulCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&modul)
+    modul, err := ctrl.Moduls.FindByID(ctx, objID)
     if err != nil {
-        if err == mongo.ErrNoDocuments {
-            return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Modul not found"})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Modul not found")
         }
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.Wrap(err)
     }
 
     // Kembalikan response
+    setVersionETag(c, modul.Version)
     return c.Status(http.StatusOK).JSON(modul)
 }
 
 
 // EditModul memperbarui modul berdasarkan ID
-func EditModul(c *fiber.Ctx) error {
+func (ctrl *Controller) EditModul(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -139,25 +125,43 @@ func EditModul(c *fiber.Ctx) error {
     id := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
     }
 
     // Parse input dari request body
-    var input models.Modul
+    var input editModulInput
     if err := c.BodyParser(&input); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
+    }
+    if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+        return err
     }
 
     // Ambil username dari middleware JWT
     updatedBy := c.Locals("username").(string)
 
-    // Set data tambahan
-    loc, err := time.LoadLocation("Asia/Jakarta")
+    // Versi dari If-Match, update ditolak jika dokumen sudah diubah pihak lain
+    version, ok := ifMatchVersion(c)
+    if !ok {
+        return preconditionFailed()
+    }
+
+    // Simpan kondisi sebelum diubah untuk audit log dan riwayat revisi
+    before, err := ctrl.Moduls.FindByID(ctx, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load time location"})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Modul not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if !versionMatches(version, before.Version) {
+        return preconditionFailed()
     }
 
-    updateFields := bson.M{
+    // Set data tambahan
+    loc := config.Location()
+
+    updateFields := repository.Fields{
         "updated_by": updatedBy,
         "updated_at": primitive.NewDateTimeFromTime(time.Now().In(loc)),
     }
@@ -176,35 +180,38 @@ func EditModul(c *fiber.Ctx) error {
         updateFields["gbr_icon"] = input.GbrIcon
     }
 
-    // Lakukan update
-    update := bson.M{
-        "$set": updateFields,
-    }
-
-    // Update data ke database
-    result, err := modulCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+    // Update data ke database bersama revisinya
+    err = ctrl.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+        if err := ctrl.Moduls.Update(ctx, objID, version, updateFields); err != nil {
+            return err
+        }
+        return recordRevision(c, ctx, ctrl.ModulRevisions, objID, *before, models.RevisionActionUpdate, 0)
+    })
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-    }
-
-    if result.MatchedCount == 0 {
-        return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Modul not found"})
+        if err == repository.ErrVersionConflict {
+            return preconditionFailed()
+        }
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Modul not found")
+        }
+        return apperror.Wrap(err)
     }
 
     // Ambil data terbaru setelah update
-    var updatedModul models.Modul
-    err = modulCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&updatedModul)
+    updatedModul, err := ctrl.Moduls.FindByID(ctx, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch updated data"})
+        return apperror.Internal("Failed to fetch updated data").Wrap(err)
     }
+    ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityModul, objID, before, updatedModul, nil)
 
     // Kembalikan response
+    setVersionETag(c, updatedModul.Version)
     return c.Status(http.StatusOK).JSON(updatedModul)
 }
 
 
 // DeleteModul menghapus modul berdasarkan ID
-func DeleteModul(c *fiber.Ctx) error {
+func (ctrl *Controller) DeleteModul(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -212,15 +219,37 @@ func DeleteModul(c *fiber.Ctx) error {
     id := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
     }
 
-    // Hapus data modul
-    _, err = modulCollection.DeleteOne(ctx, bson.M{"_id": objID})
+    // Policy untuk template_modul jenis user dan user_modul yang masih memakai modul ini
+    policy, err := parseDeletePolicy(c, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+    }
+    extra := repository.Fields{}
+    if policy.Policy == policyReassign {
+        target, err := ctrl.Moduls.FindByID(ctx, policy.ReassignTo)
+        if err != nil {
+            if err == repository.ErrNotFound {
+                return apperror.BadRequest(apperror.CodeBadRequest, "Replacement modul not found")
+            }
+            return apperror.Wrap(err)
+        }
+        extra["nm_modul"] = target.Name
     }
 
-    // Kembalikan response
-    return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Modul deleted successfully"})
+    // Pindahkan modul ke trash
+    username, _ := c.Locals("username").(string)
+    dependents := []dependent{
+        {Name: "jenis_users", Repo: ctrl.JenisUsers, Ref: repository.RefField{Field: "template_modul", Key: "modul_id"}},
+        {Name: "users", Repo: ctrl.Users, Ref: repository.RefField{Field: "user_modul", Key: "modul_id"}, Extra: extra},
+    }
+    return ctrl.deleteWithPolicy(c, ctx, policy, objID, models.AuditEntityModul, dependents, func(ctx context.Context) (interface{}, error) {
+        modul, err := ctrl.Moduls.FindByID(ctx, objID)
+        if err != nil {
+            return nil, err
+        }
+        return modul, ctrl.Moduls.SoftDelete(ctx, objID, username)
+    }, "Modul not found", "Modul deleted successfully")
 }
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
+	"project-crud/config"
+	"project-crud/middleware"
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// Kode error registrasi client OpenID Connect
+const (
+	codeOAuthClientExists = "oauth_client_exists"
+	codeOAuthClientPublic = "oauth_client_public"
+)
+
+// oauthClientModul mengambil ID modul dari parameter dan memastikan modulnya ada
+func (ctrl *Controller) oauthClientModul(c *fiber.Ctx, ctx context.Context) (*models.Modul, error) {
+	modulID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, apperror.BadRequest(apperror.CodeInvalidID, "Invalid modul ID")
+	}
+	modul, err := ctrl.Moduls.FindByID(ctx, modulID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, apperror.NotFound("Modul not found")
+		}
+		return nil, apperror.Wrap(err)
+	}
+	return modul, nil
+}
+
+
+// CreateOAuthClient mendaftarkan modul sebagai client OpenID Connect. client_secret hanya
+// dikembalikan sekali pada respons ini; yang disimpan hanya hash-nya.
+func (ctrl *Controller) CreateOAuthClient(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	modul, err := ctrl.oauthClientModul(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	var input oauthClientInput
+	if err := c.BodyParser(&input); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
+	}
+	if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+		return err
+	}
+
+	username, _ := c.Locals("username").(string)
+	now := primitive.NewDateTimeFromTime(time.Now().In(config.Location()))
+	client := models.OAuthClient{
+		ModulID:      modul.ID,
+		ClientID:     modul.ID.Hex(),
+		Public:       input.Public,
+		RedirectURIs: input.RedirectURIs,
+		CreatedAt:    now,
+		CreatedBy:    username,
+		UpdatedAt:    now,
+		UpdatedBy:    username,
+	}
+	secret := ""
+	if !input.Public {
+		var hash string
+		if secret, hash, err = middleware.NewClientSecret(); err != nil {
+			return apperror.Wrap(err)
+		}
+		client.SecretHash = hash
+	}
+
+	if err := ctrl.OAuthClients.Create(ctx, &client); err != nil {
+		if err == repository.ErrDuplicate {
+			return apperror.Conflict(codeOAuthClientExists, "Modul is already registered as an OAuth client")
+		}
+		return apperror.Internal("Failed to create OAuth client").Wrap(err)
+	}
+	ctrl.audit(c, ctx, models.AuditActionCreate, models.AuditEntityOAuthClient, client.ModulID, nil, client, nil)
+
+	return c.Status(http.StatusCreated).JSON(fiber.Map{"client": client, "client_secret": secret})
+}
+
+
+// GetOAuthClient mengembalikan registrasi client milik modul
+func (ctrl *Controller) GetOAuthClient(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	modulID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidID, "Invalid modul ID")
+	}
+	client, err := ctrl.OAuthClients.FindByModul(ctx, modulID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("OAuth client not found")
+		}
+		return apperror.Wrap(err)
+	}
+
+	setVersionETag(c, client.Version)
+	return c.Status(http.StatusOK).JSON(client)
+}
+
+
+// EditOAuthClient mengganti daftar redirect_uri. Jenis client (public atau confidential)
+// tidak bisa diubah; hapus dan daftarkan ulang client untuk menggantinya.
+func (ctrl *Controller) EditOAuthClient(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	modulID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidID, "Invalid modul ID")
+	}
+
+	var input oauthClientInput
+	if err := c.BodyParser(&input); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
+	}
+	if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+		return err
+	}
+
+	// Versi dari If-Match, update ditolak jika dokumen sudah diubah pihak lain
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return preconditionFailed()
+	}
+	before, err := ctrl.OAuthClients.FindByModul(ctx, modulID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("OAuth client not found")
+		}
+		return apperror.Wrap(err)
+	}
+	if !versionMatches(version, before.Version) {
+		return preconditionFailed()
+	}
+	if input.Public != before.Public {
+		return apperror.BadRequest(apperror.CodeBadRequest, "Client type cannot be changed, delete and register the client again")
+	}
+
+	updatedBy, _ := c.Locals("username").(string)
+	err = ctrl.OAuthClients.Update(ctx, modulID, version, repository.Fields{
+		"redirect_uris": input.RedirectURIs,
+		"updated_at":    primitive.NewDateTimeFromTime(time.Now().In(config.Location())),
+		"updated_by":    updatedBy,
+	})
+	if err != nil {
+		if err == repository.ErrVersionConflict {
+			return preconditionFailed()
+		}
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("OAuth client not found")
+		}
+		return apperror.Wrap(err)
+	}
+	if after, err := ctrl.OAuthClients.FindByModul(ctx, modulID); err == nil {
+		ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityOAuthClient, modulID, before, after, nil)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "OAuth client updated successfully"})
+}
+
+
+// RotateOAuthClientSecret membuat client secret baru, secret lama langsung tidak berlaku
+func (ctrl *Controller) RotateOAuthClientSecret(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	modulID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidID, "Invalid modul ID")
+	}
+	before, err := ctrl.OAuthClients.FindByModul(ctx, modulID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("OAuth client not found")
+		}
+		return apperror.Wrap(err)
+	}
+	if before.Public {
+		return apperror.BadRequest(codeOAuthClientPublic, "Public clients do not have a secret")
+	}
+
+	secret, hash, err := middleware.NewClientSecret()
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+	updatedBy, _ := c.Locals("username").(string)
+	err = ctrl.OAuthClients.Update(ctx, modulID, repository.AnyVersion, repository.Fields{
+		"secret_hash": hash,
+		"updated_at":  primitive.NewDateTimeFromTime(time.Now().In(config.Location())),
+		"updated_by":  updatedBy,
+	})
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+	if after, err := ctrl.OAuthClients.FindByModul(ctx, modulID); err == nil {
+		ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityOAuthClient, modulID, before, after, fiber.Map{"reason": "secret_rotated"})
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"client_id": before.ClientID, "client_secret": secret})
+}
+
+
+// DeleteOAuthClient menghapus registrasi client. Tidak ada code atau token baru untuk client ini
+// dan access token-nya ditolak endpoint userinfo; id token tetap berlaku sampai kedaluwarsa.
+func (ctrl *Controller) DeleteOAuthClient(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	modulID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidID, "Invalid modul ID")
+	}
+	before, err := ctrl.OAuthClients.FindByModul(ctx, modulID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("OAuth client not found")
+		}
+		return apperror.Wrap(err)
+	}
+	if err := ctrl.OAuthClients.Delete(ctx, modulID); err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("OAuth client not found")
+		}
+		return apperror.Wrap(err)
+	}
+	ctrl.audit(c, ctx, models.AuditActionDelete, models.AuditEntityOAuthClient, modulID, before, nil, nil)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "OAuth client deleted successfully"})
+}
@@ -0,0 +1,439 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
+	"project-crud/middleware"
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// Kode error provider OpenID Connect pada endpoint milik portal (problem+json)
+const (
+	codeInvalidClient    = "invalid_client"
+	codeInvalidRedirect  = "invalid_redirect_uri"
+	codeModulNotAssigned = "modul_not_assigned"
+)
+
+// oauthError menulis error OAuth 2.0 (RFC 6749 bagian 5.2). Endpoint token dan userinfo
+// dipanggil oleh aplikasi modul, bukan portal, sehingga formatnya mengikuti standar OAuth.
+func oauthError(c *fiber.Ctx, status int, code, description string) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(fiber.Map{"error": code, "error_description": description})
+}
+
+// authorizeRedirect membangun URL kembali ke aplikasi modul dengan parameter tambahan
+func authorizeRedirect(redirectURI string, params url.Values) string {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := target.Query()
+	for key, values := range params {
+		for _, value := range values {
+			if value != "" {
+				query.Set(key, value)
+			}
+		}
+	}
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+// requestedScopes memisahkan parameter scope dan membuang scope yang tidak dikenal
+func requestedScopes(scope string) []string {
+	scopes := []string{}
+	for _, scope := range strings.Fields(scope) {
+		for _, supported := range models.SupportedScopes {
+			if scope == supported && !containsString(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
+
+// authorizationModul memastikan client dan modulnya masih ada dan user memiliki modul tersebut
+func (ctrl *Controller) authorizationModul(ctx context.Context, req *middleware.AuthorizationRequest, userID primitive.ObjectID) (*models.Modul, error) {
+	modulID, err := primitive.ObjectIDFromHex(req.ClientID)
+	if err != nil {
+		return nil, apperror.BadRequest(codeInvalidClient, "Unknown client_id")
+	}
+	if _, err := ctrl.OAuthClients.FindByModul(ctx, modulID); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, apperror.BadRequest(codeInvalidClient, "Unknown client_id")
+		}
+		return nil, apperror.Wrap(err)
+	}
+	modul, err := ctrl.Moduls.FindByID(ctx, modulID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, apperror.BadRequest(codeInvalidClient, "Unknown client_id")
+		}
+		return nil, apperror.Wrap(err)
+	}
+
+	user, err := ctrl.Users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, apperror.NotFound("User not found")
+		}
+		return nil, apperror.Wrap(err)
+	}
+	if !hasUserModul(user.UserModul, modul.ID) {
+		return nil, apperror.Forbidden(codeModulNotAssigned, "Modul is not assigned to the user")
+	}
+	return modul, nil
+}
+
+
+// OIDCDiscovery menerbitkan metadata provider (OpenID Connect Discovery 1.0)
+func (ctrl *Controller) OIDCDiscovery(c *fiber.Ctx) error {
+	issuer := ctrl.Auth.Config.OIDC.Issuer
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/oauth/authorize",
+		"token_endpoint":                        issuer + "/oauth/token",
+		"userinfo_endpoint":                     issuer + "/oauth/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      models.SupportedScopes,
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{ctrl.Auth.Config.TokenAlgorithm},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"claims_supported": []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce",
+			"name", "preferred_username", "updated_at", "picture", "gender", "email", "phone_number",
+		},
+	})
+}
+
+
+// OIDCAuthorize memvalidasi permintaan authorization code dari aplikasi modul lalu
+// mengarahkan browser ke halaman persetujuan portal. client_id atau redirect_uri yang
+// tidak valid tidak pernah di-redirect agar endpoint ini tidak menjadi open redirect.
+func (ctrl *Controller) OIDCAuthorize(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	clientID := c.Query("client_id")
+	redirectURI := c.Query("redirect_uri")
+	modulID, err := primitive.ObjectIDFromHex(clientID)
+	if err != nil {
+		return apperror.BadRequest(codeInvalidClient, "Unknown client_id")
+	}
+	client, err := ctrl.OAuthClients.FindByModul(ctx, modulID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.BadRequest(codeInvalidClient, "Unknown client_id")
+		}
+		return apperror.Wrap(err)
+	}
+	if _, err := ctrl.Moduls.FindByID(ctx, modulID); err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.BadRequest(codeInvalidClient, "Unknown client_id")
+		}
+		return apperror.Wrap(err)
+	}
+	if !client.HasRedirectURI(redirectURI) {
+		return apperror.BadRequest(codeInvalidRedirect, "redirect_uri is not registered for this client")
+	}
+
+	// Mulai dari sini error dikembalikan ke aplikasi modul lewat redirect_uri
+	state := c.Query("state")
+	fail := func(code, description string) error {
+		return c.Redirect(authorizeRedirect(redirectURI, url.Values{
+			"error":             {code},
+			"error_description": {description},
+			"state":             {state},
+		}), http.StatusFound)
+	}
+	if c.Query("response_type") != "code" {
+		return fail("unsupported_response_type", "Only response_type=code is supported")
+	}
+	scopes := requestedScopes(c.Query("scope"))
+	if !containsString(scopes, models.ScopeOpenID) {
+		return fail("invalid_scope", "The openid scope is required")
+	}
+	challenge := c.Query("code_challenge")
+	if challenge == "" || c.Query("code_challenge_method") != "S256" {
+		return fail("invalid_request", "PKCE with code_challenge_method=S256 is required")
+	}
+
+	request, err := ctrl.Auth.GenerateAuthorizationRequest(middleware.AuthorizationRequest{
+		ClientID:      client.ClientID,
+		RedirectURI:   redirectURI,
+		Scopes:        scopes,
+		State:         state,
+		Nonce:         c.Query("nonce"),
+		CodeChallenge: challenge,
+	})
+	if err != nil {
+		return fail("server_error", "Failed to create authorization request")
+	}
+	return c.Redirect(authorizeRedirect(ctrl.OIDCConsentURL, url.Values{"request": {request}}), http.StatusFound)
+}
+
+
+// GetOIDCAuthorization dipanggil halaman persetujuan portal untuk menampilkan aplikasi
+// yang meminta akses. consent_required false berarti scope sudah pernah disetujui dan
+// portal boleh langsung melanjutkan tanpa bertanya lagi.
+func (ctrl *Controller) GetOIDCAuthorization(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+	}
+	req, err := ctrl.Auth.ParseAuthorizationRequest(c.Query("request"))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeBadRequest, err.Error())
+	}
+	modul, err := ctrl.authorizationModul(ctx, req, userID)
+	if err != nil {
+		return err
+	}
+
+	consentRequired := true
+	consent, err := ctrl.OAuthConsents.Find(ctx, userID, req.ClientID)
+	if err == nil {
+		consentRequired = !consent.Covers(req.Scopes)
+	} else if err != repository.ErrNotFound {
+		return apperror.Wrap(err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"client_id":        req.ClientID,
+		"modul":            fiber.Map{"id": modul.ID, "name": modul.Name, "alamat_url": modul.AlamatURL, "gbr_icon": modul.GbrIcon},
+		"scopes":           req.Scopes,
+		"consent_required": consentRequired,
+	})
+}
+
+
+// DecideOIDCAuthorization mencatat keputusan user. Jika disetujui, authorization code
+// diterbitkan dan persetujuan disimpan; portal lalu mengarahkan browser ke redirect_to.
+func (ctrl *Controller) DecideOIDCAuthorization(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+	}
+
+	var input oauthDecisionInput
+	if err := c.BodyParser(&input); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
+	}
+	if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+		return err
+	}
+	req, err := ctrl.Auth.ParseAuthorizationRequest(input.Request)
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeBadRequest, err.Error())
+	}
+
+	if !input.Approve {
+		return c.Status(http.StatusOK).JSON(fiber.Map{"redirect_to": authorizeRedirect(req.RedirectURI, url.Values{
+			"error":             {"access_denied"},
+			"error_description": {"The user denied the request"},
+			"state":             {req.State},
+		})})
+	}
+
+	if _, err := ctrl.authorizationModul(ctx, req, userID); err != nil {
+		return err
+	}
+
+	// auth_time pada id token adalah waktu user login ke portal, bukan waktu persetujuan
+	authTime := time.Now()
+	if sessionID, ok := c.Locals("session_id").(primitive.ObjectID); ok {
+		if session, err := ctrl.Auth.Sessions.FindByID(ctx, sessionID); err == nil {
+			authTime = session.CreatedAt.Time()
+		}
+	}
+
+	code, err := ctrl.Auth.IssueAuthorizationCode(ctx, *req, userID, authTime)
+	if err != nil {
+		return apperror.Internal("Failed to issue authorization code").Wrap(err)
+	}
+	if err := ctrl.OAuthConsents.Grant(ctx, userID, req.ClientID, req.Scopes, time.Now()); err != nil {
+		return apperror.Internal("Failed to save consent").Wrap(err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"redirect_to": authorizeRedirect(req.RedirectURI, url.Values{
+		"code":  {code},
+		"state": {req.State},
+	})})
+}
+
+
+// clientCredentials mengambil client_id dan client_secret dari header Basic atau dari body form
+func clientCredentials(c *fiber.Ctx) (string, string) {
+	header := c.Get(fiber.HeaderAuthorization)
+	if strings.HasPrefix(header, "Basic ") {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, "Basic "))
+		if err == nil {
+			if id, secret, found := strings.Cut(string(raw), ":"); found {
+				// RFC 6749 bagian 2.3.1: keduanya di-encode form-urlencoded sebelum base64
+				id, errID := url.QueryUnescape(id)
+				secret, errSecret := url.QueryUnescape(secret)
+				if errID == nil && errSecret == nil {
+					return id, secret
+				}
+			}
+		}
+		return "", ""
+	}
+	return c.FormValue("client_id"), c.FormValue("client_secret")
+}
+
+
+// OIDCToken menukar authorization code dengan access token dan id token. Confidential
+// client wajib mengirim client_secret; public client hanya diamankan dengan PKCE.
+func (ctrl *Controller) OIDCToken(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if c.FormValue("grant_type") != "authorization_code" {
+		return oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "Only grant_type=authorization_code is supported")
+	}
+
+	clientID, secret := clientCredentials(c)
+	modulID, err := primitive.ObjectIDFromHex(clientID)
+	if err != nil {
+		return oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+	}
+	client, err := ctrl.OAuthClients.FindByModul(ctx, modulID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		}
+		return apperror.Wrap(err)
+	}
+	if !client.Public && !middleware.ClientSecretMatches(secret, client.SecretHash) {
+		return oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+	}
+
+	code, err := ctrl.Auth.ExchangeAuthorizationCode(ctx, c.FormValue("code"), client.ClientID, c.FormValue("redirect_uri"), c.FormValue("code_verifier"))
+	if err != nil {
+		if err == middleware.ErrInvalidGrant {
+			return oauthError(c, http.StatusBadRequest, "invalid_grant", err.Error())
+		}
+		return apperror.Wrap(err)
+	}
+
+	user, err := ctrl.Users.FindByID(ctx, code.UserID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return oauthError(c, http.StatusBadRequest, "invalid_grant", "User no longer exists")
+		}
+		return apperror.Wrap(err)
+	}
+	accessToken, idToken, err := ctrl.Auth.GenerateOIDCTokens(*user, code)
+	if err != nil {
+		return apperror.Internal("Failed to generate tokens").Wrap(err)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int64(ctrl.Auth.Config.OIDC.AccessTokenTTL.Seconds()),
+		"id_token":     idToken,
+		"scope":        strings.Join(code.Scopes, " "),
+	})
+}
+
+
+// OIDCUserInfo mengembalikan claim user sesuai scope pada access token aplikasi modul
+func (ctrl *Controller) OIDCUserInfo(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	invalidToken := func(description string) error {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+		return oauthError(c, http.StatusUnauthorized, "invalid_token", description)
+	}
+
+	header := c.Get(fiber.HeaderAuthorization)
+	if !strings.HasPrefix(header, "Bearer ") {
+		return invalidToken("Missing bearer token")
+	}
+	claims, userID, err := ctrl.Auth.ParseOIDCAccessToken(strings.TrimPrefix(header, "Bearer "))
+	if err != nil {
+		return invalidToken(err.Error())
+	}
+
+	// Access token hanya diterima selama client yang menjadi audience-nya masih terdaftar
+	if len(claims.Audience) != 1 {
+		return invalidToken("Invalid audience")
+	}
+	if _, err := ctrl.OAuthClients.FindByClientID(ctx, claims.Audience[0]); err != nil {
+		if err == repository.ErrNotFound {
+			return invalidToken("Invalid audience")
+		}
+		return apperror.Wrap(err)
+	}
+	user, err := ctrl.Users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return invalidToken("User no longer exists")
+		}
+		return apperror.Wrap(err)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(http.StatusOK).JSON(middleware.UserInfoClaims(*user, strings.Fields(claims.Scope)))
+}
+
+
+// GetMyOAuthConsents mengembalikan aplikasi modul yang pernah disetujui user
+func (ctrl *Controller) GetMyOAuthConsents(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+	}
+	consents, err := ctrl.OAuthConsents.ListForUser(ctx, userID)
+	if err != nil {
+		return apperror.Internal("Failed to fetch consents").Wrap(err)
+	}
+	return c.Status(http.StatusOK).JSON(consents)
+}
+
+
+// RevokeMyOAuthConsent mencabut persetujuan sehingga aplikasi modul harus meminta ulang.
+// Token yang sudah diterbitkan tetap berlaku sampai kedaluwarsa.
+func (ctrl *Controller) RevokeMyOAuthConsent(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+	}
+	if err := ctrl.OAuthConsents.Revoke(ctx, userID, c.Params("client_id")); err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("Consent not found")
+		}
+		return apperror.Wrap(err)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Consent revoked successfully"})
+}
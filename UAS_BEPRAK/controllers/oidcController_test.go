@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"project-crud/models"
+)
+
+func TestOIDCAuthorize(t *testing.T) {
+	const redirectURI = "https://a.unair.ac.id/callback"
+	const challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	tests := []struct {
+		name         string
+		query        url.Values
+		wantStatus   int
+		wantCode     string // kode error JSON jika tidak di-redirect
+		wantLocation string // awalan Location jika di-redirect
+		wantError    string // parameter error pada redirect ke aplikasi modul
+	}{
+		{
+			name:         "permintaan valid ke halaman persetujuan",
+			query:        url.Values{"redirect_uri": {redirectURI}, "code_challenge": {challenge}},
+			wantStatus:   fiber.StatusFound,
+			wantLocation: "http://localhost:3000/oauth/consent?request=",
+		},
+		{
+			name:       "client tidak dikenal",
+			query:      url.Values{"client_id": {"000000000000000000000000"}, "redirect_uri": {redirectURI}, "code_challenge": {challenge}},
+			wantStatus: fiber.StatusBadRequest,
+			wantCode:   codeInvalidClient,
+		},
+		{
+			name:       "redirect_uri tidak terdaftar",
+			query:      url.Values{"redirect_uri": {"https://evil.example/callback"}, "code_challenge": {challenge}},
+			wantStatus: fiber.StatusBadRequest,
+			wantCode:   codeInvalidRedirect,
+		},
+		{
+			name:       "redirect_uri dengan path tambahan",
+			query:      url.Values{"redirect_uri": {redirectURI + "/../evil"}, "code_challenge": {challenge}},
+			wantStatus: fiber.StatusBadRequest,
+			wantCode:   codeInvalidRedirect,
+		},
+		{
+			name:       "redirect_uri dengan query tambahan",
+			query:      url.Values{"redirect_uri": {redirectURI + "?next=https://evil.example"}, "code_challenge": {challenge}},
+			wantStatus: fiber.StatusBadRequest,
+			wantCode:   codeInvalidRedirect,
+		},
+		{
+			name:         "tanpa PKCE",
+			query:        url.Values{"redirect_uri": {redirectURI}},
+			wantStatus:   fiber.StatusFound,
+			wantLocation: redirectURI + "?",
+			wantError:    "invalid_request",
+		},
+		{
+			name:         "PKCE plain",
+			query:        url.Values{"redirect_uri": {redirectURI}, "code_challenge": {challenge}, "code_challenge_method": {"plain"}},
+			wantStatus:   fiber.StatusFound,
+			wantLocation: redirectURI + "?",
+			wantError:    "invalid_request",
+		},
+		{
+			name:         "tanpa scope openid",
+			query:        url.Values{"redirect_uri": {redirectURI}, "code_challenge": {challenge}, "scope": {"profile"}},
+			wantStatus:   fiber.StatusFound,
+			wantLocation: redirectURI + "?",
+			wantError:    "invalid_scope",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, store := newTestController(t)
+			ctrl.Auth.Config.KeyDir = t.TempDir()
+			if err := ctrl.Auth.LoadSigningKeys(); err != nil {
+				t.Fatal(err)
+			}
+			modulID := seedModul(t, store, "Alpha")
+			client := models.OAuthClient{ModulID: modulID, ClientID: modulID.Hex(), Public: true, RedirectURIs: []string{redirectURI}}
+			if err := store.OAuthClients.Create(context.Background(), &client); err != nil {
+				t.Fatal(err)
+			}
+			app := newTestApp()
+			app.Get("/oauth/authorize", ctrl.OIDCAuthorize)
+
+			query := url.Values{"client_id": {client.ClientID}, "response_type": {"code"}, "scope": {"openid profile"}, "state": {"xyz"}, "code_challenge_method": {"S256"}}
+			for key, values := range tt.query {
+				query[key] = values
+			}
+			resp, err := app.Test(httptest.NewRequest("GET", "/oauth/authorize?"+query.Encode(), nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			if tt.wantCode != "" {
+				var problem struct {
+					Code string `json:"code"`
+				}
+				json.NewDecoder(resp.Body).Decode(&problem)
+				if problem.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", problem.Code, tt.wantCode)
+				}
+				return
+			}
+			location := resp.Header.Get(fiber.HeaderLocation)
+			if !strings.HasPrefix(location, tt.wantLocation) {
+				t.Fatalf("Location = %q, want prefix %q", location, tt.wantLocation)
+			}
+			target, err := url.Parse(location)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := target.Query().Get("error"); got != tt.wantError {
+				t.Errorf("error = %q, want %q", got, tt.wantError)
+			}
+			if tt.wantError != "" && target.Query().Get("state") != "xyz" {
+				t.Errorf("state = %q, want xyz", target.Query().Get("state"))
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
+	"project-crud/config"
+	"project-crud/mailer"
+	"project-crud/middleware"
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// Kode error untuk token reset yang tidak bisa dipakai
+const codeInvalidResetToken = "invalid_reset_token"
+
+// Batas waktu mengirim satu email di luar siklus request
+const mailTimeout = 30 * time.Second
+
+// ForgotPassword mengirim tautan reset password ke email user. Respons selalu sama,
+// baik email terdaftar maupun tidak, agar endpoint ini tidak bisa dipakai menebak email.
+func (ctrl *Controller) ForgotPassword(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input forgotPasswordInput
+	if err := c.BodyParser(&input); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+	}
+	input.Email = strings.TrimSpace(input.Email)
+	if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+		return err
+	}
+
+	accepted := fiber.Map{"message": "If the email is registered, a password reset link has been sent"}
+
+	user, err := ctrl.Users.FindByEmail(ctx, input.Email)
+	if err == repository.ErrNotFound {
+		return c.Status(http.StatusAccepted).JSON(accepted)
+	}
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+
+	token, err := ctrl.Auth.IssuePasswordReset(ctx, *user, c.IP())
+	if err != nil {
+		return apperror.Internal("Failed to create password reset").Wrap(err)
+	}
+
+	// Email dikirim di belakang agar waktu respons tidak membedakan email yang terdaftar
+	if token != "" {
+		go ctrl.sendPasswordResetMail(*user, token)
+	}
+	return c.Status(http.StatusAccepted).JSON(accepted)
+}
+
+// sendPasswordResetMail mengirim tautan reset, kegagalan hanya di-log karena respons sudah dikirim
+func (ctrl *Controller) sendPasswordResetMail(user models.User, token string) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+
+	name := user.NmUser
+	if name == "" {
+		name = user.Username
+	}
+	link := ctrl.PasswordResetURL
+	if strings.Contains(link, "?") {
+		link += "&token=" + url.QueryEscape(token)
+	} else {
+		link += "?token=" + url.QueryEscape(token)
+	}
+
+	body := fmt.Sprintf(`Hello %s,
+
+We received a request to reset the password for the account "%s".
+Open the link below to choose a new password. The link expires in %d minutes and can only be used once.
+
+%s
+
+If you did not request a password reset, you can ignore this email and your password will stay the same.
+`, name, user.Username, int(ctrl.Auth.Config.PasswordReset.TokenTTL/time.Minute), link)
+
+	msg := mailer.Message{To: user.Email, Subject: "Reset your password", Body: body}
+	if err := ctrl.Mailer.Send(ctx, msg); err != nil {
+		log.Printf("Failed to send password reset mail to user %s: %v", user.ID.Hex(), err)
+	}
+}
+
+
+// ResetPassword mengganti password dengan token dari email. Token hanya bisa dipakai sekali,
+// semua sesi user dicabut dan kunci login akibat percobaan gagal ikut dibuka.
+func (ctrl *Controller) ResetPassword(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input resetPasswordInput
+	if err := c.BodyParser(&input); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+	}
+	// Validasi password baru sebelum token dipakai agar token tidak terbuang
+	if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+		return err
+	}
+
+	// Policy membutuhkan data pemilik token, jadi token hanya dibaca dulu tanpa dipakai
+	userID, err := ctrl.Auth.PasswordResetOwner(ctx, input.Token)
+	if err != nil {
+		if err == middleware.ErrInvalidResetToken {
+			return apperror.BadRequest(codeInvalidResetToken, "Password reset link is invalid or has expired")
+		}
+		return apperror.Wrap(err)
+	}
+
+	before, err := ctrl.Users.FindByID(ctx, userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.BadRequest(codeInvalidResetToken, "Password reset link is invalid or has expired")
+		}
+		return apperror.Wrap(err)
+	}
+	if err := ctrl.checkPassword("new_password", input.NewPassword, before); err != nil {
+		return err
+	}
+
+	// Token bisa saja sudah dipakai request lain setelah dibaca di atas
+	if _, err := ctrl.Auth.ConsumePasswordReset(ctx, input.Token); err != nil {
+		if err == middleware.ErrInvalidResetToken {
+			return apperror.BadRequest(codeInvalidResetToken, "Password reset link is invalid or has expired")
+		}
+		return apperror.Wrap(err)
+	}
+
+	hashedPassword, err := ctrl.Hasher.Hash(input.NewPassword)
+	if err != nil {
+		return apperror.Internal("Failed to hash password").Wrap(err)
+	}
+
+	update := ctrl.passwordUpdate(before, hashedPassword)
+	update["updated_at"] = primitive.NewDateTimeFromTime(time.Now().In(config.Location()))
+	update["updated_by"] = before.Username
+	err = ctrl.Users.Update(ctx, userID, repository.AnyVersion, update)
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+	// Request ini tanpa login, catat pemilik token sebagai pelaku pada audit log
+	c.Locals("username", before.Username)
+	c.Locals("user_id", before.ID)
+	if after, err := ctrl.Users.FindByID(ctx, userID); err == nil {
+		ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityUser, userID, before, after, fiber.Map{"reason": "password_reset"})
+	}
+
+	if err := ctrl.Auth.RevokeUserSessions(ctx, userID); err != nil {
+		return apperror.Internal("Failed to revoke user sessions").Wrap(err)
+	}
+	if err := ctrl.Auth.UnlockLogin(ctx, before.Username); err != nil {
+		log.Println("Failed to reset login failures:", err)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Password has been reset, please log in again"})
+}
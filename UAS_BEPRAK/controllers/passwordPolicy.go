@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"log"
+
+	"project-crud/apperror"
+	"project-crud/models"
+	"project-crud/password"
+	"project-crud/repository"
+	"project-crud/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkPassword memeriksa password baru terhadap ctrl.PasswordPolicy dan mengembalikan
+// validation.Errors (422) pada field yang diberikan. user adalah pemilik password dengan
+// username, nama dan email yang akan berlaku setelah perubahan; hash lamanya dipakai
+// untuk menolak password yang pernah dipakai.
+func (ctrl *Controller) checkPassword(field, pass string, user *models.User) error {
+	policy := ctrl.PasswordPolicy
+	errs := validation.Errors{}
+	for _, v := range policy.Check(pass, user.Username, user.NmUser, user.Email) {
+		errs = append(errs, validation.FieldError{Field: field, Code: v.Code, Message: v.Message})
+	}
+	if user.Pass != "" && ctrl.Hasher.Reused(pass, policy.RecentHashes(user.Pass, user.PasswordHistory)) {
+		errs = append(errs, validation.FieldError{Field: field, Code: password.CodeReused, Message: "must not match one of your recent passwords"})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// passwordUpdate mengembalikan field update untuk hash password baru. Hash lama digeser
+// ke password_history sesuai jumlah riwayat pada policy.
+func (ctrl *Controller) passwordUpdate(user *models.User, hash string) repository.Fields {
+	return repository.Fields{
+		"pass":             hash,
+		"password_history": ctrl.PasswordPolicy.NextHistory(user.Pass, user.PasswordHistory),
+	}
+}
+
+// verifyCurrentPassword memverifikasi password user untuk konfirmasi aksi sensitif. Tebakan
+// dihitung bersama login gagal milik username dan IP yang sama, sehingga token curian tidak
+// bisa dipakai untuk menebak password tanpa batas.
+func (ctrl *Controller) verifyCurrentPassword(c *fiber.Ctx, ctx context.Context, user *models.User, pass string) error {
+	ip := c.IP()
+	if err := ctrl.checkLoginBlocked(c, ctx, user.Username, ip); err != nil {
+		return err
+	}
+	match, _, err := ctrl.Hasher.Verify(pass, user.Pass)
+	if err != nil {
+		log.Printf("Failed to verify password of user %s: %v", user.ID.Hex(), err)
+	}
+	if !match {
+		if err := ctrl.Auth.LoginFailed(ctx, user.Username, ip); err != nil {
+			return apperror.Wrap(err)
+		}
+		return apperror.Forbidden(apperror.CodeForbidden, "Current password is incorrect")
+	}
+	return nil
+}
+
+// rehashPassword menyimpan ulang password dengan algoritma dan parameter default. Hanya pass
+// yang diganti karena password tidak berubah; kegagalan cukup dicatat dan dicoba lagi pada
+// login berikutnya.
+func (ctrl *Controller) rehashPassword(ctx context.Context, user *models.User, pass string) {
+	hash, err := ctrl.Hasher.Hash(pass)
+	if err == nil {
+		err = ctrl.Users.Update(ctx, user.ID, repository.AnyVersion, repository.Fields{"pass": hash})
+	}
+	if err != nil {
+		log.Printf("Failed to rehash password of user %s: %v", user.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"project-crud/apperror"
+)
+
+
+// GetMyDashboard mengembalikan modul milik user yang sedang login, dikelompokkan per kategori.
+// Response diberi ETag (lihat route) sehingga portal cukup revalidasi dengan If-None-Match.
+func (ctrl *Controller) GetMyDashboard(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
+	}
+
+	kategori, err := ctrl.Portal.Dashboard(ctx, userID)
+	if err != nil {
+		return apperror.Internal("Failed to load dashboard").Wrap(err)
+	}
+
+	// Data bersifat pribadi dan harus selalu direvalidasi ke server
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	return c.Status(http.StatusOK).JSON(fiber.Map{"kategori": kategori})
+}
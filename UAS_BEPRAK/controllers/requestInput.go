@@ -0,0 +1,74 @@
+package controllers
+
+// DTO request untuk data master. Aturan validasi ditulis di tag `validate`,
+// lihat package validation dan newValidator untuk daftar rule.
+
+// roleInput adalah body CreateRole dan EditRole. Permissions dan RequireMFA nil berarti tidak diubah saat edit.
+type roleInput struct {
+	Name        string   `json:"name" validate:"required,max=100,unique=roles.name"`
+	Permissions []string `json:"permissions" validate:"dive,permission"`
+	RequireMFA  *bool    `json:"require_mfa"`
+}
+
+// kategoriModulInput adalah body CreateKategoriModul dan EditKategoriModul
+type kategoriModulInput struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// createModulInput adalah body CreateModul
+type createModulInput struct {
+	Name          string `json:"name" validate:"required,max=100"`
+	Description   string `json:"description" validate:"required,max=1000"`
+	KategoriModul string `json:"kategori_modul" validate:"required,objectid,exists=kategori_moduls"`
+	AlamatURL     string `json:"alamat_url" validate:"required,url"`
+	GbrIcon       string `json:"gbr_icon" validate:"required,max=500"`
+}
+
+// editModulInput adalah body EditModul, field kosong tidak diubah
+type editModulInput struct {
+	Name        string `json:"name" validate:"max=100"`
+	Description string `json:"description" validate:"max=1000"`
+	AlamatURL   string `json:"alamat_url" validate:"url"`
+	GbrIcon     string `json:"gbr_icon" validate:"max=500"`
+}
+
+// createJenisUserInput adalah body CreateJenisUser
+type createJenisUserInput struct {
+	NmJenisUser   string               `json:"nm_jenis_user" validate:"required,max=100"`
+	TemplateModul []templateModulInput `json:"template_modul"`
+}
+
+// editJenisUserInput adalah body EditJenisUser. Nama kosong tidak diubah, template_modul
+// ditambahkan ke template yang sudah ada.
+type editJenisUserInput struct {
+	NmJenisUser   string               `json:"nm_jenis_user" validate:"max=100"`
+	TemplateModul []templateModulInput `json:"template_modul"`
+}
+
+type templateModulInput struct {
+	ModulID string `json:"modul_id" validate:"required,objectid,exists=moduls"`
+}
+
+// forgotPasswordInput adalah body ForgotPassword
+type forgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// oauthClientInput adalah body registrasi client OpenID Connect untuk modul. Public client
+// (SPA atau aplikasi mobile) tidak mendapat secret dan hanya diamankan dengan PKCE.
+type oauthClientInput struct {
+	RedirectURIs []string `json:"redirect_uris" validate:"required,max=10,dive,required,max=500,redirecturi"`
+	Public       bool     `json:"public"`
+}
+
+// oauthDecisionInput adalah body keputusan user pada halaman persetujuan
+type oauthDecisionInput struct {
+	Request string `json:"request" validate:"required"`
+	Approve bool   `json:"approve"`
+}
+
+// resetPasswordInput adalah body ResetPassword
+type resetPasswordInput struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,max=72"`
+}
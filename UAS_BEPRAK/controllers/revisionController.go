@@ -0,0 +1,383 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"project-crud/apperror"
+	"project-crud/config"
+	"project-crud/models"
+	"project-crud/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Nilai from/to pada diff revisi yang menunjuk dokumen saat ini
+const currentRevision = "current"
+
+// revisionListSpec adalah filter dan sort untuk daftar revisi
+var revisionListSpec = listSpec{
+	Filters: map[string]filterType{"action": filterString, "created_by": filterString},
+	Sorts:   []string{"revision", "created_at"},
+}
+
+// recordRevision menyimpan doc (isi dokumen sebelum diubah) sebagai revisi baru. Dipanggil
+// di dalam ctrl.Tx.WithTransaction setelah update berhasil, sehingga update yang gagal tidak
+// meninggalkan revisi dan revisi yang gagal disimpan membatalkan update.
+func recordRevision[T any](c *fiber.Ctx, ctx context.Context, repo repository.RevisionRepository[T], id primitive.ObjectID, doc T, action string, rollbackTo int) error {
+	username, _ := c.Locals("username").(string)
+	return repo.Record(ctx, &models.Revision[T]{
+		ID:         primitive.NewObjectID(),
+		EntityID:   id,
+		Action:     action,
+		RollbackTo: rollbackTo,
+		Document:   doc,
+		CreatedAt:  primitive.NewDateTimeFromTime(time.Now().In(config.Location())),
+		CreatedBy:  username,
+	})
+}
+
+// listRevisions mengembalikan riwayat revisi dokumen :id, terbaru lebih dulu jika sort tidak diisi
+func listRevisions[T any](c *fiber.Ctx, repo repository.RevisionRepository[T]) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
+	}
+
+	q, err := parseListQuery(c, revisionListSpec)
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+	}
+	if c.Query("sort") == "" {
+		q.Sort, q.Desc = "revision", true
+	}
+
+	revisions, err := repo.List(ctx, id, q)
+	if err != nil {
+		if err == repository.ErrInvalidCursor {
+			return apperror.BadRequest(apperror.CodeInvalidCursor, "Invalid cursor")
+		}
+		return apperror.Wrap(err)
+	}
+	return listResponse(c, q, revisions)
+}
+
+// parseRevisionNumber membaca nomor revisi positif
+func parseRevisionNumber(name, raw string) (int, error) {
+	revision, err := strconv.Atoi(raw)
+	if err != nil || revision < 1 {
+		return 0, fmt.Errorf("%s must be a revision number or %q", name, currentRevision)
+	}
+	return revision, nil
+}
+
+// diffRevisions membandingkan dua versi dokumen :id. Query from wajib diisi, to default "current";
+// keduanya berupa nomor revisi atau "current" untuk dokumen saat ini.
+func diffRevisions[T any](c *fiber.Ctx, repo repository.RevisionRepository[T], current func(ctx context.Context, id primitive.ObjectID) (*T, error), name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
+	}
+
+	from, to := c.Query("from"), c.Query("to", currentRevision)
+	if from == "" {
+		return apperror.BadRequest(apperror.CodeBadRequest, "from is required")
+	}
+
+	// load mengambil isi dokumen pada versi yang diminta
+	load := func(param, version string) (*T, error) {
+		if version == currentRevision {
+			doc, err := current(ctx, id)
+			if err == repository.ErrNotFound {
+				return nil, apperror.NotFound(name + " not found")
+			}
+			return doc, err
+		}
+		number, err := parseRevisionNumber(param, version)
+		if err != nil {
+			return nil, apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+		}
+		rev, err := repo.Find(ctx, id, number)
+		if err == repository.ErrNotFound {
+			return nil, apperror.NotFound("Revision " + version + " not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &rev.Document, nil
+	}
+
+	before, err := load("from", from)
+	if err != nil {
+		return err
+	}
+	after, err := load("to", to)
+	if err != nil {
+		return err
+	}
+
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		return apperror.Wrap(err)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"id":      id,
+		"from":    from,
+		"to":      to,
+		"changes": changes,
+	})
+}
+
+// Kode error untuk rollback yang gagal karena dokumen berubah di tengah proses
+const codeRollbackConflict = "rollback_conflict"
+
+// rollbackConflict mengirim 409 jika dokumen berubah di antara pembacaan dan rollback
+func rollbackConflict() error {
+	return apperror.Conflict(codeRollbackConflict, "Document was modified during rollback, try again")
+}
+
+// rollbackTarget membaca ID dokumen dari parameter dan nomor revisi tujuan dari body
+func rollbackTarget(c *fiber.Ctx) (primitive.ObjectID, int, error) {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return id, 0, fmt.Errorf("Invalid ID format")
+	}
+	var input struct {
+		Revision int `json:"revision"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return id, 0, err
+	}
+	if input.Revision < 1 {
+		return id, 0, fmt.Errorf("revision must be a positive integer")
+	}
+	return id, input.Revision, nil
+}
+
+
+// GetModulRevisions menampilkan riwayat revisi modul. Filter: action, created_by.
+func (ctrl *Controller) GetModulRevisions(c *fiber.Ctx) error {
+	return listRevisions(c, ctrl.ModulRevisions)
+}
+
+
+// DiffModulRevisions membandingkan dua revisi modul, misalnya ?from=2&to=current
+func (ctrl *Controller) DiffModulRevisions(c *fiber.Ctx) error {
+	return diffRevisions(c, ctrl.ModulRevisions, ctrl.Moduls.FindByID, "Modul")
+}
+
+
+// RollbackModul mengembalikan isi modul ke revisi tertentu. Versi saat ini disimpan
+// sebagai revisi baru sehingga rollback juga bisa dibatalkan.
+func (ctrl *Controller) RollbackModul(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, revision, err := rollbackTarget(c)
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeBadRequest, err.Error())
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return preconditionFailed()
+	}
+
+	current, err := ctrl.Moduls.FindByID(ctx, objID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("Modul not found")
+		}
+		return apperror.Wrap(err)
+	}
+	if !versionMatches(version, current.Version) {
+		return preconditionFailed()
+	}
+	rev, err := ctrl.ModulRevisions.Find(ctx, objID, revision)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("Revision not found")
+		}
+		return apperror.Wrap(err)
+	}
+
+	// Kategori pada revisi lama mungkin sudah dihapus
+	target := rev.Document
+	if _, err := ctrl.KategoriModuls.FindByID(ctx, target.KategoriModul); err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.Conflict(apperror.CodeConflict, "Kategori modul of this revision no longer exists")
+		}
+		return apperror.Wrap(err)
+	}
+
+	// Update bersyarat pada versi yang dibaca agar tidak menimpa edit lain
+	username, _ := c.Locals("username").(string)
+	err = ctrl.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+		err := ctrl.Moduls.Update(ctx, objID, current.Version, repository.Fields{
+			"name":           target.Name,
+			"description":    target.Description,
+			"kategori_modul": target.KategoriModul,
+			"alamat_url":     target.AlamatURL,
+			"gbr_icon":       target.GbrIcon,
+			"updated_at":     primitive.NewDateTimeFromTime(time.Now().In(config.Location())),
+			"updated_by":     username,
+		})
+		if err != nil {
+			return err
+		}
+		return recordRevision(c, ctx, ctrl.ModulRevisions, objID, *current, models.RevisionActionRollback, revision)
+	})
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("Modul not found")
+		}
+		if err == repository.ErrVersionConflict {
+			return rollbackConflict()
+		}
+		return apperror.Wrap(err)
+	}
+
+	updatedModul, err := ctrl.Moduls.FindByID(ctx, objID)
+	if err != nil {
+		return apperror.Internal("Failed to fetch updated data").Wrap(err)
+	}
+	ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityModul, objID, current, updatedModul, fiber.Map{"rollback_to": revision})
+
+	setVersionETag(c, updatedModul.Version)
+	return c.Status(http.StatusOK).JSON(updatedModul)
+}
+
+
+// GetJenisUserRevisions menampilkan riwayat revisi jenis user. Filter: action, created_by.
+func (ctrl *Controller) GetJenisUserRevisions(c *fiber.Ctx) error {
+	return listRevisions(c, ctrl.JenisUserRevisions)
+}
+
+
+// DiffJenisUserRevisions membandingkan dua revisi jenis user, misalnya ?from=2&to=current
+func (ctrl *Controller) DiffJenisUserRevisions(c *fiber.Ctx) error {
+	return diffRevisions(c, ctrl.JenisUserRevisions, ctrl.JenisUsers.FindByID, "Jenis user")
+}
+
+
+// RollbackJenisUser mengembalikan nama dan template_modul jenis user ke revisi tertentu.
+// Dengan ?sync=apply user_modul semua user disamakan dengan template hasil rollback
+// (seperti SyncJenisUser), dengan ?sync=dry_run hanya ditampilkan dampaknya.
+func (ctrl *Controller) RollbackJenisUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	objID, revision, err := rollbackTarget(c)
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeBadRequest, err.Error())
+	}
+	mode, err := parseSyncMode(c, syncNone)
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return preconditionFailed()
+	}
+
+	current, err := ctrl.JenisUsers.FindByID(ctx, objID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("Jenis user not found")
+		}
+		return apperror.Wrap(err)
+	}
+	if !versionMatches(version, current.Version) {
+		return preconditionFailed()
+	}
+	rev, err := ctrl.JenisUserRevisions.Find(ctx, objID, revision)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("Revision not found")
+		}
+		return apperror.Wrap(err)
+	}
+
+	// Modul pada template revisi lama mungkin sudah dihapus
+	templates := rev.Document.TemplateModul
+	if templates == nil {
+		templates = []models.TemplateModul{}
+	}
+	if err := ctrl.checkTemplateModuls(ctx, templates); err != nil {
+		if err == errModulNotFound {
+			return apperror.Conflict(apperror.CodeConflict, "Template of this revision references a modul that no longer exists")
+		}
+		return apperror.Wrap(err)
+	}
+
+	username, _ := c.Locals("username").(string)
+	now := primitive.NewDateTimeFromTime(time.Now().In(config.Location()))
+	keep := []primitive.ObjectID{}
+	for _, tmpl := range templates {
+		keep = append(keep, tmpl.ModulID)
+	}
+	plan := templateSyncPlan{Add: templates, Prune: true, Keep: keep}
+
+	// Dry-run tidak mengubah template maupun user
+	if mode == syncDryRun {
+		result, err := ctrl.syncTemplateModul(ctx, objID, plan, mode, username, now)
+		if err != nil {
+			return syncError(c, err)
+		}
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"message": "Dry run, nothing was changed",
+			"data":    rev.Document,
+			"sync":    result,
+		})
+	}
+
+	err = ctrl.Tx.WithTransaction(ctx, func(ctx context.Context) error {
+		err := ctrl.JenisUsers.Update(ctx, objID, current.Version, repository.Fields{
+			"nm_jenis_user":  rev.Document.NmJenisUser,
+			"template_modul": templates,
+			"updated_at":     now,
+			"updated_by":     username,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		return recordRevision(c, ctx, ctrl.JenisUserRevisions, objID, *current, models.RevisionActionRollback, revision)
+	})
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("Jenis user not found")
+		}
+		if err == repository.ErrVersionConflict {
+			return rollbackConflict()
+		}
+		return apperror.Wrap(err)
+	}
+
+	updatedJenisUser, err := ctrl.JenisUsers.FindByID(ctx, objID)
+	if err != nil {
+		return apperror.Internal("Failed to fetch updated data").Wrap(err)
+	}
+	ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityJenisUser, objID, current, updatedJenisUser, fiber.Map{"rollback_to": revision})
+
+	if mode == syncApply {
+		result, err := ctrl.syncTemplateModul(ctx, objID, plan, mode, username, now)
+		if err != nil {
+			return syncError(c, err)
+		}
+		ctrl.auditSync(c, ctx, objID, result)
+		setVersionETag(c, updatedJenisUser.Version)
+		return c.Status(http.StatusOK).JSON(fiber.Map{"data": updatedJenisUser, "sync": result})
+	}
+
+	setVersionETag(c, updatedJenisUser.Version)
+	return c.Status(http.StatusOK).JSON(updatedJenisUser)
+}
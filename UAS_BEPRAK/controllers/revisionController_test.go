@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+type txKey struct{}
+
+// markingTransactor menandai ctx yang diteruskan ke fn agar test bisa memeriksa
+// operasi mana yang berjalan di dalam transaksi
+type markingTransactor struct{}
+
+func (markingTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
+
+// checkedRevisions mencatat apakah Record dipanggil di dalam transaksi dan bisa dibuat gagal
+type checkedRevisions struct {
+	repository.RevisionRepository[models.Modul]
+	err      error
+	recorded []bool
+}
+
+func (r *checkedRevisions) Record(ctx context.Context, rev *models.Revision[models.Modul]) error {
+	inTx, _ := ctx.Value(txKey{}).(bool)
+	r.recorded = append(r.recorded, inTx)
+	if r.err != nil {
+		return r.err
+	}
+	return r.RevisionRepository.Record(ctx, rev)
+}
+
+func TestEditModulRecordsRevisionInTransaction(t *testing.T) {
+	tests := []struct {
+		name       string
+		recordErr  error
+		wantStatus int
+	}{
+		{"revisi tersimpan", nil, fiber.StatusOK},
+		{"revisi gagal disimpan", errors.New("write failed"), fiber.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, store := newTestController(t)
+			revisions := &checkedRevisions{RevisionRepository: ctrl.ModulRevisions, err: tt.recordErr}
+			ctrl.ModulRevisions = revisions
+			ctrl.Tx = markingTransactor{}
+			id := seedModul(t, store, "Alpha")
+
+			app := newTestApp()
+			app.Put("/modul/:id", ctrl.EditModul)
+			status, _ := doRequestIfMatch(t, app, "PUT", "/modul/"+id.Hex(), "", fiber.Map{"name": "Beta"})
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if len(revisions.recorded) != 1 || !revisions.recorded[0] {
+				t.Errorf("Record calls in transaction = %v, want [true]", revisions.recorded)
+			}
+		})
+	}
+}
@@ -6,56 +6,68 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 
+	"project-crud/apperror"
 	"project-crud/config"
 	"project-crud/models"
+	"project-crud/repository"
 )
 
-var roleCollection *mongo.Collection = config.GetCollection("roles")
+// Kode error 403 jika admin memberi permission yang tidak dimilikinya sendiri
+const codePermissionNotHeld = "permission_not_held"
+
+// checkGrantable menolak permission baru yang tidak dimiliki role pemanggil, sehingga pemegang
+// role:update tidak bisa menaikkan hak aksesnya sendiri (misalnya memberi "*" ke role-nya).
+// Permission yang sudah ada pada role (current) boleh tetap dipertahankan.
+func checkGrantable(c *fiber.Ctx, requested, current []string) error {
+    held, _ := c.Locals("permissions").([]string)
+    caller := models.Role{Permissions: held}
+
+    missing := []string{}
+    for _, permission := range requested {
+        if caller.HasPermission(permission) || containsString(current, permission) {
+            continue
+        }
+        missing = append(missing, permission)
+    }
+    if len(missing) > 0 {
+        return apperror.Forbidden(codePermissionNotHeld, "Cannot grant permissions you do not hold").With("permissions", missing)
+    }
+    return nil
+}
+
 
 //  Create Role
-func CreateRole(c *fiber.Ctx) error {
+func (ctrl *Controller) CreateRole(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    var role models.Role
-    if err := c.BodyParser(&role); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    var input roleInput
+    if err := c.BodyParser(&input); err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
     }
 
     // Ambil username dari user yang sedang login
     loggedInUsername, ok := c.Locals("username").(string)
     if !ok || loggedInUsername == "" {
-        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized access"})
-    }
-
-    // Validasi input
-    if role.Name == "" {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Role name is required"})
+        return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
     }
 
-    // Ambil username dari context (middleware)
-    username := c.Locals("username").(string)
-    if username == "" {
-        return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "User not authenticated"})
+    // Validasi input, nama role harus unik dan permission harus dikenal
+    if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+        return err
     }
-
-    // Cek apakah role dengan nama yang sama sudah ada
-    var existingRole models.Role
-    err := roleCollection.FindOne(ctx, bson.M{"name": role.Name}).Decode(&existingRole)
-    if err == nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Role name already exists"})
+    if err := checkGrantable(c, input.Permissions, nil); err != nil {
+        return err
     }
-
-    if err != mongo.ErrNoDocuments {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+    role := models.Role{Name: input.Name, Permissions: input.Permissions, RequireMFA: input.RequireMFA != nil && *input.RequireMFA}
+    if role.Permissions == nil {
+        role.Permissions = []string{}
     }
 
     // Set waktu dan user yang membuat
-    loc, _ := time.LoadLocation("Asia/Jakarta")
+    loc := config.Location()
     role.ID = primitive.NewObjectID()
     role.CreatedAt = primitive.NewDateTimeFromTime(time.Now().In(loc))
     role.UpdatedAt = role.CreatedAt
@@ -65,72 +77,66 @@ func CreateRole(c *fiber.Ctx) error {
      role.UpdatedBy = loggedInUsername
 
     // Masukkan role ke database
-    _, err = roleCollection.InsertOne(ctx, role)
+    err := ctrl.Roles.Create(ctx, &role)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.Wrap(err)
     }
+    ctrl.audit(c, ctx, models.AuditActionCreate, models.AuditEntityRole, role.ID, nil, role, nil)
 
     return c.Status(http.StatusCreated).JSON(role)
 }
 
 
 // GetRoles untuk mendapatkan semua role
-func GetRoles(c *fiber.Ctx) error {
+func (ctrl *Controller) GetRoles(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    // Query semua role dari koleksi
-    cursor, err := roleCollection.Find(ctx, bson.M{})
+    // Query satu halaman role sesuai sort dan paginasi
+    q, err := parseListQuery(c, roleListSpec)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch roles"})
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
     }
-    defer cursor.Close(ctx)
 
-    // Iterasi hasil query
-    var roles []models.Role
-    for cursor.Next(ctx) {
-        var role models.Role
-        if err := cursor.Decode(&role); err != nil {
-            return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode role"})
+    roles, err := ctrl.Roles.List(ctx, q)
+    if err != nil {
+        if err == repository.ErrInvalidCursor {
+            return apperror.BadRequest(apperror.CodeInvalidCursor, "Invalid cursor")
         }
-        roles = append(roles, role)
-    }
-
-    if err := cursor.Err(); err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Cursor error: " + err.Error()})
+        return apperror.Internal("Failed to fetch roles")
     }
 
-    // Kembalikan daftar role
-    return c.Status(http.StatusOK).JSON(roles)
+    // Kembalikan daftar role beserta meta paginasi
+    return listResponse(c, q, roles)
 }
 
 
 // GetRoles by ID
-func GetRole(c *fiber.Ctx) error {
+func (ctrl *Controller) GetRole(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
     roleID := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(roleID)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid role ID"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid role ID")
     }
 
-    var role models.Role
-    err = roleCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&role)
+    role, err := ctrl.Roles.FindByID(ctx, objID)
     if err != nil {
-        if err == mongo.ErrNoDocuments {
-            return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Role not found"})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Role not found")
         }
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.Wrap(err)
     }
 
+    setVersionETag(c, role.Version)
     return c.Status(http.StatusOK).JSON(role)
 }
 
 
 // Update Role by ID
-func EditRole(c *fiber.Ctx) error {
+func (ctrl *Controller) EditRole(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -138,43 +144,80 @@ func EditRole(c *fiber.Ctx) error {
     roleID := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(roleID)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid role ID"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid role ID")
     }
 
     // Parsing input
-    var roleInput models.Role
-    if err := c.BodyParser(&roleInput); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    var input roleInput
+    if err := c.BodyParser(&input); err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
     }
 
-    // Validasi input
-    if roleInput.Name == "" {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Role name is required"})
+    // Validasi input, nama boleh sama dengan nama role ini sendiri
+    if err := ctrl.Validator.Struct(withUniqueExcept(ctx, objID), &input); err != nil {
+        return err
     }
 
     // Ambil username dari context (middleware)
     username := c.Locals("username").(string)
     if username == "" {
-        return c.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "User not authenticated"})
+        return apperror.Unauthorized(apperror.CodeUnauthorized, "User not authenticated")
     }
 
-    // Update data
-    loc, _ := time.LoadLocation("Asia/Jakarta")
-    update := bson.M{
-        "$set": bson.M{
-            "name":       roleInput.Name,
-            "updated_at": primitive.NewDateTimeFromTime(time.Now().In(loc)),
-            "updated_by": username,
-        },
+    // Versi dari If-Match, update ditolak jika dokumen sudah diubah pihak lain
+    version, ok := ifMatchVersion(c)
+    if !ok {
+        return preconditionFailed()
     }
 
-    result, err := roleCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+    // Simpan kondisi sebelum diubah untuk audit log
+    before, err := ctrl.Roles.FindByID(ctx, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Role not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if !versionMatches(version, before.Version) {
+        return preconditionFailed()
+    }
+    // Role dengan permission yang tidak dimiliki pemanggil (misalnya role super admin) tidak
+    // boleh diubah, termasuk nama dan require_mfa-nya
+    if err := checkGrantable(c, before.Permissions, nil); err != nil {
+        return err
+    }
+    if err := checkGrantable(c, input.Permissions, before.Permissions); err != nil {
+        return err
     }
 
-    if result.MatchedCount == 0 {
-        return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Role not found"})
+    // Update data
+    loc := config.Location()
+    updateFields := repository.Fields{
+        "name":       input.Name,
+        "updated_at": primitive.NewDateTimeFromTime(time.Now().In(loc)),
+        "updated_by": username,
+    }
+
+    // Permission hanya diganti jika dikirim pada request
+    if input.Permissions != nil {
+        updateFields["permissions"] = input.Permissions
+    }
+    if input.RequireMFA != nil {
+        updateFields["require_mfa"] = *input.RequireMFA
+    }
+
+    err = ctrl.Roles.Update(ctx, objID, version, updateFields)
+    if err != nil {
+        if err == repository.ErrVersionConflict {
+            return preconditionFailed()
+        }
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Role not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if after, err := ctrl.Roles.FindByID(ctx, objID); err == nil {
+        ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityRole, objID, before, after, nil)
     }
 
     return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Role updated successfully"})
@@ -182,7 +225,7 @@ func EditRole(c *fiber.Ctx) error {
 
 
 // Delete Role
-func DeleteRole(c *fiber.Ctx) error {
+func (ctrl *Controller) DeleteRole(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -190,18 +233,56 @@ func DeleteRole(c *fiber.Ctx) error {
     roleID := c.Params("id")
     objID, err := primitive.ObjectIDFromHex(roleID)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid role ID"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid role ID")
     }
 
-    // Hapus data
-    result, err := roleCollection.DeleteOne(ctx, bson.M{"_id": objID})
+    // Policy untuk user yang masih memakai role ini
+    policy, err := parseDeletePolicy(c, objID)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
     }
 
-    if result.DeletedCount == 0 {
-        return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Role not found"})
+    // Seperti EditRole, role dengan permission yang tidak dimiliki pemanggil tidak boleh dihapus
+    role, err := ctrl.Roles.FindByID(ctx, objID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Role not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if err := checkGrantable(c, role.Permissions, nil); err != nil {
+        return err
+    }
+    if policy.Policy == policyReassign {
+        replacement, err := ctrl.Roles.FindByID(ctx, policy.ReassignTo)
+        if err != nil {
+            if err == repository.ErrNotFound {
+                return apperror.BadRequest(apperror.CodeBadRequest, "Replacement role not found")
+            }
+            return apperror.Wrap(err)
+        }
+        // Sama seperti EditRoleFromUser, user hanya boleh dipindah ke role yang bisa diberikan pemanggil
+        if err := checkGrantable(c, replacement.Permissions, nil); err != nil {
+            return err
+        }
     }
 
-    return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Role deleted successfully"})
+    // Pindahkan ke trash
+    username, _ := c.Locals("username").(string)
+    dependents := []dependent{
+        {Name: "users", Repo: ctrl.Users, Ref: repository.RefField{Field: "role_id"}, RevokeSessions: true},
+    }
+    return ctrl.deleteWithPolicy(c, ctx, policy, objID, models.AuditEntityRole, dependents, func(ctx context.Context) (interface{}, error) {
+        role, err := ctrl.Roles.FindByID(ctx, objID)
+        if err != nil {
+            return nil, err
+        }
+        return role, ctrl.Roles.SoftDelete(ctx, objID, username)
+    }, "Role not found", "Role deleted successfully")
+}
+
+
+// GetPermissions mengembalikan daftar permission yang dapat diberikan ke role
+func (ctrl *Controller) GetPermissions(c *fiber.Ctx) error {
+    return c.Status(http.StatusOK).JSON(models.AllPermissions)
 }
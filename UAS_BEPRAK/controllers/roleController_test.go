@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/models"
+)
+
+// seedRole menyimpan role baru dengan permission tertentu
+func seedRole(t *testing.T, ctrl *Controller, name string, permissions ...string) primitive.ObjectID {
+	t.Helper()
+	role := models.Role{ID: primitive.NewObjectID(), Name: name, Permissions: append([]string{}, permissions...)}
+	if err := ctrl.Roles.Create(context.Background(), &role); err != nil {
+		t.Fatal(err)
+	}
+	return role.ID
+}
+
+func TestEditRoleGrantable(t *testing.T) {
+	// Pemanggil memegang role:update dan user:read saja
+	tests := []struct {
+		name            string
+		rolePermissions []string
+		body            fiber.Map
+		wantStatus      int
+	}{
+		{"ganti nama role yang bisa diberikan", []string{models.PermUserRead}, fiber.Map{"name": "baru"}, http.StatusOK},
+		{"ganti nama role super admin", []string{models.PermAll}, fiber.Map{"name": "baru"}, http.StatusForbidden},
+		{"kurangi permission yang tidak dimiliki", []string{models.PermUserRead, models.PermUserDelete}, fiber.Map{"name": "baru", "permissions": []string{models.PermUserRead}}, http.StatusForbidden},
+		{"tambah permission yang tidak dimiliki", []string{models.PermUserRead}, fiber.Map{"name": "baru", "permissions": []string{models.PermUserRead, models.PermUserDelete}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, _ := newTestController(t)
+			app := newTestApp(models.PermRoleUpdate, models.PermUserRead)
+			app.Put("/roles/:id", ctrl.EditRole)
+			id := seedRole(t, ctrl, "lama", tt.rolePermissions...)
+
+			status, _ := doRequestIfMatch(t, app, http.MethodPut, "/roles/"+id.Hex(), "", tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+
+			role, err := ctrl.Roles.FindByID(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if role.Name != "baru" {
+					t.Errorf("name = %s, want baru", role.Name)
+				}
+				return
+			}
+			if role.Name != "lama" || !reflect.DeepEqual(role.Permissions, tt.rolePermissions) {
+				t.Errorf("role = (%s, %v), want unchanged (lama, %v)", role.Name, role.Permissions, tt.rolePermissions)
+			}
+		})
+	}
+}
+
+func TestDeleteRoleGrantable(t *testing.T) {
+	tests := []struct {
+		name            string
+		rolePermissions []string
+		wantStatus      int
+	}{
+		{"role dengan permission yang dimiliki", []string{models.PermUserRead}, http.StatusOK},
+		{"role super admin", []string{models.PermAll}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, _ := newTestController(t)
+			app := newTestApp(models.PermRoleDelete, models.PermUserRead)
+			app.Delete("/roles/:id", ctrl.DeleteRole)
+			id := seedRole(t, ctrl, "lama", tt.rolePermissions...)
+
+			status, body := doRequest(t, app, http.MethodDelete, "/roles/"+id.Hex())
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%v)", status, tt.wantStatus, body)
+			}
+			_, err := ctrl.Roles.FindByID(context.Background(), id)
+			if deleted := err != nil; deleted != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("role deleted = %v, want %v", deleted, tt.wantStatus == http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-crud/apperror"
+	"project-crud/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Panjang minimal teks pencarian
+const minSearchLength = 2
+
+// parseSearchQuery membaca q, limit dan filter (sama seperti endpoint daftar) dari query string
+func parseSearchQuery(c *fiber.Ctx, spec listSpec) (repository.SearchQuery, error) {
+	q := repository.SearchQuery{
+		Text:  strings.TrimSpace(c.Query("q")),
+		Limit: repository.DefaultLimit,
+	}
+	if len([]rune(q.Text)) < minSearchLength {
+		return q, fmt.Errorf("q must be at least %d characters", minSearchLength)
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > repository.MaxLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d", repository.MaxLimit)
+		}
+		q.Limit = limit
+	}
+
+	filter, err := parseFilters(c, spec)
+	if err != nil {
+		return q, err
+	}
+	q.Filter = filter
+	return q, nil
+}
+
+// searchResponse mengembalikan hasil pencarian yang sudah terurut berdasarkan skor
+func searchResponse[T any](c *fiber.Ctx, q repository.SearchQuery, hits []repository.SearchHit[T]) error {
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"data": hits,
+		"meta": fiber.Map{
+			"q":     q.Text,
+			"limit": q.Limit,
+			"count": len(hits),
+		},
+	})
+}
+
+
+// SearchUsers mencari user berdasarkan nama, username, email atau nomor telepon
+func (ctrl *Controller) SearchUsers(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	q, err := parseSearchQuery(c, userListSpec)
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+	}
+
+	hits, err := ctrl.Users.Search(ctx, q)
+	if err != nil {
+		return apperror.Internal("Failed to search users").Wrap(err)
+	}
+
+	return searchResponse(c, q, hits)
+}
+
+
+// SearchModuls mencari modul berdasarkan nama atau deskripsi
+func (ctrl *Controller) SearchModuls(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	q, err := parseSearchQuery(c, modulListSpec)
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+	}
+
+	hits, err := ctrl.Moduls.Search(ctx, q)
+	if err != nil {
+		return apperror.Internal("Failed to search moduls").Wrap(err)
+	}
+
+	return searchResponse(c, q, hits)
+}
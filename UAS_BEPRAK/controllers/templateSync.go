@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// Mode sinkronisasi template_modul ke user yang sudah ada (query param "sync")
+const (
+	syncNone   = ""        // Hanya mengubah template, user lama tidak disentuh
+	syncApply  = "apply"   // Ubah template lalu terapkan ke semua user dengan jenis user tersebut
+	syncDryRun = "dry_run" // Tidak mengubah apa pun, hanya menampilkan user yang akan terdampak
+)
+
+// templateSyncPlan adalah perubahan template yang akan diterapkan ke user
+type templateSyncPlan struct {
+	Add    []models.TemplateModul // Modul yang ditambahkan ke user yang belum memilikinya
+	Remove []primitive.ObjectID   // Modul yang dicabut dari user (kecuali pemberian manual)
+	Prune  bool                   // Cabut juga modul bersumber template yang tidak ada di Keep
+	Keep   []primitive.ObjectID
+}
+
+// templateSyncChange adalah perubahan satu modul pada user dengan jenis user tertentu
+type templateSyncChange struct {
+	ModulID *primitive.ObjectID  `json:"modul_id,omitempty" bson:"modul_id,omitempty"` // Kosong untuk action "prune"
+	Action  string               `json:"action" bson:"action"`                         // "add", "remove" atau "prune"
+	UserIDs []primitive.ObjectID `json:"user_ids" bson:"user_ids"`
+}
+
+// templateSyncResult adalah ringkasan sinkronisasi yang dikirim ke client dan dicatat di audit log
+type templateSyncResult struct {
+	Mode          string               `json:"mode" bson:"mode"`
+	Changes       []templateSyncChange `json:"changes" bson:"changes"`
+	AffectedUsers int                  `json:"affected_users" bson:"affected_users"`
+}
+
+// parseSyncMode membaca query param sync
+func parseSyncMode(c *fiber.Ctx, defaultMode string) (string, error) {
+	mode := c.Query("sync", defaultMode)
+	switch mode {
+	case syncNone, syncApply, syncDryRun:
+		return mode, nil
+	}
+	return "", fmt.Errorf("sync must be %q or %q", syncApply, syncDryRun)
+}
+
+// errModulNotFound dikembalikan jika template menunjuk modul yang tidak ada
+var errModulNotFound = fmt.Errorf("modul not found")
+
+// templateUserModuls membuat UserModul bersumber template untuk setiap modul pada templates
+func (ctrl *Controller) templateUserModuls(ctx context.Context, templates []models.TemplateModul, username string, now primitive.DateTime) ([]models.UserModul, error) {
+	userModuls := []models.UserModul{}
+	for _, tmpl := range templates {
+		modul, err := ctrl.Moduls.FindByID(ctx, tmpl.ModulID)
+		if err != nil {
+			if err == repository.ErrNotFound {
+				return nil, errModulNotFound
+			}
+			return nil, err
+		}
+
+		userModuls = append(userModuls, models.UserModul{
+			ModulID:   tmpl.ModulID,
+			NamaModul: modul.Name,
+			Source:    models.UserModulSourceTemplate,
+			CreatedAt: now,
+			CreatedBy: username,
+			UpdatedAt: now,
+			UpdatedBy: username,
+		})
+	}
+	return userModuls, nil
+}
+
+// syncTemplateModul menerapkan plan pada semua user dengan jenis user tersebut.
+// Modul yang diberikan manual, termasuk data lama tanpa source, tidak pernah dicabut.
+func (ctrl *Controller) syncTemplateModul(ctx context.Context, jenisUserID primitive.ObjectID, plan templateSyncPlan, mode, username string, now primitive.DateTime) (*templateSyncResult, error) {
+	dryRun := mode == syncDryRun
+	result := &templateSyncResult{Mode: mode, Changes: []templateSyncChange{}}
+	affected := map[primitive.ObjectID]bool{}
+	record := func(modulID *primitive.ObjectID, action string, userIDs []primitive.ObjectID) {
+		if len(userIDs) == 0 {
+			return
+		}
+		result.Changes = append(result.Changes, templateSyncChange{ModulID: modulID, Action: action, UserIDs: userIDs})
+		for _, id := range userIDs {
+			affected[id] = true
+		}
+	}
+
+	userModuls, err := ctrl.templateUserModuls(ctx, plan.Add, username, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, userModul := range userModuls {
+		userIDs, err := ctrl.Users.AddTemplateModul(ctx, jenisUserID, userModul, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		modulID := userModul.ModulID
+		record(&modulID, "add", userIDs)
+	}
+
+	for _, modulID := range plan.Remove {
+		modulID := modulID
+		userIDs, err := ctrl.Users.RemoveTemplateModul(ctx, jenisUserID, modulID, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		record(&modulID, "remove", userIDs)
+	}
+
+	if plan.Prune {
+		userIDs, err := ctrl.Users.PruneTemplateModuls(ctx, jenisUserID, plan.Keep, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		record(nil, "prune", userIDs)
+	}
+
+	result.AffectedUsers = len(affected)
+	return result, nil
+}
+
+// auditSync mencatat user yang terdampak sinkronisasi template pada audit log jenis user
+func (ctrl *Controller) auditSync(c *fiber.Ctx, ctx context.Context, jenisUserID primitive.ObjectID, result *templateSyncResult) {
+	if result.Mode != syncApply || result.AffectedUsers == 0 {
+		return
+	}
+	ctrl.audit(c, ctx, models.AuditActionUpdate, models.AuditEntityJenisUser, jenisUserID, nil, nil, fiber.Map{"sync": result})
+}
+
+// checkTemplateModuls memastikan semua modul pada templates ada sebelum template diubah
+func (ctrl *Controller) checkTemplateModuls(ctx context.Context, templates []models.TemplateModul) error {
+	for _, tmpl := range templates {
+		if _, err := ctrl.Moduls.FindByID(ctx, tmpl.ModulID); err != nil {
+			if err == repository.ErrNotFound {
+				return errModulNotFound
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// syncError mengirim response untuk error dari sinkronisasi template
+func syncError(c *fiber.Ctx, err error) error {
+	if err == errModulNotFound {
+		return apperror.BadRequest(apperror.CodeBadRequest, "Modul not found")
+	}
+	return apperror.Internal("Failed to synchronize users").Wrap(err)
+}
+
+// hasTemplateModul memeriksa apakah modul ada di template_modul
+func hasTemplateModul(templates []models.TemplateModul, modulID primitive.ObjectID) bool {
+	for _, tmpl := range templates {
+		if tmpl.ModulID == modulID {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUserModul memeriksa apakah modul ada di user_modul
+func hasUserModul(userModuls []models.UserModul, modulID primitive.ObjectID) bool {
+	for _, userModul := range userModuls {
+		if userModul.ModulID == modulID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/models"
+)
+
+func TestSyncTemplateModul(t *testing.T) {
+	// Modul A, B, C; user jenis user "mhs":
+	//   budi: A dari template
+	//   cici: B dari template, C manual
+	//   dodi: C data lama tanpa source
+	// eko memakai jenis user lain dan tidak boleh ikut berubah.
+	type sync struct {
+		add    []string
+		remove []string
+		prune  bool
+		keep   []string
+	}
+	tests := []struct {
+		name        string
+		plan        sync
+		mode        string
+		wantChanges []string
+		wantModuls  map[string][]string
+	}{
+		{
+			name:        "add hanya ke user yang belum punya",
+			plan:        sync{add: []string{"A"}},
+			mode:        syncApply,
+			wantChanges: []string{"add A: cici,dodi"},
+			wantModuls: map[string][]string{
+				"budi": {"A:template"},
+				"cici": {"A:template", "B:template", "C:manual"},
+				"dodi": {"A:template", "C:"},
+				"eko":  {"B:template"},
+			},
+		},
+		{
+			name:        "add tidak mengganti modul manual",
+			plan:        sync{add: []string{"C"}},
+			mode:        syncApply,
+			wantChanges: []string{"add C: budi"},
+			wantModuls: map[string][]string{
+				"budi": {"A:template", "C:template"},
+				"cici": {"B:template", "C:manual"},
+				"dodi": {"C:"},
+				"eko":  {"B:template"},
+			},
+		},
+		{
+			name:        "remove hanya modul dari template",
+			plan:        sync{remove: []string{"B", "C"}},
+			mode:        syncApply,
+			wantChanges: []string{"remove B: cici"},
+			wantModuls: map[string][]string{
+				"budi": {"A:template"},
+				"cici": {"C:manual"},
+				"dodi": {"C:"},
+				"eko":  {"B:template"},
+			},
+		},
+		{
+			name:        "prune mempertahankan keep, manual dan data lama",
+			plan:        sync{prune: true, keep: []string{"A"}},
+			mode:        syncApply,
+			wantChanges: []string{"prune -: cici"},
+			wantModuls: map[string][]string{
+				"budi": {"A:template"},
+				"cici": {"C:manual"},
+				"dodi": {"C:"},
+				"eko":  {"B:template"},
+			},
+		},
+		{
+			name:        "sinkronisasi penuh",
+			plan:        sync{add: []string{"B"}, prune: true, keep: []string{"B"}},
+			mode:        syncApply,
+			wantChanges: []string{"add B: budi,dodi", "prune -: budi"},
+			wantModuls: map[string][]string{
+				"budi": {"B:template"},
+				"cici": {"B:template", "C:manual"},
+				"dodi": {"B:template", "C:"},
+				"eko":  {"B:template"},
+			},
+		},
+		{
+			name:        "dry run tidak mengubah user",
+			plan:        sync{add: []string{"B"}, prune: true, keep: []string{"B"}},
+			mode:        syncDryRun,
+			wantChanges: []string{"add B: budi,dodi", "prune -: budi"},
+			wantModuls: map[string][]string{
+				"budi": {"A:template"},
+				"cici": {"B:template", "C:manual"},
+				"dodi": {"C:"},
+				"eko":  {"B:template"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, store := newTestController(t)
+			ctx := context.Background()
+
+			moduls := map[string]primitive.ObjectID{}
+			names := map[primitive.ObjectID]string{}
+			for _, name := range []string{"A", "B", "C"} {
+				moduls[name] = seedModul(t, store, name)
+				names[moduls[name]] = name
+			}
+			userModul := func(name, source string) models.UserModul {
+				return models.UserModul{ModulID: moduls[name], NamaModul: name, Source: source}
+			}
+			mhs := seedJenisUser(t, store, "mhs")
+			users := map[string]primitive.ObjectID{
+				"budi": seedUser(t, store, "budi", mhs, userModul("A", models.UserModulSourceTemplate)),
+				"cici": seedUser(t, store, "cici", mhs, userModul("B", models.UserModulSourceTemplate), userModul("C", models.UserModulSourceManual)),
+				"dodi": seedUser(t, store, "dodi", mhs, userModul("C", "")),
+				"eko":  seedUser(t, store, "eko", seedJenisUser(t, store, "dosen"), userModul("B", models.UserModulSourceTemplate)),
+			}
+			for name, id := range users {
+				names[id] = name
+			}
+
+			plan := templateSyncPlan{Prune: tt.plan.prune}
+			for _, name := range tt.plan.add {
+				plan.Add = append(plan.Add, models.TemplateModul{ModulID: moduls[name]})
+			}
+			for _, name := range tt.plan.remove {
+				plan.Remove = append(plan.Remove, moduls[name])
+			}
+			for _, name := range tt.plan.keep {
+				plan.Keep = append(plan.Keep, moduls[name])
+			}
+
+			result, err := ctrl.syncTemplateModul(ctx, mhs, plan, tt.mode, "admin", primitive.NewDateTimeFromTime(time.Now()))
+			if err != nil {
+				t.Fatalf("syncTemplateModul() error = %v", err)
+			}
+
+			changes := []string{}
+			affected := map[primitive.ObjectID]bool{}
+			for _, change := range result.Changes {
+				modul := "-"
+				if change.ModulID != nil {
+					modul = names[*change.ModulID]
+				}
+				userNames := []string{}
+				for _, id := range change.UserIDs {
+					userNames = append(userNames, names[id])
+					affected[id] = true
+				}
+				sort.Strings(userNames)
+				changes = append(changes, change.Action+" "+modul+": "+strings.Join(userNames, ","))
+			}
+			if !reflect.DeepEqual(changes, tt.wantChanges) {
+				t.Errorf("changes = %v, want %v", changes, tt.wantChanges)
+			}
+			if result.AffectedUsers != len(affected) {
+				t.Errorf("affected_users = %d, want %d", result.AffectedUsers, len(affected))
+			}
+
+			for name, id := range users {
+				got := []string{}
+				for _, modul := range findUser(t, store, id).UserModul {
+					got = append(got, names[modul.ModulID]+":"+modul.Source)
+				}
+				sort.Strings(got)
+				if !reflect.DeepEqual(got, tt.wantModuls[name]) {
+					t.Errorf("user_modul %s = %v, want %v", name, got, tt.wantModuls[name])
+				}
+			}
+		})
+	}
+}
+
+func TestSyncTemplateModulMissingModul(t *testing.T) {
+	ctrl, store := newTestController(t)
+	mhs := seedJenisUser(t, store, "mhs")
+	seedUser(t, store, "budi", mhs)
+
+	plan := templateSyncPlan{Add: []models.TemplateModul{{ModulID: primitive.NewObjectID()}}}
+	_, err := ctrl.syncTemplateModul(context.Background(), mhs, plan, syncApply, "admin", primitive.NewDateTimeFromTime(time.Now()))
+	if err != errModulNotFound {
+		t.Fatalf("syncTemplateModul() error = %v, want errModulNotFound", err)
+	}
+}
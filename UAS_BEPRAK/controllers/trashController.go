@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"project-crud/apperror"
+	"project-crud/models"
+	"project-crud/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// trashSpec menurunkan listSpec untuk isi trash: bisa difilter deleted_by dan diurutkan deleted_at
+func trashSpec(spec listSpec) listSpec {
+	filters := map[string]filterType{"deleted_by": filterString}
+	for field, kind := range spec.Filters {
+		filters[field] = kind
+	}
+	sorts := append([]string{"deleted_at"}, spec.Sorts...)
+	return listSpec{Filters: filters, Sorts: sorts}
+}
+
+// listTrash mengembalikan satu halaman isi trash dengan envelope yang sama seperti daftar biasa
+func listTrash[T any](c *fiber.Ctx, repo repository.Trash[T], spec listSpec) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	q, err := parseListQuery(c, trashSpec(spec))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
+	}
+
+	page, err := repo.ListDeleted(ctx, q)
+	if err != nil {
+		if err == repository.ErrInvalidCursor {
+			return apperror.BadRequest(apperror.CodeInvalidCursor, "Invalid cursor")
+		}
+		return apperror.Wrap(err)
+	}
+	return listResponse(c, q, page)
+}
+
+// trashRepository adalah repository entitas yang mendukung soft delete
+type trashRepository[T any] interface {
+	repository.Trash[T]
+	FindByID(ctx context.Context, id primitive.ObjectID) (*T, error)
+}
+
+// restoreFromTrash memulihkan dokumen :id dari trash. conflict (boleh nil) mengembalikan pesan
+// jika dokumen bentrok dengan data aktif yang dibuat setelah dihapus, dan restore ditolak dengan 409.
+func restoreFromTrash[T any](ctrl *Controller, c *fiber.Ctx, repo trashRepository[T], conflict func(ctx context.Context, doc *T) (string, error), name, entity string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidID, "Invalid " + name + " ID")
+	}
+
+	doc, err := repo.FindDeleted(ctx, id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound(name + " not found in trash")
+		}
+		return apperror.Wrap(err)
+	}
+
+	if conflict != nil {
+		message, err := conflict(ctx, doc)
+		if err != nil {
+			return apperror.Wrap(err)
+		}
+		if message != "" {
+			return apperror.Conflict(apperror.CodeConflict, message)
+		}
+	}
+
+	if err := repo.Restore(ctx, id); err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound(name + " not found in trash")
+		}
+		if err == repository.ErrDuplicate {
+			return apperror.Conflict(apperror.CodeConflict, name+" conflicts with an existing document")
+		}
+		return apperror.Wrap(err)
+	}
+	if restored, err := repo.FindByID(ctx, id); err == nil {
+		ctrl.audit(c, ctx, models.AuditActionRestore, entity, id, doc, restored, nil)
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": name + " restored successfully",
+		"id":      id,
+	})
+}
+
+
+// GetTrashRoles menampilkan role yang ada di trash
+func (ctrl *Controller) GetTrashRoles(c *fiber.Ctx) error {
+	return listTrash[models.Role](c, ctrl.Roles, roleListSpec)
+}
+
+
+// RestoreRole memulihkan role dari trash, ditolak jika namanya sudah dipakai role lain
+func (ctrl *Controller) RestoreRole(c *fiber.Ctx) error {
+	return restoreFromTrash[models.Role](ctrl, c, ctrl.Roles, func(ctx context.Context, role *models.Role) (string, error) {
+		_, err := ctrl.Roles.FindByName(ctx, role.Name)
+		if err == repository.ErrNotFound {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return "Role name already exists", nil
+	}, "Role", models.AuditEntityRole)
+}
+
+
+// GetTrashKategoriModuls menampilkan kategori modul yang ada di trash
+func (ctrl *Controller) GetTrashKategoriModuls(c *fiber.Ctx) error {
+	return listTrash[models.KategoriModul](c, ctrl.KategoriModuls, kategoriModulListSpec)
+}
+
+
+// RestoreKategoriModul memulihkan kategori modul dari trash
+func (ctrl *Controller) RestoreKategoriModul(c *fiber.Ctx) error {
+	return restoreFromTrash[models.KategoriModul](ctrl, c, ctrl.KategoriModuls, nil, "Kategori modul", models.AuditEntityKategoriModul)
+}
+
+
+// GetTrashModuls menampilkan modul yang ada di trash
+func (ctrl *Controller) GetTrashModuls(c *fiber.Ctx) error {
+	return listTrash[models.Modul](c, ctrl.Moduls, modulListSpec)
+}
+
+
+// RestoreModul memulihkan modul dari trash. Referensi yang sudah dicabut atau dipindahkan
+// saat penghapusan (policy cascade/reassign) tidak ikut dipulihkan.
+func (ctrl *Controller) RestoreModul(c *fiber.Ctx) error {
+	return restoreFromTrash[models.Modul](ctrl, c, ctrl.Moduls, nil, "Modul", models.AuditEntityModul)
+}
+
+
+// GetTrashJenisUsers menampilkan jenis user yang ada di trash
+func (ctrl *Controller) GetTrashJenisUsers(c *fiber.Ctx) error {
+	return listTrash[models.JenisUser](c, ctrl.JenisUsers, jenisUserListSpec)
+}
+
+
+// RestoreJenisUser memulihkan jenis user dari trash
+func (ctrl *Controller) RestoreJenisUser(c *fiber.Ctx) error {
+	return restoreFromTrash[models.JenisUser](ctrl, c, ctrl.JenisUsers, nil, "Jenis user", models.AuditEntityJenisUser)
+}
+
+
+// GetTrashUsers menampilkan user yang ada di trash
+func (ctrl *Controller) GetTrashUsers(c *fiber.Ctx) error {
+	return listTrash[models.User](c, ctrl.Users, userListSpec)
+}
+
+
+// RestoreUser memulihkan user dari trash. Ditolak jika username atau email-nya sudah dipakai
+// user lain, atau role dan jenis user-nya sudah tidak ada.
+func (ctrl *Controller) RestoreUser(c *fiber.Ctx) error {
+	return restoreFromTrash[models.User](ctrl, c, ctrl.Users, func(ctx context.Context, user *models.User) (string, error) {
+		if _, err := ctrl.Users.FindByUsername(ctx, user.Username); err != repository.ErrNotFound {
+			if err != nil {
+				return "", err
+			}
+			return "Username already exists", nil
+		}
+		if user.Email != "" {
+			if _, err := ctrl.Users.FindByEmail(ctx, user.Email); err != repository.ErrNotFound {
+				if err != nil {
+					return "", err
+				}
+				return "Email already exists", nil
+			}
+		}
+		if _, err := ctrl.Roles.FindByID(ctx, user.RoleID); err != nil {
+			if err == repository.ErrNotFound {
+				return "Role of the user no longer exists", nil
+			}
+			return "", err
+		}
+		if _, err := ctrl.JenisUsers.FindByID(ctx, user.JenisUserID); err != nil {
+			if err == repository.ErrNotFound {
+				return "Jenis user of the user no longer exists", nil
+			}
+			return "", err
+		}
+		return "", nil
+	}, "User", models.AuditEntityUser)
+}
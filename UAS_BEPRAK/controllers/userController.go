@@ -2,122 +2,183 @@ package controllers
 
 import (
 	"context"
-	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"golang.org/x/crypto/bcrypt"
 
+	"project-crud/apperror"
 	"project-crud/config"
-	"project-crud/middleware"
 	"project-crud/models"
+	"project-crud/repository"
 )
 
-var userCollection *mongo.Collection = config.GetCollection("users")
-
 // Fungsi login untuk memverifikasi user dan memberikan token
-func Login(c *fiber.Ctx) error {
+func (ctrl *Controller) Login(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    var inputUser models.User
+    // Pass pada models.User tidak ikut JSON, jadi body login dibaca dengan struct sendiri
+    var inputUser struct {
+        Username string `json:"username"`
+        Pass     string `json:"pass"`
+    }
     if err := c.BodyParser(&inputUser); err != nil {
-        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+        return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
     }
 
-    // Cari user berdasarkan username di database
-    var user models.User
-    err := userCollection.FindOne(ctx, bson.M{"username": inputUser.Username}).Decode(&user)
-    if err != nil {
-        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+    // Tolak lebih dulu jika username atau IP sedang dikunci, tanpa memeriksa password
+    ip := c.IP()
+    if err := ctrl.checkLoginBlocked(c, ctx, inputUser.Username, ip); err != nil {
+        return err
     }
 
-    // Verifikasi password menggunakan bcrypt
-    err = bcrypt.CompareHashAndPassword([]byte(user.Pass), []byte(inputUser.Pass))
-    if err != nil {
-        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid password"})
+    // Cari user berdasarkan username di database
+    user, err := ctrl.Users.FindByUsername(ctx, inputUser.Username)
+    if err != nil && err != repository.ErrNotFound {
+        return apperror.Wrap(err)
+    }
+
+    // Verifikasi password dengan algoritma yang tercatat pada hash. Username yang tidak ada tetap
+    // diverifikasi terhadap hash pengganti dan dijawab dengan error yang sama, sehingga respons
+    // maupun waktunya tidak bisa dipakai untuk menebak username yang terdaftar.
+    match, rehash := false, false
+    if user != nil {
+        match, rehash, err = ctrl.Hasher.Verify(inputUser.Pass, user.Pass)
+        if err != nil {
+            log.Printf("Failed to verify password of user %s: %v", user.ID.Hex(), err)
+        }
+    } else {
+        ctrl.Hasher.VerifyDummy(inputUser.Pass)
+    }
+    if !match {
+        userID := primitive.NilObjectID
+        if user != nil {
+            userID = user.ID
+        }
+        ctrl.recordLogin(c, ctx, inputUser.Username, userID, models.LoginResultInvalidCredentials)
+        if err := ctrl.Auth.LoginFailed(ctx, inputUser.Username, ip); err != nil {
+            return apperror.Wrap(err)
+        }
+        return apperror.Unauthorized(apperror.CodeInvalidCredentials, "Invalid username or password")
     }
 
-    // Middleware Auth - Verifikasi Token JWT
-    token, err := middleware.GenerateToken(user.Username, user.RoleID, user.JenisUserID)
-    if err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate token"})
+    // Hash dengan algoritma atau parameter lama diganti selagi password polosnya tersedia
+    if rehash {
+        ctrl.rehashPassword(ctx, user, inputUser.Pass)
     }
 
     // Middleware CheckRole - Verifikasi apakah role user sesuai
     if user.RoleID == primitive.NilObjectID {
-        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User has no assigned role"})
+        ctrl.recordLogin(c, ctx, inputUser.Username, user.ID, models.LoginResultAccountIncomplete)
+        return apperror.Unauthorized(apperror.CodeUnauthorized, "User has no assigned role")
     }
 
-    var role models.Role
-    // Pastikan Anda mencari role dari roleCollection, bukan userCollection
-    err = roleCollection.FindOne(ctx, bson.M{"_id": user.RoleID}).Decode(&role)
+    // Pastikan Anda mencari role dari repository role, bukan repository user
+    role, err := ctrl.Roles.FindByID(ctx, user.RoleID)
     if err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve role"})
+        return apperror.Internal("Failed to retrieve role").Wrap(err)
     }
 
     // Middleware CheckJenisUser - Verifikasi apakah jenis user valid
     if user.JenisUserID == primitive.NilObjectID {
-        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "User has no assigned jenis user"})
+        ctrl.recordLogin(c, ctx, inputUser.Username, user.ID, models.LoginResultAccountIncomplete)
+        return apperror.Unauthorized(apperror.CodeUnauthorized, "User has no assigned jenis user")
     }
 
-    var jenisUser models.JenisUser
-    err = jenisUserCollection.FindOne(ctx, bson.M{"_id": user.JenisUserID}).Decode(&jenisUser)
+    jenisUser, err := ctrl.JenisUsers.FindByID(ctx, user.JenisUserID)
     if err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve jenis user"})
+        return apperror.Internal("Failed to retrieve jenis user").Wrap(err)
     }
 
-    // Jika semua validasi berhasil, kirimkan token
-    return c.Status(fiber.StatusOK).JSON(fiber.Map{
-        "token":      token,
-        "role":       role.Name,
-        "jenis_user": jenisUser.NmJenisUser,
-    })
+    // User dengan MFA aktif, atau yang role-nya mewajibkan MFA, harus menyelesaikan langkah kedua
+    // lewat /api/login/mfa. Penghitung gagal baru direset setelah langkah kedua berhasil.
+    enroll := role.RequireMFA && !user.MFAEnabled()
+    if user.MFAEnabled() || enroll {
+        mfaToken, err := ctrl.Auth.GenerateMFAToken(*user, enroll)
+        if err != nil {
+            return apperror.Internal("Failed to generate token").Wrap(err)
+        }
+        ctrl.recordLogin(c, ctx, inputUser.Username, user.ID, models.LoginResultMFARequired)
+        return c.Status(fiber.StatusOK).JSON(fiber.Map{
+            "mfa_required":            true,
+            "mfa_enrollment_required": enroll,
+            "mfa_token":               mfaToken,
+            "expires_in":              int64(ctrl.Auth.Config.MFATokenTTL / time.Second),
+        })
+    }
+
+    // Jika semua validasi berhasil, buat sesi baru dan kirimkan token
+    return ctrl.completeLogin(c, ctx, inputUser.Username, user, role, jenisUser, nil)
 }
 
 
 // CreateUser membuat user baru
-func CreateUser(c *fiber.Ctx) error {
+func (ctrl *Controller) CreateUser(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    var user models.User
-    if err := c.BodyParser(&user); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    var input createUserInput
+    if err := c.BodyParser(&input); err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
     }
 
     // Ambil username dari user yang sedang login
     loggedInUsername, ok := c.Locals("username").(string)
     if !ok || loggedInUsername == "" {
-        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized access"})
+        return apperror.Unauthorized(apperror.CodeUnauthorized, "Unauthorized access")
     }
 
-    // Periksa apakah username sudah ada
-    var existingUser models.User
-    err := userCollection.FindOne(ctx, bson.M{"username": user.Username}).Decode(&existingUser)
-    if err == nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Username already exists"})
+    // Validasi input, termasuk username/email unik dan role/jenis user yang ada
+    if err := ctrl.Validator.Struct(ctx, &input); err != nil {
+        return err
     }
-    if err != mongo.ErrNoDocuments {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+
+    // Password harus memenuhi policy dan tidak memuat username, nama atau email user baru
+    owner := &models.User{Username: input.Username, NmUser: input.NmUser, Email: input.Email}
+    if err := ctrl.checkPassword("pass", input.Pass, owner); err != nil {
+        return err
     }
 
     // Hash password
-    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Pass), bcrypt.DefaultCost)
+    hashedPassword, err := ctrl.Hasher.Hash(input.Pass)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to hash password"})
+        return apperror.Internal("Failed to hash password").Wrap(err)
     }
-    user.Pass = string(hashedPassword)
 
-    // Generate ID untuk user baru
-    user.ID = primitive.NewObjectID()
+    // ID sudah divalidasi sebagai ObjectID yang ada
+    roleID, _ := primitive.ObjectIDFromHex(input.RoleID)
+    jenisUserID, _ := primitive.ObjectIDFromHex(input.JenisUserID)
+
+    // Admin tidak boleh membuat user dengan role yang lebih tinggi dari role-nya sendiri
+    role, err := ctrl.Roles.FindByID(ctx, roleID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.BadRequest(apperror.CodeBadRequest, "Role not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if err := checkGrantable(c, role.Permissions, nil); err != nil {
+        return err
+    }
+    user := models.User{
+        ID:           primitive.NewObjectID(),
+        Username:     strings.TrimSpace(input.Username),
+        NmUser:       strings.TrimSpace(input.NmUser),
+        Pass:         hashedPassword,
+        Email:        strings.TrimSpace(input.Email),
+        Photo:        input.Photo,
+        Phone:        strings.TrimSpace(input.Phone),
+        RoleID:       roleID,
+        JenisKelamin: input.JenisKelamin,
+        JenisUserID:  jenisUserID,
+    }
 
     // Waktu pembuatan
-    loc, _ := time.LoadLocation("Asia/Jakarta")
+    loc := config.Location()
     now := primitive.NewDateTimeFromTime(time.Now().In(loc))
     user.CreatedAt = now
     user.UpdatedAt = now
@@ -127,76 +188,53 @@ func CreateUser(c *fiber.Ctx) error {
     user.UpdatedBy = loggedInUsername
 
     // Ambil jenis user
-    var jenisUser models.JenisUser
-    err = jenisUserCollection.FindOne(ctx, bson.M{"_id": user.JenisUserID}).Decode(&jenisUser)
+    jenisUser, err := ctrl.JenisUsers.FindByID(ctx, user.JenisUserID)
     if err != nil {
-        if err == mongo.ErrNoDocuments {
-            return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid jenis user ID"})
-        }
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.Wrap(err)
     }
 
     // Isi user_modul dari template_modul di jenis_user
-    if len(jenisUser.TemplateModul) == 0 {
-        user.UserModul = []models.UserModul{}
-    } else {
-        for _, tmpl := range jenisUser.TemplateModul {
-            var modul models.Modul
-            err := modulCollection.FindOne(ctx, bson.M{"_id": tmpl.ModulID}).Decode(&modul)
-            if err != nil {
-                return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Modul not found"})
-            }
-
-            user.UserModul = append(user.UserModul, models.UserModul{
-                ModulID:   tmpl.ModulID,
-                NamaModul: modul.Name,
-                CreatedAt: now,
-                CreatedBy: loggedInUsername,
-                UpdatedAt: now,
-                UpdatedBy: loggedInUsername,
-            })
-        }
+    user.UserModul, err = ctrl.templateUserModuls(ctx, jenisUser.TemplateModul, loggedInUsername, now)
+    if err != nil {
+        return apperror.Internal("Modul not found").Wrap(err)
     }
 
     // Simpan user ke database
-    _, err = userCollection.InsertOne(ctx, user)
+    err = ctrl.Users.Create(ctx, &user)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.Wrap(err)
     }
+    ctrl.audit(c, ctx, models.AuditActionCreate, models.AuditEntityUser, user.ID, nil, user, nil)
 
     return c.Status(http.StatusCreated).JSON(user)
 }
 
 
 // GetAllUsers mendapatkan semua user dari koleksi
-func GetAllUsers(c *fiber.Ctx) error {
+func (ctrl *Controller) GetAllUsers(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    // Menyimpan semua user dalam slice
-    var users []models.User
-
-    // Query untuk mendapatkan semua data user
-    cursor, err := userCollection.Find(ctx, bson.M{})
+    // Query satu halaman user sesuai filter, sort dan paginasi
+    q, err := parseListQuery(c, userListSpec)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.BadRequest(apperror.CodeInvalidQuery, err.Error())
     }
-    defer cursor.Close(ctx)
 
-    for cursor.Next(ctx) {
-        var user models.User
-        if err := cursor.Decode(&user); err != nil {
-            return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+    users, err := ctrl.Users.List(ctx, q)
+    if err != nil {
+        if err == repository.ErrInvalidCursor {
+            return apperror.BadRequest(apperror.CodeInvalidCursor, "Invalid cursor")
         }
-        users = append(users, user)
+        return apperror.Wrap(err)
     }
 
-    return c.Status(http.StatusOK).JSON(users)
+    return listResponse(c, q, users)
 }
 
 
 // GetUserByID mendapatkan user berdasarkan ID
-func GetUserByID(c *fiber.Ctx) error {
+func (ctrl *Controller) GetUserByID(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -204,25 +242,25 @@ func GetUserByID(c *fiber.Ctx) error {
     id := c.Params("id")
     objectID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
     }
 
     // Cari user berdasarkan ID
-    var user models.User
-    err = userCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
+    user, err := ctrl.Users.FindByID(ctx, objectID)
     if err != nil {
-        if err == mongo.ErrNoDocuments {
-            return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
         }
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return apperror.Wrap(err)
     }
 
+    setVersionETag(c, user.Version)
     return c.Status(http.StatusOK).JSON(user)
 }
 
 
 // EditUser memperbarui data user berdasarkan ID
-func EditUser(c *fiber.Ctx) error {
+func (ctrl *Controller) EditUser(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -230,38 +268,184 @@ func EditUser(c *fiber.Ctx) error {
     id := c.Params("id")
     objectID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
     }
 
-    // Parse input dari body request
-    var updateData map[string]interface{}
-    if err := c.BodyParser(&updateData); err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+    // Parse input dari body request, hanya field profil yang boleh diubah di sini
+    input, err := parseEditUserInput(c.Body())
+    if err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, err.Error())
+    }
+    input.trim()
+    if err := ctrl.Validator.Struct(withUniqueExcept(ctx, objectID), input); err != nil {
+        return err
+    }
+
+    // Versi dari If-Match, update ditolak jika dokumen sudah diubah pihak lain
+    version, ok := ifMatchVersion(c)
+    if !ok {
+        return preconditionFailed()
     }
 
-    // Hindari perubahan pada _id
-    delete(updateData, "_id")
+    // Simpan kondisi sebelum diubah untuk audit log
+    before, err := ctrl.Users.FindByID(ctx, objectID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if !versionMatches(version, before.Version) {
+        return preconditionFailed()
+    }
 
-    // Tambahkan updated_at
-    loc, _ := time.LoadLocation("Asia/Jakarta")
+    updateData := input.fields()
+    // Password baru diperiksa terhadap policy memakai data profil setelah perubahan, lalu disimpan dalam bentuk hash
+    if input.Pass != nil {
+        owner := *before
+        if input.Username != nil {
+            owner.Username = *input.Username
+        }
+        if input.NmUser != nil {
+            owner.NmUser = *input.NmUser
+        }
+        if input.Email != nil {
+            owner.Email = *input.Email
+        }
+        if err := ctrl.checkPassword("pass", *input.Pass, &owner); err != nil {
+            return err
+        }
+        hashedPassword, err := ctrl.Hasher.Hash(*input.Pass)
+        if err != nil {
+            return apperror.Internal("Failed to hash password").Wrap(err)
+        }
+        for field, value := range ctrl.passwordUpdate(before, hashedPassword) {
+            updateData[field] = value
+        }
+    }
+    if len(updateData) == 0 {
+        return apperror.BadRequest(apperror.CodeBadRequest, "No fields to update")
+    }
+
+    // Tambahkan updated_at dan updated_by dari user yang sedang login
+    loc := config.Location()
+    updatedBy, _ := c.Locals("username").(string)
     updateData["updated_at"] = primitive.NewDateTimeFromTime(time.Now().In(loc))
+    updateData["updated_by"] = updatedBy
 
     // Update user berdasarkan ID
-    update := bson.M{"$set": updateData}
-    result, err := userCollection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+    err = ctrl.Users.Update(ctx, objectID, version, updateData)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        if err == repository.ErrVersionConflict {
+            return preconditionFailed()
+        }
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Wrap(err)
     }
+    ctrl.auditUserUpdate(c, ctx, objectID, before)
 
-    if result.MatchedCount == 0 {
-        return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+    // Sesi lama tidak berlaku lagi setelah password diganti admin
+    if input.Pass != nil {
+        if err := ctrl.Auth.RevokeUserSessions(ctx, objectID); err != nil {
+            return apperror.Internal("Failed to revoke user sessions").Wrap(err)
+        }
     }
 
     return c.Status(http.StatusOK).JSON(fiber.Map{"message": "User updated successfully"})
 }
 
+
+// EditRoleFromUser mengubah role user berdasarkan ID
+func (ctrl *Controller) EditRoleFromUser(c *fiber.Ctx) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    // Ambil ID dari URL parameter
+    id := c.Params("id")
+    objectID, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
+    }
+
+    // Parse input dari body request
+    var request struct {
+        RoleID string `json:"role_id"`
+    }
+    if err := c.BodyParser(&request); err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
+    }
+
+    // Validasi role_id dan pastikan role ada
+    roleID, err := primitive.ObjectIDFromHex(request.RoleID)
+    if err != nil {
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid role_id format")
+    }
+    role, err := ctrl.Roles.FindByID(ctx, roleID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.BadRequest(apperror.CodeBadRequest, "Role not found")
+        }
+        return apperror.Wrap(err)
+    }
+    // Memberi role sama dengan memberi seluruh permission-nya
+    if err := checkGrantable(c, role.Permissions, nil); err != nil {
+        return err
+    }
+
+    // Versi dari If-Match, update ditolak jika dokumen sudah diubah pihak lain
+    version, ok := ifMatchVersion(c)
+    if !ok {
+        return preconditionFailed()
+    }
+
+    user, err := ctrl.Users.FindByID(ctx, objectID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if !versionMatches(version, user.Version) {
+        return preconditionFailed()
+    }
+
+    loc := config.Location()
+    username, _ := c.Locals("username").(string)
+    updateFields := repository.Fields{
+        "role_id":    roleID,
+        "updated_at": primitive.NewDateTimeFromTime(time.Now().In(loc)),
+        "updated_by": username,
+    }
+
+    err = ctrl.Users.Update(ctx, objectID, version, updateFields)
+    if err != nil {
+        if err == repository.ErrVersionConflict {
+            return preconditionFailed()
+        }
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Internal("Failed to update user role")
+    }
+    ctrl.auditUserUpdate(c, ctx, objectID, user)
+
+    // role_id ikut tersimpan di token, sesi lama dicabut agar permission baru langsung berlaku
+    if err := ctrl.Auth.RevokeUserSessions(ctx, objectID); err != nil {
+        return apperror.Internal("Failed to revoke user sessions").Wrap(err)
+    }
+
+    return c.Status(fiber.StatusOK).JSON(fiber.Map{
+        "message": "User role updated successfully",
+        "user_id": objectID,
+        "role_id": roleID,
+    })
+}
+
+
 // EditJenisUserFromUser mengubah jenis user pada user berdasarkan ID
-func EditJenisUserFromUser(c *fiber.Ctx) error {
+func (ctrl *Controller) EditJenisUserFromUser(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -269,7 +453,7 @@ func EditJenisUserFromUser(c *fiber.Ctx) error {
     id := c.Params("id")
     objectID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
     }
 
     // Parse input dari body request
@@ -278,65 +462,97 @@ func EditJenisUserFromUser(c *fiber.Ctx) error {
     }
 
     if err := c.BodyParser(&request); err != nil {
-        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+        return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
     }
 
     // Validasi jenis_user_id
     jenisUserID, err := primitive.ObjectIDFromHex(request.JenisUserID)
     if err != nil {
-        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid jenis_user_id format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid jenis_user_id format")
     }
 
     // Ambil data jenis user berdasarkan jenis_user_id
-    var jenisUser models.JenisUser
-    err = jenisUserCollection.FindOne(ctx, bson.M{"_id": jenisUserID}).Decode(&jenisUser)
+    jenisUser, err := ctrl.JenisUsers.FindByID(ctx, jenisUserID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("Jenis user not found")
+        }
+        return apperror.Wrap(err)
+    }
+
+    // Versi dari If-Match, update ditolak jika dokumen sudah diubah pihak lain
+    version, ok := ifMatchVersion(c)
+    if !ok {
+        return preconditionFailed()
+    }
+
+    // Ambil user untuk mempertahankan modul yang diberikan manual
+    user, err := ctrl.Users.FindByID(ctx, objectID)
     if err != nil {
-        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Jenis user not found"})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if !versionMatches(version, user.Version) {
+        return preconditionFailed()
     }
 
     // Waktu sekarang
-    loc, _ := time.LoadLocation("Asia/Jakarta")
+    loc := config.Location()
     now := primitive.NewDateTimeFromTime(time.Now().In(loc))
+    username, _ := c.Locals("username").(string)
 
-    // Filter untuk user yang akan diperbarui
-    filter := bson.M{"_id": objectID}
-
-    // Mulai membuat update document
-    updateFields := bson.M{
-        "$set": bson.M{
-            "jenis_user_id": jenisUserID,  // Memperbarui jenis_user_id
-            "updated_at": now,             // Perbarui waktu
-            "user_modul": jenisUser.TemplateModul, // Set user_modul (kosong jika tidak ada)
-        },
+    // Modul template jenis user lama diganti dengan template jenis user baru
+    userModul, err := ctrl.templateUserModuls(ctx, jenisUser.TemplateModul, username, now)
+    if err != nil {
+        return apperror.Internal("Modul not found").Wrap(err)
+    }
+    // Modul data lama tanpa source diperlakukan sebagai pemberian manual
+    for _, modul := range user.UserModul {
+        if modul.Source != models.UserModulSourceTemplate && !hasUserModul(userModul, modul.ModulID) {
+            userModul = append(userModul, modul)
+        }
     }
 
-    // Jika TemplateModul kosong, set user_modul sebagai array kosong
-    if len(jenisUser.TemplateModul) == 0 {
-        updateFields["$set"].(bson.M)["user_modul"] = []interface{}{}
+    // Mulai membuat update document
+    updateFields := repository.Fields{
+        "jenis_user_id": jenisUserID,  // Memperbarui jenis_user_id
+        "updated_at": now,             // Perbarui waktu
+        "updated_by": username,
+        "user_modul": userModul,       // Template baru ditambah modul manual
     }
 
     // Lakukan update user
-    result, err := userCollection.UpdateOne(ctx, filter, updateFields)
+    err = ctrl.Users.Update(ctx, objectID, version, updateFields)
     if err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update user type"})
+        if err == repository.ErrVersionConflict {
+            return preconditionFailed()
+        }
+        // Jika tidak ada data yang diupdate
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Internal("Failed to update user type")
     }
+    ctrl.auditUserUpdate(c, ctx, objectID, user)
 
-    // Jika tidak ada data yang diupdate
-    if result.MatchedCount == 0 {
-        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+    // jenis_user_id ikut tersimpan di token, sesi lama dicabut agar token baru memuat jenis user baru
+    if err := ctrl.Auth.RevokeUserSessions(ctx, objectID); err != nil {
+        return apperror.Internal("Failed to revoke user sessions").Wrap(err)
     }
 
     return c.Status(fiber.StatusOK).JSON(fiber.Map{
         "message":   "User type updated successfully",
         "user_id":   objectID,
         "jenis_user_id": request.JenisUserID,
-        "user_modul": jenisUser.TemplateModul,  // Menyertakan modul yang baru
+        "user_modul": userModul,  // Menyertakan modul yang baru
     })
 }
 
 
 // DeleteUser menghapus user berdasarkan ID
-func DeleteUser(c *fiber.Ctx) error {
+func (ctrl *Controller) DeleteUser(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -344,17 +560,32 @@ func DeleteUser(c *fiber.Ctx) error {
     id := c.Params("id")
     objectID, err := primitive.ObjectIDFromHex(id)
     if err != nil {
-        return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ID format")
+    }
+
+    // Simpan kondisi sebelum dihapus untuk audit log
+    before, err := ctrl.Users.FindByID(ctx, objectID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Wrap(err)
     }
 
-    // Hapus user berdasarkan ID
-    result, err := userCollection.DeleteOne(ctx, bson.M{"_id": objectID})
+    // Pindahkan user ke trash, user tidak bisa login lagi sampai dipulihkan
+    username, _ := c.Locals("username").(string)
+    err = ctrl.Users.SoftDelete(ctx, objectID, username)
     if err != nil {
-        return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Wrap(err)
     }
+    ctrl.audit(c, ctx, models.AuditActionDelete, models.AuditEntityUser, objectID, before, nil, nil)
 
-    if result.DeletedCount == 0 {
-        return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+    // Cabut semua sesi milik user yang dihapus
+    if err := ctrl.Auth.RevokeUserSessions(ctx, objectID); err != nil {
+        return apperror.Internal("Failed to revoke user sessions").Wrap(err)
     }
 
     return c.Status(http.StatusOK).JSON(fiber.Map{"message": "User deleted successfully"})
@@ -362,7 +593,7 @@ func DeleteUser(c *fiber.Ctx) error {
 
 
 // Menambahkan Tambahan Modul Baru
-func AddUserModule(c *fiber.Ctx) error {
+func (ctrl *Controller) AddUserModule(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -373,46 +604,72 @@ func AddUserModule(c *fiber.Ctx) error {
     }
 
     if err := c.BodyParser(&request); err != nil {
-        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+        return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
     }
 
     // Validasi ObjectID
     if !primitive.IsValidObjectID(request.UserID.Hex()) || !primitive.IsValidObjectID(request.ModulID.Hex()) {
-        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ObjectID format"})
+        return apperror.BadRequest(apperror.CodeInvalidID, "Invalid ObjectID format")
     }
 
     // Ambil informasi modul dari koleksi Modul
-    var modul models.Modul
-    err := modulCollection.FindOne(ctx, bson.M{"_id": request.ModulID}).Decode(&modul)
+    modul, err := ctrl.Moduls.FindByID(ctx, request.ModulID)
     if err != nil {
-        if err == mongo.ErrNoDocuments {
-            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Modul not found"})
+        if err == repository.ErrNotFound {
+            return apperror.BadRequest(apperror.CodeBadRequest, "Modul not found")
         }
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Error fetching modul", "details": err.Error()})
+        return apperror.Internal("Error fetching modul").Wrap(err)
     }
 
     // Persiapkan data modul baru
-    loc, _ := time.LoadLocation("Asia/Jakarta")
+    loc := config.Location()
     now := primitive.NewDateTimeFromTime(time.Now().In(loc))
+    username, _ := c.Locals("username").(string)
 
+    // Modul manual tidak ikut dicabut saat template jenis user berubah
     newModule := models.UserModul{
         ModulID:    modul.ID,
         NamaModul:  modul.Name,
+        Source:     models.UserModulSourceManual,
         CreatedAt:  now,
+        CreatedBy:  username,
         UpdatedAt:  now,
+        UpdatedBy:  username,
     }
 
-    // Tambahkan modul ke array user_modul
-    update := bson.M{
-        "$addToSet": bson.M{"user_modul": newModule}, // Hindari duplikasi
+    // Jika modul sudah ada (misalnya dari template), tandai sebagai pemberian manual
+    user, err := ctrl.Users.FindByID(ctx, request.UserID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Wrap(err)
+    }
+    if hasUserModul(user.UserModul, modul.ID) {
+        err = ctrl.Users.UpdateModul(ctx, request.UserID, modul.ID, repository.Fields{
+            "source":     models.UserModulSourceManual,
+            "updated_at": now,
+            "updated_by": username,
+        })
+        if err != nil {
+            return apperror.Internal("Failed to add module").Wrap(err)
+        }
+        ctrl.auditUserUpdate(c, ctx, request.UserID, user)
+        return c.JSON(fiber.Map{
+            "message": "Module already assigned, marked as manual",
+            "modul":   newModule,
+        })
     }
 
-    // Lakukan update pada userCollection
-    _, err = userCollection.UpdateByID(ctx, request.UserID, update)
+    // Tambahkan modul ke array user_modul
+    err = ctrl.Users.AddModul(ctx, request.UserID, newModule)
     if err != nil {
-        fmt.Println("MongoDB Update Error:", err) // Debugging
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add module", "details": err.Error()})
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Internal("Failed to add module").Wrap(err)
     }
+    ctrl.auditUserUpdate(c, ctx, request.UserID, user)
 
     return c.JSON(fiber.Map{
         "message": "Module added successfully",
@@ -422,7 +679,7 @@ func AddUserModule(c *fiber.Ctx) error {
 
 
 // Menghapus Modul Tambahan
-func RemoveUserModule(c *fiber.Ctx) error {
+func (ctrl *Controller) RemoveUserModule(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -433,19 +690,27 @@ func RemoveUserModule(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
 	}
 
-	// Update query untuk menghapus modul dari array
-	update := bson.M{
-		"$pull": bson.M{"user_modul": bson.M{"modul_id": request.ModulID}}, // Hapus berdasarkan modul_id
+	// Simpan kondisi sebelum diubah untuk audit log
+	before, err := ctrl.Users.FindByID(ctx, request.UserID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("User not found")
+		}
+		return apperror.Internal("Failed to remove module")
 	}
 
-	// Lakukan update
-	_, err := userCollection.UpdateByID(ctx, request.UserID, update)
+	// Hapus modul dari array berdasarkan modul_id
+	err = ctrl.Users.RemoveModul(ctx, request.UserID, request.ModulID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to remove module"})
+		if err == repository.ErrNotFound {
+			return apperror.NotFound("User not found")
+		}
+		return apperror.Internal("Failed to remove module")
 	}
+	ctrl.auditUserUpdate(c, ctx, request.UserID, before)
 
 	return c.JSON(fiber.Map{
 		"message": "Module removed successfully",
@@ -455,7 +720,7 @@ func RemoveUserModule(c *fiber.Ctx) error {
 
 
 // Memperbarui Modul Tambahan
-func UpdateUserModule(c *fiber.Ctx) error {
+func (ctrl *Controller) UpdateUserModule(c *fiber.Ctx) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
@@ -468,32 +733,35 @@ func UpdateUserModule(c *fiber.Ctx) error {
     }
 
     if err := c.BodyParser(&request); err != nil {
-        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+        return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body")
     }
 
     // Waktu sekarang
-    loc, _ := time.LoadLocation("Asia/Jakarta")
+    loc := config.Location()
     now := primitive.NewDateTimeFromTime(time.Now().In(loc))
 
     // Update spesifik elemen dalam array UserModul
-    filter := bson.M{
-        "_id":             request.UserID,                      // Filter User ID
-        "user_modul.modul_id": request.ModulID,                 // Cari elemen dalam array
+    update := repository.Fields{
+        "updated_at": now,          // Update waktu terakhir diperbarui
+        "updated_by": request.UpdatedBy,
+        "update_ip":  request.UpdateIP,
     }
 
-    update := bson.M{
-        "$set": bson.M{
-            "user_modul.$.updated_at": now,          // Update waktu terakhir diperbarui
-            "user_modul.$.updated_by": request.UpdatedBy,
-            "user_modul.$.update_ip":  request.UpdateIP,
-        },
+    // Simpan kondisi sebelum diubah untuk audit log
+    before, err := ctrl.Users.FindByID(ctx, request.UserID)
+    if err != nil {
+        if err == repository.ErrNotFound {
+            return apperror.NotFound("User not found")
+        }
+        return apperror.Internal("Failed to update module")
     }
 
     // Lakukan update
-    _, err := userCollection.UpdateOne(ctx, filter, update)
+    err = ctrl.Users.UpdateModul(ctx, request.UserID, request.ModulID, update)
     if err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update module"})
+        return apperror.Internal("Failed to update module").Wrap(err)
     }
+    ctrl.auditUserUpdate(c, ctx, request.UserID, before)
 
     return c.JSON(fiber.Map{
         "message": "Module updated successfully",
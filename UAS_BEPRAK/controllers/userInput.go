@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"project-crud/repository"
+)
+
+// Field user yang hanya bisa diubah lewat endpoint khusus karena perlu pemeriksaan tambahan
+var userDedicatedFields = map[string]string{
+	"role_id":       "PUT /api/admin/update-roleuser/:id",
+	"jenis_user_id": "PUT /api/admin/update-jenisuser/:id",
+	"user_modul":    "/api/admin/add-moduluser-tertentu",
+}
+
+// createUserInput adalah body CreateUser. Password dikirim polos di field pass,
+// role_id dan jenis_user_id harus merujuk dokumen yang ada.
+type createUserInput struct {
+	Username     string `json:"username" validate:"required,max=50,unique=users.username"`
+	NmUser       string `json:"nm_user" validate:"required,max=100"`
+	Pass         string `json:"pass" validate:"required,max=72"`
+	Email        string `json:"email" validate:"required,email,unique=users.email"`
+	Phone        string `json:"phone" validate:"phone"`
+	Photo        string `json:"photo" validate:"max=500"`
+	JenisKelamin string `json:"jenis_kelamin" validate:"oneof=L P"`
+	RoleID       string `json:"role_id" validate:"required,objectid,exists=roles"`
+	JenisUserID  string `json:"jenis_user_id" validate:"required,objectid,exists=jenis_users"`
+}
+
+// editUserInput adalah field yang boleh diubah lewat EditUser. Field yang tidak dikirim bernilai nil
+// dan tidak diubah. Password dikirim polos lalu di-hash sebelum disimpan.
+type editUserInput struct {
+	Username     *string `json:"username" validate:"notblank,max=50,unique=users.username"`
+	NmUser       *string `json:"nm_user" validate:"notblank,max=100"`
+	Email        *string `json:"email" validate:"notblank,email,unique=users.email"`
+	Phone        *string `json:"phone" validate:"phone"`
+	Photo        *string `json:"photo" validate:"max=500"`
+	JenisKelamin *string `json:"jenis_kelamin" validate:"notblank,oneof=L P"`
+	Pass         *string `json:"pass" validate:"notblank,max=72"`
+}
+
+// parseEditUserInput membaca body JSON ke editUserInput. Field di luar daftar ditolak,
+// termasuk field yang punya endpoint khusus, sehingga body tidak pernah di-$set mentah.
+func parseEditUserInput(body []byte) (*editUserInput, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("Invalid request body")
+	}
+	for field := range fields {
+		if endpoint, ok := userDedicatedFields[field]; ok {
+			return nil, fmt.Errorf("Field %s must be changed through %s", field, endpoint)
+		}
+	}
+
+	var input editUserInput
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			return nil, fmt.Errorf("Field %s must be a string", typeErr.Field)
+		}
+		if field := strings.TrimPrefix(err.Error(), "json: unknown field "); field != err.Error() {
+			return nil, fmt.Errorf("Field %s cannot be updated", strings.Trim(field, `"`))
+		}
+		return nil, fmt.Errorf("Invalid request body")
+	}
+	return &input, nil
+}
+
+// trim merapikan spasi di awal/akhir field teks sebelum divalidasi dan disimpan
+func (in *editUserInput) trim() {
+	for _, value := range []*string{in.Username, in.NmUser, in.Email, in.Phone, in.JenisKelamin} {
+		if value != nil {
+			*value = strings.TrimSpace(*value)
+		}
+	}
+}
+
+// fields mengembalikan field profil yang dikirim untuk di-$set, tanpa password
+func (in *editUserInput) fields() repository.Fields {
+	fields := repository.Fields{}
+	set := func(name string, value *string) {
+		if value != nil {
+			fields[name] = *value
+		}
+	}
+	set("username", in.Username)
+	set("nm_user", in.NmUser)
+	set("email", in.Email)
+	set("phone", in.Phone)
+	set("photo", in.Photo)
+	set("jenis_kelamin", in.JenisKelamin)
+	return fields
+}
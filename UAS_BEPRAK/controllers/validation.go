@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/models"
+	"project-crud/repository"
+	"project-crud/validation"
+)
+
+type uniqueExceptKey struct{}
+
+// withUniqueExcept membuat rule unique mengabaikan dokumen dengan ID ini,
+// dipakai saat mengedit dokumen yang nilainya tidak berubah
+func withUniqueExcept(ctx context.Context, id primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, uniqueExceptKey{}, id)
+}
+
+// newValidator membuat validator request dengan rule tambahan yang membaca repository:
+//
+//	exists=<koleksi>         ObjectID harus merujuk dokumen yang ada (dan tidak di trash)
+//	unique=<koleksi>.<field> nilai belum dipakai dokumen lain
+//	permission               nilai adalah permission yang dikenal
+//	redirecturi              redirect_uri OAuth yang aman (lihat validRedirectURI)
+func (ctrl *Controller) newValidator() *validation.Validator {
+	v := validation.New()
+
+	finders := map[string]func(ctx context.Context, id primitive.ObjectID) error{
+		"roles":           findOnly(ctrl.Roles.FindByID),
+		"jenis_users":     findOnly(ctrl.JenisUsers.FindByID),
+		"kategori_moduls": findOnly(ctrl.KategoriModuls.FindByID),
+		"moduls":          findOnly(ctrl.Moduls.FindByID),
+	}
+	v.Register("exists", func(ctx context.Context, value reflect.Value, param string) (bool, error) {
+		find, ok := finders[param]
+		if !ok {
+			return false, fmt.Errorf("validation: exists does not support %q", param)
+		}
+		id, ok := validation.ObjectID(value)
+		if !ok {
+			return false, nil
+		}
+		err := find(ctx, id)
+		if err == repository.ErrNotFound {
+			return false, nil
+		}
+		return err == nil, err
+	}, "does not reference an existing document")
+
+	// Setiap lookup mengembalikan ID dokumen pemilik nilai tersebut
+	lookups := map[string]func(ctx context.Context, value string) (primitive.ObjectID, error){
+		"users.username": func(ctx context.Context, value string) (primitive.ObjectID, error) {
+			user, err := ctrl.Users.FindByUsername(ctx, value)
+			if err != nil {
+				return primitive.NilObjectID, err
+			}
+			return user.ID, nil
+		},
+		"users.email": func(ctx context.Context, value string) (primitive.ObjectID, error) {
+			user, err := ctrl.Users.FindByEmail(ctx, value)
+			if err != nil {
+				return primitive.NilObjectID, err
+			}
+			return user.ID, nil
+		},
+		"roles.name": func(ctx context.Context, value string) (primitive.ObjectID, error) {
+			role, err := ctrl.Roles.FindByName(ctx, value)
+			if err != nil {
+				return primitive.NilObjectID, err
+			}
+			return role.ID, nil
+		},
+	}
+	v.Register("unique", func(ctx context.Context, value reflect.Value, param string) (bool, error) {
+		lookup, ok := lookups[param]
+		if !ok {
+			return false, fmt.Errorf("validation: unique does not support %q", param)
+		}
+		ownerID, err := lookup(ctx, strings.TrimSpace(value.String()))
+		if err == repository.ErrNotFound {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		except, _ := ctx.Value(uniqueExceptKey{}).(primitive.ObjectID)
+		return ownerID == except, nil
+	}, "is already in use")
+
+	v.Register("permission", func(ctx context.Context, value reflect.Value, param string) (bool, error) {
+		return value.Kind() == reflect.String && models.IsValidPermission(value.String()), nil
+	}, "is not a known permission")
+
+	v.Register("redirecturi", func(ctx context.Context, value reflect.Value, param string) (bool, error) {
+		return value.Kind() == reflect.String && validRedirectURI(value.String()), nil
+	}, "must be an https URL without fragment (http is only allowed for localhost)")
+
+	return v
+}
+
+// validRedirectURI menerima URL https absolut tanpa fragment. http hanya untuk loopback
+// agar aplikasi modul bisa dikembangkan di mesin lokal (RFC 8252 bagian 7.3).
+func validRedirectURI(raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || parsed.Fragment != "" || parsed.User != nil {
+		return false
+	}
+	switch parsed.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := parsed.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+	return false
+}
+
+// findOnly membuang dokumen hasil FindByID, hanya error yang dipakai
+func findOnly[T any](find func(ctx context.Context, id primitive.ObjectID) (*T, error)) func(ctx context.Context, id primitive.ObjectID) error {
+	return func(ctx context.Context, id primitive.ObjectID) error {
+		_, err := find(ctx, id)
+		return err
+	}
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"project-crud/apperror"
+	"project-crud/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setVersionETag mengirim versi dokumen sebagai ETag agar bisa dikirim balik lewat If-Match
+func setVersionETag(c *fiber.Ctx, version int64) {
+	c.Set(fiber.HeaderETag, strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// ifMatchVersion membaca versi dari header If-Match. Tanpa header atau "*" berarti update
+// tidak bersyarat (repository.AnyVersion). ok bernilai false jika header tidak berisi tepat
+// satu ETag versi yang valid, misalnya ETag weak, sehingga tidak mungkin cocok.
+func ifMatchVersion(c *fiber.Ctx) (version int64, ok bool) {
+	raw := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if raw == "" || raw == "*" {
+		return repository.AnyVersion, true
+	}
+	if unquoted, err := strconv.Unquote(raw); err == nil {
+		raw = unquoted
+	}
+	version, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || version < 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// versionMatches mengecek versi dokumen saat ini terhadap versi dari If-Match
+func versionMatches(expected, current int64) bool {
+	return expected == repository.AnyVersion || expected == current
+}
+
+// preconditionFailed adalah error 412 jika versi pada If-Match sudah tidak sesuai
+func preconditionFailed() error {
+	return apperror.PreconditionFailed("Document has been modified, fetch the latest version and try again")
+}
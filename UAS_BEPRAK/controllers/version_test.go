@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/repository"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header      string
+		wantVersion int64
+		wantOK      bool
+	}{
+		{"", repository.AnyVersion, true},
+		{"*", repository.AnyVersion, true},
+		{`"3"`, 3, true},
+		{"3", 3, true},
+		{` "0" `, 0, true},
+		{`W/"3"`, 0, false},
+		{`"3", "4"`, 0, false},
+		{`"-1"`, 0, false},
+		{`"abc"`, 0, false},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			version, ok := ifMatchVersion(c)
+			if version != tt.wantVersion || ok != tt.wantOK {
+				t.Errorf("ifMatchVersion(%q) = (%d, %v), want (%d, %v)", tt.header, version, ok, tt.wantVersion, tt.wantOK)
+			}
+			return nil
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(fiber.HeaderIfMatch, tt.header)
+		if _, err := app.Test(req, -1); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// doRequestIfMatch mengirim body JSON dengan header If-Match (jika tidak kosong) dan
+// mengembalikan status serta ETag response
+func doRequestIfMatch(t *testing.T, app *fiber.App, method, path, ifMatch string, body interface{}) (int, string) {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if ifMatch != "" {
+		req.Header.Set(fiber.HeaderIfMatch, ifMatch)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, resp.Header.Get(fiber.HeaderETag)
+}
+
+func TestEditModulIfMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifMatch     string
+		wantStatus  int
+		wantChanged bool
+	}{
+		{"tanpa If-Match", "", fiber.StatusOK, true},
+		{"If-Match *", "*", fiber.StatusOK, true},
+		{"versi terbaru", "current", fiber.StatusOK, true},
+		{"versi lama", `"99"`, fiber.StatusPreconditionFailed, false},
+		{"ETag weak", "weak", fiber.StatusPreconditionFailed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl, store := newTestController(t)
+			id := seedModul(t, store, "Alpha")
+			app := newTestApp()
+			app.Get("/moduls/:id", ctrl.GetModulByID)
+			app.Put("/moduls/:id", ctrl.EditModul)
+
+			_, etag := doRequestIfMatch(t, app, "GET", "/moduls/"+id.Hex(), "", nil)
+			ifMatch := tt.ifMatch
+			switch ifMatch {
+			case "current":
+				ifMatch = etag
+			case "weak":
+				ifMatch = "W/" + etag
+			}
+
+			status, newETag := doRequestIfMatch(t, app, "PUT", "/moduls/"+id.Hex(), ifMatch, fiber.Map{"description": "baru"})
+			if status != tt.wantStatus {
+				t.Fatalf("EditModul() status = %d, want %d", status, tt.wantStatus)
+			}
+			modul, err := store.Moduls.FindByID(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed := modul.Description == "baru"; changed != tt.wantChanged {
+				t.Errorf("description changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if tt.wantChanged && (newETag == "" || newETag == etag) {
+				t.Errorf("ETag after update = %q, want a new version (was %q)", newETag, etag)
+			}
+		})
+	}
+}
+
+func TestUpdateVersionConflict(t *testing.T) {
+	// Dua admin membaca versi yang sama; update kedua harus ditolak walaupun lolos pemeriksaan awal
+	ctx := context.Background()
+	_, store := newTestController(t)
+	id := seedModul(t, store, "Alpha")
+	modul, err := store.Moduls.FindByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Moduls.Update(ctx, id, modul.Version, repository.Fields{"description": "admin A"}); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	if err := store.Moduls.Update(ctx, id, modul.Version, repository.Fields{"description": "admin B"}); err != repository.ErrVersionConflict {
+		t.Fatalf("second update: error = %v, want ErrVersionConflict", err)
+	}
+	if err := store.Moduls.Update(ctx, primitive.NewObjectID(), modul.Version, repository.Fields{"description": "x"}); err != repository.ErrNotFound {
+		t.Errorf("update missing modul: error = %v, want ErrNotFound", err)
+	}
+
+	updated, err := store.Moduls.FindByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Description != "admin A" || updated.Version != modul.Version+1 {
+		t.Errorf("modul = (%q, v%d), want (%q, v%d)", updated.Description, updated.Version, "admin A", modul.Version+1)
+	}
+}
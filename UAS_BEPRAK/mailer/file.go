@@ -0,0 +1,26 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// File menyimpan setiap email sebagai file .eml di Dir agar bisa dibuka dengan mail client
+// saat pengujian lokal
+type File struct {
+	Dir  string
+	From string
+}
+
+func (m File) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0o700); err != nil {
+		return err
+	}
+	now := time.Now()
+	body, messageID := format(m.From, msg, now)
+	name := now.Format("20060102-150405") + "-" + strings.Trim(strings.SplitN(messageID, "@", 2)[0], "<") + ".eml"
+	return os.WriteFile(filepath.Join(m.Dir, name), body, 0o600)
+}
@@ -0,0 +1,63 @@
+// Package mailer mengirim email aplikasi (misalnya tautan reset password) lewat SMTP,
+// atau menyimpannya ke file / log untuk pengembangan lokal.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message adalah satu email teks biasa
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer mengirim email. Implementasi harus aman dipakai dari beberapa goroutine.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Log menulis email ke log aplikasi, hanya untuk pengembangan lokal
+type Log struct {
+	From string
+}
+
+func (m Log) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail from %s to %s: %s\n%s", m.From, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// format menyusun email RFC 5322 dengan body UTF-8. CR dan LF dibuang dari nilai header
+// agar alamat atau subject dari input tidak bisa menyisipkan header baru.
+func format(from string, msg Message, now time.Time) ([]byte, string) {
+	clean := strings.NewReplacer("\r", "", "\n", "")
+	id := make([]byte, 12)
+	rand.Read(id)
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = clean.Replace(strings.Trim(from[at+1:], "> "))
+	}
+	messageID := fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", clean.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", clean.Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", clean.Replace(msg.Subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: %s\r\n", messageID)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes(), messageID
+}
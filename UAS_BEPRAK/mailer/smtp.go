@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTP mengirim email lewat server SMTP. STARTTLS dipakai otomatis jika server mendukungnya;
+// autentikasi PLAIN hanya dikirim lewat koneksi terenkripsi (aturan net/smtp).
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m SMTP) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	body, _ := format(m.From, msg, time.Now())
+
+	// From boleh berisi nama ("UNAIR SATU <no-reply@...>"), envelope hanya menerima alamatnya
+	sender, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return err
+	}
+
+	// net/smtp tidak menerima context, jalankan di goroutine agar pemanggil tidak tertahan
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, sender.Address, []string{msg.To}, body)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,17 +1,185 @@
 package main
 
 import (
+	"context"
 	"log"
+	"project-crud/apperror"
+	"project-crud/config"
+	"project-crud/controllers"
+	"project-crud/mailer"
+	"project-crud/middleware"
+	"project-crud/password"
+	"project-crud/repository"
 	"project-crud/routes"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 
 func main() {
-    app := fiber.New()
+    // Muat konfigurasi (environment variable / file YAML) sebelum koneksi database
+    cfg := config.Get()
+    log.Printf("Starting in %s profile", cfg.Env)
 
-    routes.RouterApp(app)
+    // Pilih penyimpanan data lalu rakit repository, middleware dan controller
+    var store *repository.Store
+    if cfg.Storage == config.StorageMemory {
+        log.Println("Using in-memory storage, data will be lost on restart")
+        store = repository.NewMemoryStore()
+    } else {
+        db := config.ConnectDB()
+        ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.Timeout)
+        if err := repository.EnsureIndexes(ctx, db); err != nil {
+            log.Fatal("Failed to create indexes:", err)
+        }
+        cancel()
+        store = repository.NewMongoStore(db)
+    }
 
-    log.Fatal(app.Listen(":3000"))
+    // Role admin selalu memiliki semua permission agar tidak terkunci dari API
+    if cfg.AdminRole != "" {
+        adminRole, _ := primitive.ObjectIDFromHex(cfg.AdminRole)
+        ctx, cancel := context.WithTimeout(context.Background(), cfg.Mongo.Timeout)
+        granted, err := repository.EnsureAdminRole(ctx, store.Roles, adminRole)
+        cancel()
+        switch {
+        case err == repository.ErrNotFound:
+            log.Println("Admin role", cfg.AdminRole, "not found, no role was granted all permissions")
+        case err != nil:
+            log.Fatal("Failed to bootstrap admin role:", err)
+        case granted:
+            log.Println("Granted all permissions to admin role", cfg.AdminRole)
+        }
+    }
+
+    // Hapus permanen data yang sudah melewati masa simpan di trash
+    repository.StartPurgeJob(store, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+
+    auth := middleware.NewAuth(store, newAuthConfig(cfg))
+
+    // Muat kunci penandatangan token dan jalankan rotasi berkala
+    if err := auth.LoadSigningKeys(); err != nil {
+        log.Fatal("Failed to load signing keys:", err)
+    }
+    auth.StartKeyRotation(time.Hour)
+
+    ctrl := controllers.NewController(store, auth)
+    ctrl.Mailer = newMailer(cfg.Mail)
+    ctrl.PasswordResetURL = cfg.PasswordReset.URL
+    policy, err := newPasswordPolicy(cfg.Password)
+    if err != nil {
+        log.Fatal("Failed to load breached password list:", err)
+    }
+    ctrl.PasswordPolicy = policy
+    ctrl.Hasher = newPasswordHasher(cfg.Password.Hash)
+    ctrl.OIDCConsentURL = cfg.OIDC.ConsentURL
+
+    // Semua error handler dan middleware ditulis sebagai problem+json oleh satu ErrorHandler,
+    // detail error internal disembunyikan di profil prod
+    app := fiber.New(fiber.Config{
+        ErrorHandler: apperror.Handler(cfg.Env == config.EnvProd),
+    })
+    app.Use(middleware.TraceID)
+
+    routes.RouterApp(app, ctrl, auth)
+
+    log.Fatal(app.Listen(":" + cfg.Port))
+}
+
+// newAuthConfig menyusun pengaturan token, proteksi login, reset password dan OpenID Connect
+func newAuthConfig(cfg *config.Config) middleware.Config {
+    return middleware.Config{
+        TokenIssuer:         cfg.JWT.Issuer,
+        TokenAlgorithm:      cfg.JWT.Algorithm,
+        KeyDir:              cfg.JWT.KeyDir,
+        KeyRotationInterval: cfg.JWT.KeyRotation,
+        AccessTokenTTL:      cfg.JWT.AccessTTL,
+        RefreshTokenTTL:     cfg.JWT.RefreshTTL,
+        MFATokenTTL:         cfg.JWT.MFATTL,
+        ClockSkew:           cfg.JWT.ClockSkew,
+        Login: middleware.LoginLimits{
+            MaxFailures:     cfg.Login.MaxFailures,
+            IPMaxFailures:   cfg.Login.IPMaxFailures,
+            DelayAfter:      cfg.Login.DelayAfter,
+            BaseDelay:       cfg.Login.BaseDelay,
+            MaxDelay:        cfg.Login.MaxDelay,
+            Window:          cfg.Login.Window,
+            LockoutDuration: cfg.Login.LockoutDuration,
+            RecordRetention: cfg.Login.RecordRetention,
+        },
+        PasswordReset: middleware.PasswordResetConfig{
+            TokenTTL: cfg.PasswordReset.TokenTTL,
+            Cooldown: cfg.PasswordReset.Cooldown,
+        },
+        OIDC: middleware.OIDCConfig{
+            Issuer:         cfg.OIDC.Issuer,
+            RequestTTL:     cfg.OIDC.RequestTTL,
+            CodeTTL:        cfg.OIDC.CodeTTL,
+            AccessTokenTTL: cfg.OIDC.AccessTTL,
+            IDTokenTTL:     cfg.OIDC.IDTokenTTL,
+        },
+    }
+}
+
+// newMailer memilih implementasi Mailer sesuai MAIL_DRIVER
+func newMailer(cfg config.MailConfig) mailer.Mailer {
+    switch cfg.Driver {
+    case config.MailSMTP:
+        return mailer.SMTP{
+            Host:     cfg.SMTP.Host,
+            Port:     cfg.SMTP.Port,
+            Username: cfg.SMTP.Username,
+            Password: cfg.SMTP.Password,
+            From:     cfg.From,
+        }
+    case config.MailFile:
+        log.Println("Writing outgoing mail to", cfg.Dir)
+        return mailer.File{Dir: cfg.Dir, From: cfg.From}
+    }
+    log.Println("Writing outgoing mail to the application log")
+    return mailer.Log{From: cfg.From}
+}
+
+// newPasswordPolicy menyusun policy password dari konfigurasi
+func newPasswordPolicy(cfg config.PasswordConfig) (*password.Policy, error) {
+    policy := &password.Policy{
+        MinLength:            cfg.MinLength,
+        RequireUpper:         cfg.RequireUpper,
+        RequireLower:         cfg.RequireLower,
+        RequireDigit:         cfg.RequireDigit,
+        RequireSymbol:        cfg.RequireSymbol,
+        DisallowPersonalInfo: cfg.DisallowPersonalInfo,
+        History:              cfg.History,
+    }
+    if !cfg.BreachedCheck {
+        return policy, nil
+    }
+    if cfg.BreachedFile == "" {
+        policy.Breached = password.BundledBreachedList()
+        return policy, nil
+    }
+    list, err := password.LoadBreachedList(cfg.BreachedFile)
+    if err != nil {
+        return nil, err
+    }
+    log.Printf("Loaded %d breached password hashes", list.Len())
+    policy.Breached = list
+    return policy, nil
+}
+
+// newPasswordHasher memilih algoritma hash untuk password baru. Algoritma lainnya tetap
+// dipakai untuk memverifikasi hash lama sampai diganti saat user login.
+func newPasswordHasher(cfg config.PasswordHashConfig) *password.Hasher {
+    bcrypt := password.Bcrypt{Cost: cfg.BcryptCost}
+    argon2id := password.DefaultArgon2id()
+    argon2id.Memory = uint32(cfg.Argon2Memory)
+    argon2id.Iterations = uint32(cfg.Argon2Iterations)
+    argon2id.Parallelism = uint8(cfg.Argon2Parallelism)
+
+    if cfg.Algorithm == config.HashBcrypt {
+        return password.NewHasher(bcrypt, argon2id)
+    }
+    return password.NewHasher(argon2id, bcrypt)
 }
\ No newline at end of file
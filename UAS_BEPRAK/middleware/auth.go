@@ -1,154 +1,160 @@
 package middleware
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/json"
+	"context"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
 )
 
-// Secret key untuk enkripsi
-var SecretKey = []byte("anisa")
+// Kode error 401 agar frontend bisa membedakan token kedaluwarsa dan token tidak valid
+const (
+	ErrCodeTokenMissing       = "token_missing"
+	ErrCodeTokenInvalid       = "token_invalid"
+	ErrCodeTokenExpired       = "token_expired"
+	ErrCodeTokenNotYetValid   = "token_not_yet_valid"
+	ErrCodeSessionRevoked     = "session_revoked"
+	ErrCodeRefreshTokenReused = "refresh_token_reused"
+)
 
-// Fungsi untuk membuat JWT
-func GenerateToken(username string, roleID, jenisUserID primitive.ObjectID) (string, error) {
-	// Header JWT
-	header := map[string]string{
-		"alg": "HS256", // Algoritma yang digunakan
-		"typ": "JWT",   // Tipe token
-	}
-	headerJSON, err := json.Marshal(header)
-	if err != nil {
-		return "", err
-	}
-	headerEncoded := base64.URLEncoding.EncodeToString(headerJSON)
+// Claims adalah isi access token
+type Claims struct {
+	Username    string `json:"username"`
+	RoleID      string `json:"role_id"`
+	JenisUserID string `json:"jenis_user_id"`
+	SessionID   string `json:"sid"`
+	Purpose     string `json:"purpose,omitempty"` // Diisi pada token khusus (misalnya mfa_pending), kosong untuk access token
+	jwt.RegisteredClaims
+}
 
-	// Payload JWT (Data yang ingin dikirimkan)
-	payload := map[string]interface{}{
-		"username":       username,
-		"role_id":        roleID.Hex(),
-		"jenis_user_id":  jenisUserID.Hex(),
-		"exp":            time.Now().Add(time.Hour * 1).Unix(), // Token berlaku selama 1 jam
-	}
-	payloadJSON, err := json.Marshal(payload)
+// Fungsi untuk membuat JWT yang terikat pada sesi login (claim "sid"),
+// ditandatangani dengan kunci asimetris aktif (header "kid")
+func (a *Auth) GenerateToken(username string, roleID, jenisUserID, sessionID primitive.ObjectID) (string, error) {
+	key, err := a.currentSigningKey()
 	if err != nil {
 		return "", err
 	}
-	payloadEncoded := base64.URLEncoding.EncodeToString(payloadJSON)
 
-	// Signature - menggunakan HMAC SHA256 dengan secret key
-	message := headerEncoded + "." + payloadEncoded
-	h := hmac.New(sha256.New, SecretKey)
-	h.Write([]byte(message))
-	signature := base64.URLEncoding.EncodeToString(h.Sum(nil))
+	now := time.Now()
+	claims := Claims{
+		Username:    username,
+		RoleID:      roleID.Hex(),
+		JenisUserID: jenisUserID.Hex(),
+		SessionID:   sessionID.Hex(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    a.Config.TokenIssuer,
+			Subject:   username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.Config.AccessTokenTTL)),
+		},
+	}
 
-	// Gabungkan semuanya menjadi token
-	token := headerEncoded + "." + payloadEncoded + "." + signature
-	return token, nil
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Alg), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
 }
 
-
 // Fungsi untuk memverifikasi JWT
-func JWTAuth(c *fiber.Ctx) error {
+func (a *Auth) JWTAuth(c *fiber.Ctx) error {
 	// Ambil token dari header Authorization
 	token := c.Get("Authorization")
 	if token == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "No token provided",
-		})
+		return tokenError(ErrCodeTokenMissing, "No token provided")
 	}
 
 	// Periksa apakah token menggunakan format Bearer
 	if !strings.HasPrefix(token, "Bearer ") {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid token format",
-		})
+		return tokenError(ErrCodeTokenInvalid, "Invalid token format")
 	}
 
 	// Ambil token yang sebenarnya dengan menghapus "Bearer "
 	token = strings.TrimPrefix(token, "Bearer ")
 
-	// Pisahkan token menjadi 3 bagian (header, payload, signature)
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid token",
-		})
+	// Verifikasi signature berdasarkan kid; waktu berlaku diperiksa terpisah dengan ClockSkew
+	var claims Claims
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+		jwt.WithoutClaimsValidation(),
+	)
+	if _, err := parser.ParseWithClaims(token, &claims, a.verificationKey); err != nil {
+		return tokenError(ErrCodeTokenInvalid, "Invalid token signature")
 	}
 
-	// Ambil header dan payload
-	headerEncoded := parts[0]
-	payloadEncoded := parts[1]
-	signatureReceived := parts[2]
-
-	// Verifikasi signature menggunakan HMAC SHA256
-	message := headerEncoded + "." + payloadEncoded
-	h := hmac.New(sha256.New, SecretKey)
-	h.Write([]byte(message))
-	signatureCalculated := base64.URLEncoding.EncodeToString(h.Sum(nil))
-
-	// Bandingkan signature yang dihitung dengan signature yang diterima
-	if signatureReceived != signatureCalculated {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid token signature",
-		})
+	// Periksa masa berlaku token (exp, nbf, iat) dan issuer
+	if code, msg := validateTimeClaims(claims.RegisteredClaims, time.Now(), a.Config.ClockSkew); code != "" {
+		return tokenError(code, msg)
 	}
-
-	// Decode payload untuk mengambil data pengguna
-	payloadJSON, err := base64.URLEncoding.DecodeString(payloadEncoded)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid token payload",
-		})
+	if claims.Issuer != a.Config.TokenIssuer {
+		return tokenError(ErrCodeTokenInvalid, "Invalid token issuer")
 	}
-
-	var payload map[string]interface{}
-	err = json.Unmarshal(payloadJSON, &payload)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid token payload format",
-		})
+	// Token mfa_pending ditandatangani dengan kunci yang sama tetapi bukan access token
+	if claims.Purpose != "" {
+		return tokenError(ErrCodeTokenInvalid, "Token cannot be used as an access token")
 	}
 
-	// Ambil role_id dan jenis_user_id dari payload
-	roleIDHex, ok := payload["role_id"].(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Missing role_id in token",
-		})
+	// Konversi ke primitive.ObjectID
+	roleID, err := primitive.ObjectIDFromHex(claims.RoleID)
+	if err != nil {
+		return tokenError(ErrCodeTokenInvalid, "Invalid role_id in token")
 	}
 
-	jenisUserIDHex, ok := payload["jenis_user_id"].(string)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Missing jenis_user_id in token",
-		})
+	jenisUserID, err := primitive.ObjectIDFromHex(claims.JenisUserID)
+	if err != nil {
+		return tokenError(ErrCodeTokenInvalid, "Invalid jenis_user_id in token")
 	}
 
-	// Konversi ke primitive.ObjectID
-	roleID, err := primitive.ObjectIDFromHex(roleIDHex)
+	// Pastikan sesi belum dicabut (logout) dan user masih ada
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid role_id in token",
-		})
+		return tokenError(ErrCodeTokenInvalid, "Invalid sid in token")
 	}
 
-	jenisUserID, err := primitive.ObjectIDFromHex(jenisUserIDHex)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, err := a.checkSession(ctx, sessionID)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Invalid jenis_user_id in token",
-		})
+		if err == ErrSessionRevoked || err == ErrUserNotFound {
+			return tokenError(ErrCodeSessionRevoked, err.Error())
+		}
+		return apperror.Internal("Failed to verify session").Wrap(err)
 	}
 
 	// Simpan role_id dan jenis_user_id ke context
 	c.Locals("role_id", roleID)
 	c.Locals("jenis_user_id", jenisUserID)
-	c.Locals("username", payload["username"])
+	c.Locals("username", claims.Username)
+	c.Locals("user_id", session.UserID)
+	c.Locals("session_id", session.ID)
 
 	return c.Next()
 }
+
+// validateTimeClaims memeriksa claim exp (wajib), nbf dan iat dengan toleransi skew.
+// Mengembalikan kode error kosong jika token masih berlaku.
+func validateTimeClaims(claims jwt.RegisteredClaims, now time.Time, skew time.Duration) (string, string) {
+	if claims.ExpiresAt == nil {
+		return ErrCodeTokenInvalid, "Missing exp in token"
+	}
+	if now.After(claims.ExpiresAt.Add(skew)) {
+		return ErrCodeTokenExpired, "Token has expired"
+	}
+	if claims.NotBefore != nil && now.Add(skew).Before(claims.NotBefore.Time) {
+		return ErrCodeTokenNotYetValid, "Token is not valid yet"
+	}
+	if claims.IssuedAt != nil && now.Add(skew).Before(claims.IssuedAt.Time) {
+		return ErrCodeTokenInvalid, "Token issued in the future"
+	}
+	return "", ""
+}
+
+// tokenError adalah error 401 beserta kode error yang bisa dibaca mesin
+func tokenError(code, message string) error {
+	return apperror.Unauthorized(code, message)
+}
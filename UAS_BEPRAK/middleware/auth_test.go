@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestValidateTimeClaims(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	skew := 30 * time.Second
+	at := func(offset time.Duration) *jwt.NumericDate { return jwt.NewNumericDate(now.Add(offset)) }
+
+	tests := []struct {
+		name     string
+		claims   jwt.RegisteredClaims
+		wantCode string
+	}{
+		{"berlaku", jwt.RegisteredClaims{ExpiresAt: at(time.Minute), NotBefore: at(-time.Minute), IssuedAt: at(-time.Minute)}, ""},
+		{"tanpa exp", jwt.RegisteredClaims{IssuedAt: at(-time.Minute)}, ErrCodeTokenInvalid},
+		{"exp lewat dalam skew", jwt.RegisteredClaims{ExpiresAt: at(-20 * time.Second)}, ""},
+		{"exp lewat melebihi skew", jwt.RegisteredClaims{ExpiresAt: at(-40 * time.Second)}, ErrCodeTokenExpired},
+		{"nbf akan datang dalam skew", jwt.RegisteredClaims{ExpiresAt: at(time.Hour), NotBefore: at(20 * time.Second)}, ""},
+		{"nbf akan datang melebihi skew", jwt.RegisteredClaims{ExpiresAt: at(time.Hour), NotBefore: at(40 * time.Second)}, ErrCodeTokenNotYetValid},
+		{"iat akan datang dalam skew", jwt.RegisteredClaims{ExpiresAt: at(time.Hour), IssuedAt: at(20 * time.Second)}, ""},
+		{"iat akan datang melebihi skew", jwt.RegisteredClaims{ExpiresAt: at(time.Hour), IssuedAt: at(40 * time.Second)}, ErrCodeTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _ := validateTimeClaims(tt.claims, now, skew); code != tt.wantCode {
+				t.Errorf("validateTimeClaims() code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestJWTAuthClaims(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClockSkew = 30 * time.Second
+	auth, store := newTestAuth(t, cfg)
+	user := seedUser(t, store, "budi")
+	tokens, err := auth.CreateSession(context.Background(), user, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var issued Claims
+	if _, _, err := jwt.NewParser().ParseUnverified(tokens.AccessToken, &issued); err != nil {
+		t.Fatal(err)
+	}
+
+	// Token dibuat ulang dari claims asli dengan kunci yang sama, hanya field tertentu diubah
+	now := time.Now()
+	at := func(offset time.Duration) *jwt.NumericDate { return jwt.NewNumericDate(now.Add(offset)) }
+	tests := []struct {
+		name       string
+		change     func(*Claims)
+		wantStatus int
+		wantCode   string
+	}{
+		{"token asli", func(*Claims) {}, fiber.StatusOK, ""},
+		{"kedaluwarsa dalam skew", func(c *Claims) { c.ExpiresAt = at(-10 * time.Second) }, fiber.StatusOK, ""},
+		{"kedaluwarsa", func(c *Claims) { c.ExpiresAt = at(-time.Minute) }, fiber.StatusUnauthorized, ErrCodeTokenExpired},
+		{"tanpa exp", func(c *Claims) { c.ExpiresAt = nil }, fiber.StatusUnauthorized, ErrCodeTokenInvalid},
+		{"belum berlaku", func(c *Claims) { c.NotBefore = at(time.Minute) }, fiber.StatusUnauthorized, ErrCodeTokenNotYetValid},
+		{"diterbitkan di masa depan", func(c *Claims) { c.IssuedAt = at(time.Minute) }, fiber.StatusUnauthorized, ErrCodeTokenInvalid},
+		{"issuer lain", func(c *Claims) { c.Issuer = "lain" }, fiber.StatusUnauthorized, ErrCodeTokenInvalid},
+		{"token mfa_pending", func(c *Claims) { c.Purpose = PurposeMFAPending }, fiber.StatusUnauthorized, ErrCodeTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := issued
+			tt.change(&claims)
+			token, err := auth.signToken(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status, code := authorize(t, auth, token); status != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("JWTAuth() = %d %q, want %d %q", status, code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+
+	// Token yang diubah setelah ditandatangani ditolak walaupun claims-nya berlaku
+	tampered := tokens.AccessToken[:len(tokens.AccessToken)-4] + "AAAA"
+	if status, code := authorize(t, auth, tampered); status != fiber.StatusUnauthorized || code != ErrCodeTokenInvalid {
+		t.Errorf("tampered token = %d %q, want 401 %q", status, code, ErrCodeTokenInvalid)
+	}
+	if status, code := authorize(t, auth, ""); status != fiber.StatusUnauthorized || code != ErrCodeTokenMissing {
+		t.Errorf("missing token = %d %q, want 401 %q", status, code, ErrCodeTokenMissing)
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"project-crud/apperror"
+	"project-crud/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kode error 403 jika role tidak memiliki permission yang dibutuhkan route
+const ErrCodeMissingPermission = "missing_permission"
+
+// Middleware untuk memeriksa apakah role user memiliki permission tertentu.
+// Permission dibaca dari dokumen role di database berdasarkan role_id pada token.
+func (a *Auth) CheckPermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Ambil role_id dari context yang disimpan setelah validasi JWT
+		roleID, ok := c.Locals("role_id").(primitive.ObjectID)
+		if !ok || roleID.IsZero() {
+			return apperror.Forbidden(apperror.CodeForbidden, "Access denied. Missing role.")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		role, err := a.Roles.FindByID(ctx, roleID)
+		if err != nil {
+			if err == repository.ErrNotFound {
+				return apperror.Forbidden(apperror.CodeForbidden, "Access denied. Role not found.")
+			}
+			return apperror.Internal("Failed to retrieve role").Wrap(err)
+		}
+
+		if !role.HasPermission(permission) {
+			return apperror.Forbidden(ErrCodeMissingPermission, "Access denied. Insufficient permission.").With("permission", permission)
+		}
+
+		c.Locals("permissions", role.Permissions)
+		return c.Next()
+	}
+}
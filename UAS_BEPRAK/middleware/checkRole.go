@@ -3,6 +3,8 @@ package middleware
 import (
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
 )
 
 // Middleware untuk memeriksa role user berdasarkan role_id
@@ -11,9 +13,7 @@ func CheckRole(requiredRoleID primitive.ObjectID) fiber.Handler {
 		// Ambil role_id dari context yang disimpan setelah validasi JWT
 		userRoleID, ok := c.Locals("role_id").(primitive.ObjectID)
 		if !ok || userRoleID != requiredRoleID {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Access denied. Insufficient role.",
-			})
+			return apperror.Forbidden(apperror.CodeForbidden, "Access denied. Insufficient role.")
 		}
 		return c.Next()
 	}
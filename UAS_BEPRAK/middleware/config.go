@@ -0,0 +1,84 @@
+package middleware
+
+import "time"
+
+// Config adalah pengaturan token, proteksi login, reset password dan OpenID Connect yang
+// dipakai Auth. Diisi dari konfigurasi aplikasi saat start, lihat DefaultConfig.
+type Config struct {
+	TokenIssuer         string        // Issuer (claim "iss") yang ditulis ke setiap token
+	TokenAlgorithm      string        // Algoritma untuk kunci baru
+	KeyDir              string        // Folder penyimpanan private key (PEM)
+	KeyRotationInterval time.Duration // Umur kunci sebelum diganti kunci baru
+	AccessTokenTTL      time.Duration
+	RefreshTokenTTL     time.Duration
+	MFATokenTTL         time.Duration // Masa berlaku token mfa_pending antara langkah password dan langkah kode
+	ClockSkew           time.Duration // Toleransi perbedaan jam antar server saat memeriksa exp, nbf dan iat
+
+	Login         LoginLimits
+	PasswordReset PasswordResetConfig
+	OIDC          OIDCConfig
+}
+
+// LoginLimits adalah batas proteksi brute-force login
+type LoginLimits struct {
+	MaxFailures     int
+	IPMaxFailures   int
+	DelayAfter      int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	Window          time.Duration
+	LockoutDuration time.Duration
+	RecordRetention time.Duration
+}
+
+// PasswordResetConfig adalah masa berlaku token reset password dan jeda minimal antar
+// permintaan untuk user yang sama
+type PasswordResetConfig struct {
+	TokenTTL time.Duration
+	Cooldown time.Duration
+}
+
+// OIDCConfig adalah konfigurasi provider OpenID Connect. Issuer adalah URL publik backend ini
+// dan ditulis sebagai iss pada id token dan access token untuk aplikasi modul.
+type OIDCConfig struct {
+	Issuer         string
+	RequestTTL     time.Duration
+	CodeTTL        time.Duration
+	AccessTokenTTL time.Duration
+	IDTokenTTL     time.Duration
+}
+
+// DefaultConfig mengembalikan pengaturan bawaan untuk pengembangan lokal
+func DefaultConfig() Config {
+	return Config{
+		TokenIssuer:         "unairsatu",
+		TokenAlgorithm:      AlgEdDSA,
+		KeyDir:              "storage/keys",
+		KeyRotationInterval: 24 * time.Hour,
+		AccessTokenTTL:      time.Hour,
+		RefreshTokenTTL:     7 * 24 * time.Hour,
+		MFATokenTTL:         5 * time.Minute,
+		ClockSkew:           30 * time.Second,
+		Login: LoginLimits{
+			MaxFailures:     5,
+			IPMaxFailures:   50,
+			DelayAfter:      3,
+			BaseDelay:       time.Second,
+			MaxDelay:        30 * time.Second,
+			Window:          15 * time.Minute,
+			LockoutDuration: 15 * time.Minute,
+			RecordRetention: 90 * 24 * time.Hour,
+		},
+		PasswordReset: PasswordResetConfig{
+			TokenTTL: 30 * time.Minute,
+			Cooldown: time.Minute,
+		},
+		OIDC: OIDCConfig{
+			Issuer:         "http://localhost:3000",
+			RequestTTL:     10 * time.Minute,
+			CodeTTL:        time.Minute,
+			AccessTokenTTL: time.Hour,
+			IDTokenTTL:     time.Hour,
+		},
+	}
+}
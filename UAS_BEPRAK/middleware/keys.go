@@ -0,0 +1,313 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Algoritma tanda tangan token yang didukung
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// signingKey adalah satu pasang kunci asimetris yang diidentifikasi dengan kid
+type signingKey struct {
+	ID        string
+	Alg       string
+	Private   crypto.Signer
+	CreatedAt time.Time
+	RetiredAt time.Time // Nol jika kunci masih dipakai untuk menandatangani
+}
+
+// keySet menyimpan kunci aktif dan kunci lama yang masih dipakai untuk verifikasi
+type keySet struct {
+	mu      sync.RWMutex
+	current *signingKey
+	keys    map[string]*signingKey
+}
+
+func newKeySet() *keySet {
+	return &keySet{keys: map[string]*signingKey{}}
+}
+
+// LoadSigningKeys memuat kunci dari Config.KeyDir dan membuat kunci baru jika belum ada
+// atau kunci terbaru sudah melewati Config.KeyRotationInterval.
+// Semua instance aplikasi harus memakai folder kunci yang sama.
+func (a *Auth) LoadSigningKeys() error {
+	if err := os.MkdirAll(a.Config.KeyDir, 0o700); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(a.Config.KeyDir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	var loaded []*signingKey
+	for _, file := range files {
+		key, err := readSigningKey(file)
+		if err != nil {
+			return fmt.Errorf("load signing key %s: %w", file, err)
+		}
+		loaded = append(loaded, key)
+	}
+
+	// Kunci terbaru dipakai untuk menandatangani, kunci lain hanya untuk verifikasi
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].CreatedAt.Before(loaded[j].CreatedAt) })
+	for i := 0; i < len(loaded)-1; i++ {
+		loaded[i].RetiredAt = loaded[i+1].CreatedAt
+	}
+
+	a.keys.mu.Lock()
+	a.keys.keys = map[string]*signingKey{}
+	for _, key := range loaded {
+		a.keys.keys[key.ID] = key
+	}
+	if len(loaded) > 0 {
+		a.keys.current = loaded[len(loaded)-1]
+	}
+	a.keys.mu.Unlock()
+
+	return a.RotateSigningKeys(false)
+}
+
+// RotateSigningKeys membuat kunci baru jika kunci aktif sudah kedaluwarsa (atau force
+// bernilai true) dan membuang kunci lama yang tidak mungkin lagi dipakai token aktif
+func (a *Auth) RotateSigningKeys(force bool) error {
+	a.keys.mu.Lock()
+	defer a.keys.mu.Unlock()
+
+	now := time.Now()
+	current := a.keys.current
+	if force || current == nil || current.Alg != a.Config.TokenAlgorithm || now.Sub(current.CreatedAt) >= a.Config.KeyRotationInterval {
+		key, err := newSigningKey(a.Config.TokenAlgorithm, now)
+		if err != nil {
+			return err
+		}
+		if err := writeSigningKey(a.Config.KeyDir, key); err != nil {
+			return err
+		}
+		if current != nil {
+			current.RetiredAt = now
+		}
+		a.keys.keys[key.ID] = key
+		a.keys.current = key
+		log.Printf("Signing key rotated, kid=%s alg=%s", key.ID, key.Alg)
+	}
+
+	// Kunci lama disimpan selama token terlama yang ditandatanganinya masih berlaku
+	retention := a.longestTokenTTL() + a.Config.ClockSkew
+	for id, key := range a.keys.keys {
+		if !key.RetiredAt.IsZero() && now.Sub(key.RetiredAt) > retention {
+			delete(a.keys.keys, id)
+			if err := os.Remove(filepath.Join(a.Config.KeyDir, id+".pem")); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Failed to remove signing key %s: %v", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// longestTokenTTL mengembalikan masa berlaku terpanjang dari semua token yang ditandatangani,
+// termasuk token OpenID Connect yang diverifikasi aplikasi modul lewat JWKS
+func (a *Auth) longestTokenTTL() time.Duration {
+	longest := a.Config.AccessTokenTTL
+	for _, ttl := range []time.Duration{a.Config.MFATokenTTL, a.Config.OIDC.RequestTTL, a.Config.OIDC.AccessTokenTTL, a.Config.OIDC.IDTokenTTL} {
+		if ttl > longest {
+			longest = ttl
+		}
+	}
+	return longest
+}
+
+// StartKeyRotation menjalankan rotasi kunci secara berkala di background
+func (a *Auth) StartKeyRotation(every time.Duration) {
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := a.RotateSigningKeys(false); err != nil {
+				log.Println("Signing key rotation failed:", err)
+			}
+		}
+	}()
+}
+
+// currentSigningKey mengembalikan kunci yang dipakai untuk menandatangani token baru
+func (a *Auth) currentSigningKey() (*signingKey, error) {
+	a.keys.mu.RLock()
+	defer a.keys.mu.RUnlock()
+	if a.keys.current == nil {
+		return nil, errors.New("signing keys are not loaded")
+	}
+	return a.keys.current, nil
+}
+
+// verificationKey adalah jwt.Keyfunc yang memilih public key berdasarkan header kid
+func (a *Auth) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	a.keys.mu.RLock()
+	key, ok := a.keys.keys[kid]
+	a.keys.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.Alg {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.Private.Public(), nil
+}
+
+// JWK adalah representasi public key dalam format JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS mengembalikan semua public key yang masih berlaku untuk verifikasi token
+func (a *Auth) JWKS() map[string][]JWK {
+	a.keys.mu.RLock()
+	defer a.keys.mu.RUnlock()
+
+	keys := make([]JWK, 0, len(a.keys.keys))
+	for _, key := range a.keys.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Alg}
+		switch pub := key.Private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		keys = append(keys, jwk)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+	return map[string][]JWK{"keys": keys}
+}
+
+// newSigningKey membuat pasangan kunci baru sesuai algoritma
+func newSigningKey(alg string, now time.Time) (*signingKey, error) {
+	var private crypto.Signer
+	switch alg {
+	case AlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		private = key
+	case AlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+
+	kid, err := keyID(private.Public(), now)
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{ID: kid, Alg: alg, Private: private, CreatedAt: now}, nil
+}
+
+// keyID membuat kid dari tanggal pembuatan dan thumbprint public key
+func keyID(public crypto.PublicKey, created time.Time) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return created.UTC().Format("20060102") + "-" + base64.RawURLEncoding.EncodeToString(sum[:9]), nil
+}
+
+// writeSigningKey menyimpan private key di folder dir dalam format PKCS#8 PEM
+func writeSigningKey(dir string, key *signingKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return err
+	}
+	block := &pem.Block{
+		Type: "PRIVATE KEY",
+		Headers: map[string]string{
+			"Kid":     key.ID,
+			"Alg":     key.Alg,
+			"Created": key.CreatedAt.UTC().Format(time.RFC3339),
+		},
+		Bytes: der,
+	}
+	return os.WriteFile(filepath.Join(dir, key.ID+".pem"), pem.EncodeToMemory(block), 0o600)
+}
+
+// readSigningKey membaca private key yang disimpan oleh writeSigningKey
+func readSigningKey(file string) (*signingKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("invalid PEM block")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+	created, err := time.Parse(time.RFC3339, block.Headers["Created"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid Created header: %w", err)
+	}
+
+	key := &signingKey{
+		ID:        block.Headers["Kid"],
+		Alg:       block.Headers["Alg"],
+		Private:   private,
+		CreatedAt: created,
+	}
+	if key.ID == "" {
+		key.ID = strings.TrimSuffix(filepath.Base(file), ".pem")
+	}
+	switch private.(type) {
+	case *rsa.PrivateKey:
+		key.Alg = AlgRS256
+	case ed25519.PrivateKey:
+		key.Alg = AlgEdDSA
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+	return key, nil
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+
+	"project-crud/repository"
+)
+
+// publicKeyFromJWK membangun public key dari JWK seperti yang dilakukan aplikasi modul
+func publicKeyFromJWK(t *testing.T, jwk JWK) crypto.PublicKey {
+	t.Helper()
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("decode %q: %v", s, err)
+		}
+		return b
+	}
+	switch jwk.Kty {
+	case "RSA":
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(decode(jwk.N)), E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64())}
+	case "OKP":
+		return ed25519.PublicKey(decode(jwk.X))
+	}
+	t.Fatalf("unexpected kty %q", jwk.Kty)
+	return nil
+}
+
+// findJWK mencari JWK dengan kid tertentu di JWKS
+func findJWK(auth *Auth, kid string) (JWK, bool) {
+	for _, jwk := range auth.JWKS()["keys"] {
+		if jwk.Kid == kid {
+			return jwk, true
+		}
+	}
+	return JWK{}, false
+}
+
+func TestSigningAlgorithms(t *testing.T) {
+	tests := []struct {
+		alg     string
+		wantKty string
+	}{
+		{AlgRS256, "RSA"},
+		{AlgEdDSA, "OKP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.TokenAlgorithm = tt.alg
+			auth, store := newTestAuth(t, cfg)
+			tokens, err := auth.CreateSession(context.Background(), seedUser(t, store, "budi"), "test", "127.0.0.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Aplikasi modul cukup memakai JWKS untuk memverifikasi token
+			var claims Claims
+			token, err := jwt.ParseWithClaims(tokens.AccessToken, &claims, func(token *jwt.Token) (interface{}, error) {
+				kid, _ := token.Header["kid"].(string)
+				jwk, ok := findJWK(auth, kid)
+				if !ok {
+					t.Fatalf("kid %q not published in JWKS", kid)
+				}
+				if jwk.Kty != tt.wantKty || jwk.Alg != tt.alg || jwk.Use != "sig" {
+					t.Errorf("JWK = %+v, want kty %s alg %s use sig", jwk, tt.wantKty, tt.alg)
+				}
+				return publicKeyFromJWK(t, jwk), nil
+			}, jwt.WithValidMethods([]string{tt.alg}))
+			if err != nil || !token.Valid {
+				t.Fatalf("verify with JWKS: %v", err)
+			}
+			if claims.Username != "budi" || claims.Issuer != cfg.TokenIssuer {
+				t.Errorf("claims = %+v, want username budi and issuer %s", claims, cfg.TokenIssuer)
+			}
+		})
+	}
+}
+
+func TestRotateSigningKeys(t *testing.T) {
+	ctx := context.Background()
+	cfg := DefaultConfig()
+	cfg.KeyDir = t.TempDir()
+	store := repository.NewMemoryStore()
+	auth := NewAuth(store, cfg)
+	if err := auth.LoadSigningKeys(); err != nil {
+		t.Fatal(err)
+	}
+	user := seedUser(t, store, "budi")
+	before, err := auth.CreateSession(ctx, user, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := auth.RotateSigningKeys(true); err != nil {
+		t.Fatal(err)
+	}
+	after, err := auth.CreateSession(ctx, user, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid(t, before.AccessToken) == kid(t, after.AccessToken) {
+		t.Fatal("token after rotation uses the old kid")
+	}
+	if n := len(auth.JWKS()["keys"]); n != 2 {
+		t.Errorf("JWKS has %d keys after rotation, want 2", n)
+	}
+
+	// Instance lain dengan folder kunci yang sama dan algoritma baru memuat kunci lama
+	// untuk verifikasi dan menandatangani token baru dengan kunci RS256
+	cfg.TokenAlgorithm = AlgRS256
+	other := NewAuth(store, cfg)
+	if err := other.LoadSigningKeys(); err != nil {
+		t.Fatal(err)
+	}
+	for name, token := range map[string]string{"kunci pertama": before.AccessToken, "kunci kedua": after.AccessToken} {
+		if status, code := authorize(t, other, token); status != fiber.StatusOK {
+			t.Errorf("%s on other instance = %d %s, want 200", name, status, code)
+		}
+	}
+	current, err := other.currentSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Alg != AlgRS256 {
+		t.Errorf("current key alg = %s, want %s", current.Alg, AlgRS256)
+	}
+}
+
+func TestJWTAuthRejectsOtherAlgorithms(t *testing.T) {
+	auth, store := newTestAuth(t, DefaultConfig())
+	tokens, err := auth.CreateSession(context.Background(), seedUser(t, store, "budi"), "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims Claims
+	if _, _, err := jwt.NewParser().ParseUnverified(tokens.AccessToken, &claims); err != nil {
+		t.Fatal(err)
+	}
+	key, err := auth.currentSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// HS256 dengan public key sebagai secret (algorithm confusion) dan token tanpa tanda tangan
+	hs256 := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hs256.Header["kid"] = key.ID
+	public, _ := key.Private.Public().(ed25519.PublicKey)
+	hsToken, err := hs256.SignedString([]byte(public))
+	if err != nil {
+		t.Fatal(err)
+	}
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	none.Header["kid"] = key.ID
+	noneToken, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknownKid := jwt.NewWithClaims(jwt.GetSigningMethod(key.Alg), claims)
+	unknownKid.Header["kid"] = "tidak-dikenal"
+	unknownToken, err := unknownKid.SignedString(key.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"HS256": hsToken, "none": noneToken, "kid tidak dikenal": unknownToken} {
+		if status, code := authorize(t, auth, token); status != fiber.StatusUnauthorized || code != ErrCodeTokenInvalid {
+			t.Errorf("%s = %d %q, want 401 %q", name, status, code, ErrCodeTokenInvalid)
+		}
+	}
+}
+
+// kid membaca header kid tanpa memverifikasi token
+func kid(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := parsed.Header["kid"].(string)
+	return id
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// Kode error 429 saat login ditolak sebelum password diperiksa
+const (
+	ErrCodeAccountLocked   = "account_locked"
+	ErrCodeTooManyAttempts = "too_many_attempts"
+)
+
+// LoginBlock menjelaskan alasan login ditolak dan kapan boleh dicoba lagi
+type LoginBlock struct {
+	Code       string
+	RetryAfter time.Duration
+}
+
+// Kunci penghitung di LoginThrottleRepository. Username dibuat huruf kecil agar
+// variasi huruf besar tidak menghasilkan penghitung baru.
+func userThrottleKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginBlocked memeriksa apakah username atau IP sedang dikunci atau masih dalam jeda progresif.
+// Jika keduanya diblokir, yang dikembalikan adalah blokir dengan waktu tunggu terlama.
+func (a *Auth) LoginBlocked(ctx context.Context, username, ip string) (*LoginBlock, error) {
+	now := time.Now()
+	var block *LoginBlock
+	check := func(key, lockedCode string) error {
+		throttle, err := a.LoginThrottles.Find(ctx, key)
+		if err == repository.ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		candidates := []struct {
+			until *primitive.DateTime
+			code  string
+		}{
+			{throttle.LockedUntil, lockedCode},
+			{throttle.NextAttemptAt, ErrCodeTooManyAttempts},
+		}
+		for _, candidate := range candidates {
+			if candidate.until == nil {
+				continue
+			}
+			wait := candidate.until.Time().Sub(now)
+			if wait > 0 && (block == nil || wait > block.RetryAfter) {
+				block = &LoginBlock{Code: candidate.code, RetryAfter: wait}
+			}
+		}
+		return nil
+	}
+
+	if err := check(userThrottleKey(username), ErrCodeAccountLocked); err != nil {
+		return nil, err
+	}
+	if err := check(ipThrottleKey(ip), ErrCodeTooManyAttempts); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// LoginFailed menghitung satu login gagal untuk username dan IP. Setelah Login.DelayAfter kali
+// gagal, username harus menunggu jeda yang berlipat dua setiap kegagalan (paling lama
+// Login.MaxDelay); setelah Login.MaxFailures kali username dikunci selama Login.LockoutDuration.
+// IP hanya dikunci setelah Login.IPMaxFailures kali gagal untuk username mana pun.
+func (a *Auth) LoginFailed(ctx context.Context, username, ip string) error {
+	now := time.Now()
+	limits := a.Config.Login
+
+	throttle, err := a.LoginThrottles.RecordFailure(ctx, userThrottleKey(username), now, limits.Window)
+	if err != nil {
+		return err
+	}
+	if throttle.Failures >= limits.MaxFailures {
+		lockedUntil := now.Add(limits.LockoutDuration)
+		err = a.LoginThrottles.Block(ctx, throttle.Key, nil, &lockedUntil)
+	} else if throttle.Failures >= limits.DelayAfter {
+		nextAttemptAt := now.Add(limits.progressiveDelay(throttle.Failures - limits.DelayAfter))
+		err = a.LoginThrottles.Block(ctx, throttle.Key, &nextAttemptAt, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	throttle, err = a.LoginThrottles.RecordFailure(ctx, ipThrottleKey(ip), now, limits.Window)
+	if err != nil {
+		return err
+	}
+	if throttle.Failures >= limits.IPMaxFailures {
+		lockedUntil := now.Add(limits.LockoutDuration)
+		return a.LoginThrottles.Block(ctx, throttle.Key, nil, &lockedUntil)
+	}
+	return nil
+}
+
+// progressiveDelay mengembalikan BaseDelay * 2^step, dibatasi MaxDelay
+func (l LoginLimits) progressiveDelay(step int) time.Duration {
+	delay := l.BaseDelay
+	for i := 0; i < step && delay < l.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > l.MaxDelay {
+		return l.MaxDelay
+	}
+	return delay
+}
+
+// LoginSucceeded mereset penghitung username. Penghitung IP dibiarkan agar satu akun
+// yang valid tidak bisa dipakai untuk menghapus jejak tebakan ke akun lain.
+func (a *Auth) LoginSucceeded(ctx context.Context, username string) error {
+	return a.LoginThrottles.Delete(ctx, userThrottleKey(username))
+}
+
+// UnlockLogin menghapus kunci dan penghitung login gagal milik username
+func (a *Auth) UnlockLogin(ctx context.Context, username string) error {
+	return a.LoginThrottles.Delete(ctx, userThrottleKey(username))
+}
+
+// RecordLogin menyimpan catatan percobaan login yang dihapus otomatis setelah Login.RecordRetention
+func (a *Auth) RecordLogin(ctx context.Context, record models.LoginRecord) error {
+	now := time.Now()
+	record.ID = primitive.NewObjectID()
+	record.Success = record.Result == models.LoginResultSuccess
+	record.CreatedAt = primitive.NewDateTimeFromTime(now)
+	record.ExpiresAt = primitive.NewDateTimeFromTime(now.Add(a.Config.Login.RecordRetention))
+	return a.LoginRecords.Record(ctx, &record)
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProgressiveDelay(t *testing.T) {
+	limits := LoginLimits{BaseDelay: time.Second, MaxDelay: 8 * time.Second}
+	tests := []struct {
+		step int
+		want time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{4, 8 * time.Second},
+		{40, 8 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := limits.progressiveDelay(tt.step); got != tt.want {
+			t.Errorf("progressiveDelay(%d) = %v, want %v", tt.step, got, tt.want)
+		}
+	}
+}
+
+func TestLoginGuard(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Login = LoginLimits{
+		MaxFailures:     4,
+		IPMaxFailures:   6,
+		DelayAfter:      2,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		Window:          time.Hour,
+		LockoutDuration: 15 * time.Minute,
+		RecordRetention: time.Hour,
+	}
+
+	// Setiap langkah menjalankan aksi lalu memeriksa LoginBlocked untuk username dan IP
+	type step struct {
+		action    string // "fail" atau "success"
+		username  string
+		ip        string
+		wantCode  string
+		wantAtMin time.Duration // RetryAfter minimal jika diblokir
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "jeda progresif lalu akun dikunci",
+			steps: []step{
+				{"fail", "budi", "10.0.0.1", "", 0},
+				{"fail", "budi", "10.0.0.1", ErrCodeTooManyAttempts, 0},
+				{"fail", "budi", "10.0.0.1", ErrCodeTooManyAttempts, time.Second},
+				{"fail", "budi", "10.0.0.1", ErrCodeAccountLocked, 14 * time.Minute},
+				// Akun tetap terkunci dari IP lain dan tanpa memandang huruf besar
+				{"check", "BUDI", "10.0.0.2", ErrCodeAccountLocked, 14 * time.Minute},
+				{"check", "cici", "10.0.0.2", "", 0},
+			},
+		},
+		{
+			name: "login berhasil mereset penghitung username",
+			steps: []step{
+				{"fail", "budi", "10.0.0.1", "", 0},
+				{"fail", "budi", "10.0.0.1", ErrCodeTooManyAttempts, 0},
+				{"success", "budi", "10.0.0.1", "", 0},
+				{"fail", "budi", "10.0.0.1", "", 0},
+			},
+		},
+		{
+			name: "IP diblokir setelah gagal untuk banyak username",
+			steps: []step{
+				{"fail", "u1", "10.0.0.1", "", 0},
+				{"fail", "u2", "10.0.0.1", "", 0},
+				{"fail", "u3", "10.0.0.1", "", 0},
+				{"fail", "u4", "10.0.0.1", "", 0},
+				{"fail", "u5", "10.0.0.1", "", 0},
+				{"fail", "u6", "10.0.0.1", ErrCodeTooManyAttempts, 14 * time.Minute},
+				{"check", "budi", "10.0.0.1", ErrCodeTooManyAttempts, 14 * time.Minute},
+				// Login berhasil tidak menghapus penghitung IP
+				{"success", "u6", "10.0.0.1", ErrCodeTooManyAttempts, 14 * time.Minute},
+				{"check", "budi", "10.0.0.2", "", 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			auth, _ := newTestAuth(t, cfg)
+			for i, s := range tt.steps {
+				var err error
+				switch s.action {
+				case "fail":
+					err = auth.LoginFailed(ctx, s.username, s.ip)
+				case "success":
+					err = auth.LoginSucceeded(ctx, s.username)
+				}
+				if err != nil {
+					t.Fatalf("step %d: %s: %v", i, s.action, err)
+				}
+
+				block, err := auth.LoginBlocked(ctx, s.username, s.ip)
+				if err != nil {
+					t.Fatalf("step %d: LoginBlocked: %v", i, err)
+				}
+				code := ""
+				if block != nil {
+					code = block.Code
+				}
+				if code != s.wantCode {
+					t.Fatalf("step %d: %s %s@%s: block = %q, want %q", i, s.action, s.username, s.ip, code, s.wantCode)
+				}
+				if block != nil && block.RetryAfter < s.wantAtMin {
+					t.Errorf("step %d: RetryAfter = %v, want at least %v", i, block.RetryAfter, s.wantAtMin)
+				}
+			}
+		})
+	}
+}
+
+func TestUnlockLogin(t *testing.T) {
+	ctx := context.Background()
+	cfg := DefaultConfig()
+	cfg.Login.MaxFailures = 1
+	auth, _ := newTestAuth(t, cfg)
+
+	if err := auth.LoginFailed(ctx, "budi", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if block, _ := auth.LoginBlocked(ctx, "budi", "10.0.0.2"); block == nil || block.Code != ErrCodeAccountLocked {
+		t.Fatalf("LoginBlocked() = %+v, want %s", block, ErrCodeAccountLocked)
+	}
+	if err := auth.UnlockLogin(ctx, "Budi"); err != nil {
+		t.Fatal(err)
+	}
+	if block, _ := auth.LoginBlocked(ctx, "budi", "10.0.0.2"); block != nil {
+		t.Errorf("LoginBlocked() after unlock = %+v, want nil", block)
+	}
+}
@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/models"
+	"project-crud/repository"
+	"project-crud/totp"
+)
+
+// Jumlah recovery code yang diterbitkan setiap kali MFA diaktifkan atau kode dibuat ulang
+const RecoveryCodeCount = 10
+
+// Toleransi satu periode (30 detik) sebelum dan sesudah waktu server
+const totpSkew = 1
+
+// Purpose token yang diterbitkan Login saat password benar tetapi kode kedua masih dibutuhkan
+const PurposeMFAPending = "mfa_pending"
+
+// Kode error autentikasi dua faktor
+const (
+	ErrCodeMFAInvalidCode = "mfa_invalid_code"
+	ErrCodeMFANotPending  = "mfa_not_pending"
+)
+
+var (
+	ErrInvalidMFACode     = errors.New("invalid two-factor code")
+	ErrMFANotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrMFANoPendingSecret = errors.New("no pending two-factor enrollment")
+)
+
+// MFAClaims adalah isi token mfa_pending. Enroll bernilai true jika role user mewajibkan
+// MFA tetapi user belum mendaftarkan authenticator, sehingga token hanya boleh dipakai
+// untuk pendaftaran sebelum login diselesaikan.
+type MFAClaims struct {
+	UserID  string `json:"uid"`
+	Purpose string `json:"purpose"`
+	Enroll  bool   `json:"enroll,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateMFAToken membuat token mfa_pending berumur pendek untuk user yang passwordnya sudah benar
+func (a *Auth) GenerateMFAToken(user models.User, enroll bool) (string, error) {
+	key, err := a.currentSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := MFAClaims{
+		UserID:  user.ID.Hex(),
+		Purpose: PurposeMFAPending,
+		Enroll:  enroll,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    a.Config.TokenIssuer,
+			Subject:   user.Username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.Config.MFATokenTTL)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Alg), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// ParseMFAToken memverifikasi token mfa_pending dan mengembalikan ID user di dalamnya
+func (a *Auth) ParseMFAToken(token string) (*MFAClaims, primitive.ObjectID, error) {
+	var claims MFAClaims
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+		jwt.WithoutClaimsValidation(),
+	)
+	if _, err := parser.ParseWithClaims(token, &claims, a.verificationKey); err != nil {
+		return nil, primitive.NilObjectID, tokenError(ErrCodeTokenInvalid, "Invalid mfa token")
+	}
+	if code, msg := validateTimeClaims(claims.RegisteredClaims, time.Now(), a.Config.ClockSkew); code != "" {
+		return nil, primitive.NilObjectID, tokenError(code, msg)
+	}
+	if claims.Issuer != a.Config.TokenIssuer || claims.Purpose != PurposeMFAPending {
+		return nil, primitive.NilObjectID, tokenError(ErrCodeTokenInvalid, "Invalid mfa token")
+	}
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return nil, primitive.NilObjectID, tokenError(ErrCodeTokenInvalid, "Invalid uid in mfa token")
+	}
+	return &claims, userID, nil
+}
+
+// MFARequired memeriksa apakah role user mewajibkan autentikasi dua faktor
+func (a *Auth) MFARequired(ctx context.Context, user models.User) (bool, error) {
+	role, err := a.Roles.FindByID(ctx, user.RoleID)
+	if err == repository.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return role.RequireMFA, nil
+}
+
+// StartMFAEnrollment membuat secret baru yang baru aktif setelah dikonfirmasi dengan
+// ConfirmMFAEnrollment. Mengembalikan secret dan URI otpauth untuk QR code.
+func (a *Auth) StartMFAEnrollment(ctx context.Context, user models.User) (string, string, error) {
+	if user.MFAEnabled() {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	mfa := models.UserMFA{PendingSecret: secret}
+	if err := a.Users.Update(ctx, user.ID, user.Version, repository.Fields{"mfa": mfa}); err != nil {
+		return "", "", err
+	}
+	return secret, totp.ProvisioningURI(a.Config.TokenIssuer, user.Username, secret), nil
+}
+
+// ConfirmMFAEnrollment mengaktifkan secret yang sedang didaftarkan jika code cocok
+// dan mengembalikan recovery code dalam bentuk asli (hanya ditampilkan sekali)
+func (a *Auth) ConfirmMFAEnrollment(ctx context.Context, user models.User, code string) ([]string, error) {
+	if user.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFA == nil || user.MFA.PendingSecret == "" {
+		return nil, ErrMFANoPendingSecret
+	}
+	now := time.Now()
+	step, ok := totp.Verify(user.MFA.PendingSecret, code, now, totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	enabledAt := primitive.NewDateTimeFromTime(now)
+	mfa := models.UserMFA{
+		Enabled:       true,
+		EnabledAt:     &enabledAt,
+		Secret:        user.MFA.PendingSecret,
+		RecoveryCodes: hashes,
+		LastUsedStep:  step,
+	}
+	if err := a.Users.Update(ctx, user.ID, user.Version, repository.Fields{"mfa": mfa}); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyMFA memeriksa kode TOTP atau recovery code milik user. Kode TOTP yang sudah pernah
+// diterima dan recovery code yang sudah dipakai ditolak. Update memakai versi dokumen
+// sehingga dua request bersamaan dengan kode yang sama tidak bisa sama-sama berhasil.
+func (a *Auth) VerifyMFA(ctx context.Context, user models.User, code, recoveryCode string) error {
+	mfa, err := checkMFA(user, code, recoveryCode)
+	if err != nil {
+		return err
+	}
+	return a.saveMFA(ctx, user, mfa)
+}
+
+// RegenerateRecoveryCodes mengganti semua recovery code setelah kode TOTP diverifikasi
+func (a *Auth) RegenerateRecoveryCodes(ctx context.Context, user models.User, code string) ([]string, error) {
+	mfa, err := checkMFA(user, code, "")
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	mfa.RecoveryCodes = hashes
+	if err := a.saveMFA(ctx, user, mfa); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkMFA mencocokkan kode dan mengembalikan pengaturan MFA setelah kode tersebut dipakai
+func checkMFA(user models.User, code, recoveryCode string) (models.UserMFA, error) {
+	if !user.MFAEnabled() {
+		return models.UserMFA{}, ErrMFANotEnabled
+	}
+	mfa := *user.MFA
+
+	switch {
+	case code != "":
+		step, ok := totp.Verify(mfa.Secret, code, time.Now(), totpSkew)
+		if !ok || step <= mfa.LastUsedStep {
+			return mfa, ErrInvalidMFACode
+		}
+		mfa.LastUsedStep = step
+	case recoveryCode != "":
+		index := matchRecoveryCode(mfa.RecoveryCodes, recoveryCode)
+		if index < 0 {
+			return mfa, ErrInvalidMFACode
+		}
+		remaining := make([]string, 0, len(mfa.RecoveryCodes)-1)
+		remaining = append(remaining, mfa.RecoveryCodes[:index]...)
+		mfa.RecoveryCodes = append(remaining, mfa.RecoveryCodes[index+1:]...)
+	default:
+		return mfa, ErrInvalidMFACode
+	}
+	return mfa, nil
+}
+
+// saveMFA menyimpan pengaturan MFA jika dokumen user belum berubah sejak dibaca.
+// Konflik versi berarti kode yang sama baru saja dipakai request lain.
+func (a *Auth) saveMFA(ctx context.Context, user models.User, mfa models.UserMFA) error {
+	err := a.Users.Update(ctx, user.ID, user.Version, repository.Fields{"mfa": mfa})
+	if err == repository.ErrVersionConflict {
+		return ErrInvalidMFACode
+	}
+	return err
+}
+
+// newRecoveryCodes membuat recovery code acak berformat xxxxx-xxxxx beserta hash yang disimpan
+func newRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode mengabaikan huruf besar, spasi dan tanda hubung agar kode mudah diketik ulang
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// matchRecoveryCode mengembalikan indeks hash yang cocok dengan code, atau -1
+func matchRecoveryCode(hashes []string, code string) int {
+	hash := hashRecoveryCode(code)
+	found := -1
+	for i, stored := range hashes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			found = i
+		}
+	}
+	return found
+}
@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// Purpose token yang diterbitkan provider OpenID Connect
+const (
+	PurposeOIDCRequest = "oidc_request" // Permintaan authorize yang menunggu persetujuan user di portal
+	PurposeOIDCAccess  = "oidc_access"  // Access token untuk aplikasi modul, hanya berlaku di endpoint userinfo
+)
+
+// ErrInvalidGrant dikembalikan jika authorization code tidak bisa ditukar dengan token
+var ErrInvalidGrant = errors.New("invalid, expired or already used authorization code")
+
+// AuthorizationRequest adalah parameter /oauth/authorize yang sudah divalidasi. Selama user
+// login dan memberi persetujuan di portal, permintaan dibawa sebagai token bertanda tangan
+// sehingga tidak perlu disimpan dan tidak bisa diubah di tengah jalan.
+type AuthorizationRequest struct {
+	ClientID      string   `json:"client_id"`
+	RedirectURI   string   `json:"redirect_uri"`
+	Scopes        []string `json:"scopes"`
+	State         string   `json:"state,omitempty"`
+	Nonce         string   `json:"nonce,omitempty"`
+	CodeChallenge string   `json:"code_challenge"`
+}
+
+type authorizationRequestClaims struct {
+	AuthorizationRequest
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// OIDCAccessClaims adalah isi access token untuk aplikasi modul
+type OIDCAccessClaims struct {
+	Scope   string `json:"scope"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// signToken menandatangani claims dengan kunci aktif (header "kid")
+func (a *Auth) signToken(claims jwt.Claims) (string, error) {
+	key, err := a.currentSigningKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Alg), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// NewClientSecret membuat client secret acak beserta hash SHA-256 yang disimpan
+func NewClientSecret() (string, string, error) {
+	return newRefreshToken()
+}
+
+// ClientSecretMatches membandingkan client secret dengan hash tersimpan dalam waktu konstan
+func ClientSecretMatches(secret, hash string) bool {
+	return hash != "" && subtle.ConstantTimeCompare([]byte(hashRefreshToken(secret)), []byte(hash)) == 1
+}
+
+// GenerateAuthorizationRequest membuat token permintaan authorize untuk halaman persetujuan
+func (a *Auth) GenerateAuthorizationRequest(req AuthorizationRequest) (string, error) {
+	now := time.Now()
+	return a.signToken(authorizationRequestClaims{
+		AuthorizationRequest: req,
+		Purpose:              PurposeOIDCRequest,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    a.Config.TokenIssuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.Config.OIDC.RequestTTL)),
+		},
+	})
+}
+
+// ParseAuthorizationRequest memverifikasi token permintaan authorize
+func (a *Auth) ParseAuthorizationRequest(token string) (*AuthorizationRequest, error) {
+	var claims authorizationRequestClaims
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+		jwt.WithoutClaimsValidation(),
+	)
+	if _, err := parser.ParseWithClaims(token, &claims, a.verificationKey); err != nil {
+		return nil, tokenError(ErrCodeTokenInvalid, "Invalid authorization request")
+	}
+	if code, msg := validateTimeClaims(claims.RegisteredClaims, time.Now(), a.Config.ClockSkew); code != "" {
+		return nil, tokenError(code, msg)
+	}
+	if claims.Issuer != a.Config.TokenIssuer || claims.Purpose != PurposeOIDCRequest {
+		return nil, tokenError(ErrCodeTokenInvalid, "Invalid authorization request")
+	}
+	return &claims.AuthorizationRequest, nil
+}
+
+// IssueAuthorizationCode membuat authorization code sekali pakai untuk permintaan yang sudah disetujui
+func (a *Auth) IssueAuthorizationCode(ctx context.Context, req AuthorizationRequest, userID primitive.ObjectID, authTime time.Time) (string, error) {
+	// Formatnya sama dengan refresh token: 32 byte acak, yang disimpan hanya hash SHA-256
+	code, hash, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	err = a.OAuthCodes.Create(ctx, &models.OAuthCode{
+		ID:            primitive.NewObjectID(),
+		CodeHash:      hash,
+		ClientID:      req.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        req.Scopes,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      primitive.NewDateTimeFromTime(authTime),
+		CreatedAt:     primitive.NewDateTimeFromTime(now),
+		ExpiresAt:     primitive.NewDateTimeFromTime(now.Add(a.Config.OIDC.CodeTTL)),
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// ExchangeAuthorizationCode memakai authorization code lalu memastikan code diterbitkan untuk
+// client dan redirect_uri yang sama dan code_verifier cocok dengan code_challenge (PKCE).
+// Code tetap hangus walaupun pemeriksaan gagal, sehingga verifier tidak bisa ditebak berulang.
+func (a *Auth) ExchangeAuthorizationCode(ctx context.Context, code, clientID, redirectURI, verifier string) (*models.OAuthCode, error) {
+	issued, err := a.OAuthCodes.Consume(ctx, hashRefreshToken(code), time.Now())
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrInvalidGrant
+		}
+		return nil, err
+	}
+	if issued.ClientID != clientID || issued.RedirectURI != redirectURI || !pkceMatches(verifier, issued.CodeChallenge) {
+		return nil, ErrInvalidGrant
+	}
+	return issued, nil
+}
+
+// pkceMatches memeriksa code_verifier terhadap code_challenge metode S256 (RFC 7636)
+func pkceMatches(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, r := range verifier {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("-._~", r)) {
+			return false
+		}
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// GenerateOIDCTokens membuat access token dan id token untuk code yang sudah ditukar
+func (a *Auth) GenerateOIDCTokens(user models.User, code *models.OAuthCode) (string, string, error) {
+	now := time.Now()
+	subject := user.ID.Hex()
+
+	accessToken, err := a.signToken(OIDCAccessClaims{
+		Scope:   strings.Join(code.Scopes, " "),
+		Purpose: PurposeOIDCAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    a.Config.OIDC.Issuer,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{code.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.Config.OIDC.AccessTokenTTL)),
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	claims := jwt.MapClaims{}
+	for name, value := range UserInfoClaims(user, code.Scopes) {
+		claims[name] = value
+	}
+	claims["iss"] = a.Config.OIDC.Issuer
+	claims["aud"] = code.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(a.Config.OIDC.IDTokenTTL).Unix()
+	claims["auth_time"] = code.AuthTime.Time().Unix()
+	if code.Nonce != "" {
+		claims["nonce"] = code.Nonce
+	}
+	idToken, err := a.signToken(claims)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, idToken, nil
+}
+
+// ParseOIDCAccessToken memverifikasi access token aplikasi modul dan mengembalikan ID user
+func (a *Auth) ParseOIDCAccessToken(token string) (*OIDCAccessClaims, primitive.ObjectID, error) {
+	var claims OIDCAccessClaims
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+		jwt.WithoutClaimsValidation(),
+	)
+	if _, err := parser.ParseWithClaims(token, &claims, a.verificationKey); err != nil {
+		return nil, primitive.NilObjectID, tokenError(ErrCodeTokenInvalid, "Invalid access token")
+	}
+	if code, msg := validateTimeClaims(claims.RegisteredClaims, time.Now(), a.Config.ClockSkew); code != "" {
+		return nil, primitive.NilObjectID, tokenError(code, msg)
+	}
+	if claims.Issuer != a.Config.OIDC.Issuer || claims.Purpose != PurposeOIDCAccess {
+		return nil, primitive.NilObjectID, tokenError(ErrCodeTokenInvalid, "Invalid access token")
+	}
+	userID, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return nil, primitive.NilObjectID, tokenError(ErrCodeTokenInvalid, "Invalid sub in access token")
+	}
+	return &claims, userID, nil
+}
+
+// UserInfoClaims mengembalikan claim standar OpenID Connect milik user sesuai scope
+func UserInfoClaims(user models.User, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{"sub": user.ID.Hex()}
+	for _, scope := range scopes {
+		switch scope {
+		case models.ScopeProfile:
+			claims["name"] = user.NmUser
+			claims["preferred_username"] = user.Username
+			claims["updated_at"] = user.UpdatedAt.Time().Unix()
+			if user.Photo != "" {
+				claims["picture"] = user.Photo
+			}
+			switch user.JenisKelamin {
+			case "L":
+				claims["gender"] = "male"
+			case "P":
+				claims["gender"] = "female"
+			}
+		case models.ScopeEmail:
+			claims["email"] = user.Email
+		case models.ScopePhone:
+			if user.Phone != "" {
+				claims["phone_number"] = user.Phone
+			}
+		}
+	}
+	return claims
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Contoh dari RFC 7636 lampiran B
+const (
+	rfcVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	rfcChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestPKCEMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+		want     bool
+	}{
+		{"contoh RFC 7636", rfcVerifier, true},
+		{"verifier lain", strings.Replace(rfcVerifier, "d", "e", 1), false},
+		{"challenge dipakai sebagai verifier (plain)", rfcChallenge, false},
+		{"terlalu pendek", rfcVerifier[:42], false},
+		{"terlalu panjang", strings.Repeat("a", 129), false},
+		{"karakter tidak valid", rfcVerifier[:42] + "+", false},
+		{"kosong", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pkceMatches(tt.verifier, rfcChallenge); got != tt.want {
+				t.Errorf("pkceMatches(%q) = %v, want %v", tt.verifier, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExchangeAuthorizationCode(t *testing.T) {
+	ctx := context.Background()
+	req := AuthorizationRequest{
+		ClientID:      "modul-a",
+		RedirectURI:   "https://a.unair.ac.id/callback",
+		Scopes:        []string{"openid", "profile"},
+		Nonce:         "n-0S6_WzA2Mj",
+		CodeChallenge: rfcChallenge,
+	}
+
+	tests := []struct {
+		name        string
+		codeTTL     time.Duration
+		clientID    string
+		redirectURI string
+		verifier    string
+		wantErr     error
+	}{
+		{"cocok", time.Minute, req.ClientID, req.RedirectURI, rfcVerifier, nil},
+		{"client lain", time.Minute, "modul-b", req.RedirectURI, rfcVerifier, ErrInvalidGrant},
+		{"redirect_uri lain", time.Minute, req.ClientID, "https://a.unair.ac.id/callback/", rfcVerifier, ErrInvalidGrant},
+		{"verifier salah", time.Minute, req.ClientID, req.RedirectURI, strings.Repeat("a", 43), ErrInvalidGrant},
+		{"tanpa verifier", time.Minute, req.ClientID, req.RedirectURI, "", ErrInvalidGrant},
+		{"code kedaluwarsa", -time.Second, req.ClientID, req.RedirectURI, rfcVerifier, ErrInvalidGrant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.OIDC.CodeTTL = tt.codeTTL
+			auth, _ := newTestAuth(t, cfg)
+			userID := primitive.NewObjectID()
+			code, err := auth.IssueAuthorizationCode(ctx, req, userID, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			issued, err := auth.ExchangeAuthorizationCode(ctx, code, tt.clientID, tt.redirectURI, tt.verifier)
+			if err != tt.wantErr {
+				t.Fatalf("ExchangeAuthorizationCode() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (issued.UserID != userID || issued.Nonce != req.Nonce) {
+				t.Errorf("exchanged code = %+v, want user %v and nonce %q", issued, userID, req.Nonce)
+			}
+
+			// Code hangus setelah ditukar, termasuk jika penukaran pertama gagal
+			if _, err := auth.ExchangeAuthorizationCode(ctx, code, req.ClientID, req.RedirectURI, rfcVerifier); err != ErrInvalidGrant {
+				t.Errorf("second exchange: error = %v, want ErrInvalidGrant", err)
+			}
+		})
+	}
+}
+
+func TestAuthorizationRequestToken(t *testing.T) {
+	auth, store := newTestAuth(t, DefaultConfig())
+	req := AuthorizationRequest{ClientID: "modul-a", RedirectURI: "https://a.unair.ac.id/callback", Scopes: []string{"openid"}, CodeChallenge: rfcChallenge}
+	token, err := auth.GenerateAuthorizationRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := auth.ParseAuthorizationRequest(token)
+	if err != nil {
+		t.Fatalf("ParseAuthorizationRequest() error = %v", err)
+	}
+	if parsed.RedirectURI != req.RedirectURI || parsed.CodeChallenge != req.CodeChallenge {
+		t.Errorf("ParseAuthorizationRequest() = %+v, want %+v", parsed, req)
+	}
+
+	// redirect_uri di dalam permintaan tidak bisa diubah tanpa merusak tanda tangan
+	var claims authorizationRequestClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		t.Fatal(err)
+	}
+	claims.RedirectURI = "https://evil.example/callback"
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+	if _, err := auth.ParseAuthorizationRequest(forged); err == nil {
+		t.Error("ParseAuthorizationRequest() accepted a modified redirect_uri")
+	}
+
+	// Token lain dengan kunci yang sama tidak bisa dipakai sebagai permintaan authorize
+	mfaToken, err := auth.GenerateMFAToken(seedUser(t, store, "budi"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.ParseAuthorizationRequest(mfaToken); err == nil {
+		t.Error("ParseAuthorizationRequest() accepted an mfa_pending token")
+	}
+}
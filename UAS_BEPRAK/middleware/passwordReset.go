@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+// IssuePasswordReset membatalkan token reset lama milik user lalu membuat token baru.
+// Mengembalikan string kosong tanpa error jika permintaan sebelumnya masih dalam
+// Config.PasswordReset.Cooldown, agar endpoint lupa password tidak bisa dipakai membanjiri inbox.
+func (a *Auth) IssuePasswordReset(ctx context.Context, user models.User, ip string) (string, error) {
+	now := time.Now()
+	latest, err := a.PasswordResets.LatestForUser(ctx, user.ID)
+	if err != nil && err != repository.ErrNotFound {
+		return "", err
+	}
+	if latest != nil && now.Sub(latest.CreatedAt.Time()) < a.Config.PasswordReset.Cooldown {
+		return "", nil
+	}
+
+	if err := a.PasswordResets.InvalidateForUser(ctx, user.ID, now); err != nil {
+		return "", err
+	}
+
+	// Formatnya sama dengan refresh token: 32 byte acak, yang disimpan hanya hash SHA-256
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	reset := models.PasswordReset{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		TokenHash: hash,
+		IP:        ip,
+		CreatedAt: primitive.NewDateTimeFromTime(now),
+		ExpiresAt: primitive.NewDateTimeFromTime(now.Add(a.Config.PasswordReset.TokenTTL)),
+	}
+	if err := a.PasswordResets.Create(ctx, &reset); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// PasswordResetOwner mengembalikan ID pemilik token reset yang masih berlaku tanpa memakai
+// tokennya, sehingga password baru bisa diperiksa terhadap data user lebih dulu
+func (a *Auth) PasswordResetOwner(ctx context.Context, token string) (primitive.ObjectID, error) {
+	reset, err := a.PasswordResets.FindUsable(ctx, hashRefreshToken(token), time.Now())
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return primitive.NilObjectID, ErrInvalidResetToken
+		}
+		return primitive.NilObjectID, err
+	}
+	return reset.UserID, nil
+}
+
+// ConsumePasswordReset memakai token reset dan mengembalikan ID pemiliknya. Token yang
+// tidak dikenal, kedaluwarsa atau sudah dipakai menghasilkan ErrInvalidResetToken.
+func (a *Auth) ConsumePasswordReset(ctx context.Context, token string) (primitive.ObjectID, error) {
+	reset, err := a.PasswordResets.Consume(ctx, hashRefreshToken(token), time.Now())
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return primitive.NilObjectID, ErrInvalidResetToken
+		}
+		return primitive.NilObjectID, err
+	}
+
+	// Token lain yang mungkin masih berlaku ikut dibatalkan
+	if err := a.PasswordResets.InvalidateForUser(ctx, reset.UserID, time.Now()); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return reset.UserID, nil
+}
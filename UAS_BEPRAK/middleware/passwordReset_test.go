@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPasswordResetSingleUse(t *testing.T) {
+	ctx := context.Background()
+	cfg := DefaultConfig()
+	cfg.PasswordReset.Cooldown = 0
+	auth, store := newTestAuth(t, cfg)
+	user := seedUser(t, store, "budi")
+
+	token, err := auth.IssuePasswordReset(ctx, user, "127.0.0.1")
+	if err != nil || token == "" {
+		t.Fatalf("IssuePasswordReset() = (%q, %v), want a token", token, err)
+	}
+
+	// Memeriksa pemilik token tidak memakai tokennya
+	for i := 0; i < 2; i++ {
+		if owner, err := auth.PasswordResetOwner(ctx, token); err != nil || owner != user.ID {
+			t.Fatalf("PasswordResetOwner() = (%v, %v), want (%v, nil)", owner, err, user.ID)
+		}
+	}
+	if owner, err := auth.ConsumePasswordReset(ctx, token); err != nil || owner != user.ID {
+		t.Fatalf("ConsumePasswordReset() = (%v, %v), want (%v, nil)", owner, err, user.ID)
+	}
+	if _, err := auth.ConsumePasswordReset(ctx, token); err != ErrInvalidResetToken {
+		t.Errorf("second ConsumePasswordReset() error = %v, want ErrInvalidResetToken", err)
+	}
+	if _, err := auth.PasswordResetOwner(ctx, token); err != ErrInvalidResetToken {
+		t.Errorf("PasswordResetOwner() after use: error = %v, want ErrInvalidResetToken", err)
+	}
+}
+
+func TestPasswordResetRejected(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		prepare func(t *testing.T, auth *Auth, token string) string
+	}{
+		{
+			name:    "token tidak dikenal",
+			ttl:     time.Hour,
+			prepare: func(t *testing.T, auth *Auth, token string) string { return "tidak-dikenal" },
+		},
+		{
+			name:    "token kedaluwarsa",
+			ttl:     -time.Minute,
+			prepare: func(t *testing.T, auth *Auth, token string) string { return token },
+		},
+		{
+			name: "token lama setelah permintaan baru",
+			ttl:  time.Hour,
+			prepare: func(t *testing.T, auth *Auth, token string) string {
+				user, err := auth.Users.FindByUsername(ctx, "budi")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if newer, err := auth.IssuePasswordReset(ctx, *user, "127.0.0.1"); err != nil || newer == "" {
+					t.Fatalf("second IssuePasswordReset() = (%q, %v)", newer, err)
+				}
+				return token
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.PasswordReset = PasswordResetConfig{TokenTTL: tt.ttl, Cooldown: 0}
+			auth, store := newTestAuth(t, cfg)
+			token, err := auth.IssuePasswordReset(ctx, seedUser(t, store, "budi"), "127.0.0.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			token = tt.prepare(t, auth, token)
+			if _, err := auth.PasswordResetOwner(ctx, token); err != ErrInvalidResetToken {
+				t.Errorf("PasswordResetOwner() error = %v, want ErrInvalidResetToken", err)
+			}
+			if _, err := auth.ConsumePasswordReset(ctx, token); err != ErrInvalidResetToken {
+				t.Errorf("ConsumePasswordReset() error = %v, want ErrInvalidResetToken", err)
+			}
+		})
+	}
+}
+
+func TestPasswordResetCooldown(t *testing.T) {
+	ctx := context.Background()
+	cfg := DefaultConfig()
+	cfg.PasswordReset.Cooldown = time.Hour
+	auth, store := newTestAuth(t, cfg)
+	user := seedUser(t, store, "budi")
+
+	first, err := auth.IssuePasswordReset(ctx, user, "127.0.0.1")
+	if err != nil || first == "" {
+		t.Fatalf("IssuePasswordReset() = (%q, %v), want a token", first, err)
+	}
+	// Permintaan dalam masa cooldown tidak menerbitkan token dan tidak membatalkan token lama
+	second, err := auth.IssuePasswordReset(ctx, user, "127.0.0.1")
+	if err != nil || second != "" {
+		t.Fatalf("IssuePasswordReset() in cooldown = (%q, %v), want no token", second, err)
+	}
+	if _, err := auth.ConsumePasswordReset(ctx, first); err != nil {
+		t.Errorf("first token after cooldown request: %v", err)
+	}
+}
+
+func TestPasswordResetStoresHashOnly(t *testing.T) {
+	ctx := context.Background()
+	auth, store := newTestAuth(t, DefaultConfig())
+	user := seedUser(t, store, "budi")
+	token, err := auth.IssuePasswordReset(ctx, user, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reset, err := store.PasswordResets.LatestForUser(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reset.TokenHash == token || reset.TokenHash != hashRefreshToken(token) {
+		t.Errorf("stored TokenHash = %q, want the SHA-256 of the token", reset.TokenHash)
+	}
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"project-crud/models"
+	"project-crud/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+	ErrUserNotFound        = errors.New("user no longer exists")
+)
+
+// Auth menyediakan middleware autentikasi/otorisasi dan pengelolaan sesi login
+type Auth struct {
+	Users          repository.UserRepository
+	Roles          repository.RoleRepository
+	Sessions       repository.SessionRepository
+	LoginThrottles repository.LoginThrottleRepository
+	LoginRecords   repository.LoginRecordRepository
+	PasswordResets repository.PasswordResetRepository
+	OAuthCodes     repository.OAuthCodeRepository
+	Config         Config
+
+	keys *keySet
+}
+
+// NewAuth membuat Auth dari repository yang dibutuhkan dan pengaturannya. Kunci penandatangan
+// token harus dimuat dengan LoadSigningKeys sebelum token pertama diterbitkan.
+func NewAuth(store *repository.Store, cfg Config) *Auth {
+	return &Auth{
+		Users:          store.Users,
+		Roles:          store.Roles,
+		Sessions:       store.Sessions,
+		LoginThrottles: store.LoginThrottles,
+		LoginRecords:   store.LoginRecords,
+		PasswordResets: store.PasswordResets,
+		OAuthCodes:     store.OAuthCodes,
+		Config:         cfg,
+		keys:           newKeySet(),
+	}
+}
+
+// TokenPair adalah pasangan access token dan refresh token yang dikirim ke client
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// newRefreshToken membuat refresh token acak beserta hash yang disimpan di database
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateSession membuat sesi baru untuk user yang berhasil login dan
+// mengembalikan access token serta refresh token
+func (a *Auth) CreateSession(ctx context.Context, user models.User, userAgent, ip string) (*TokenPair, error) {
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:               primitive.NewObjectID(),
+		UserID:           user.ID,
+		Username:         user.Username,
+		RefreshTokenHash: refreshHash,
+		PreviousHashes:   []string{},
+		UserAgent:        userAgent,
+		IP:               ip,
+		ExpiresAt:        primitive.NewDateTimeFromTime(now.Add(a.Config.RefreshTokenTTL)),
+		CreatedAt:        primitive.NewDateTimeFromTime(now),
+		UpdatedAt:        primitive.NewDateTimeFromTime(now),
+	}
+	if err := a.Sessions.Create(ctx, &session); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := a.GenerateToken(user.Username, user.RoleID, user.JenisUserID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresIn: int64(a.Config.AccessTokenTTL.Seconds())}, nil
+}
+
+// RotateSession menukar refresh token lama dengan pasangan token baru.
+// Refresh token lama yang dipakai ulang akan mencabut seluruh sesi tersebut.
+func (a *Auth) RotateSession(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	hash := hashRefreshToken(refreshToken)
+
+	session, err := a.Sessions.FindByRefreshHash(ctx, hash)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+
+	// Refresh token lama dipakai lagi, kemungkinan token bocor
+	if session.RefreshTokenHash != hash {
+		if err := a.Sessions.Revoke(ctx, session.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if session.ExpiresAt.Time().Before(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Ambil data user terbaru agar perubahan role/jenis user ikut terbawa
+	user, err := a.Users.FindByID(ctx, session.UserID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			_ = a.Sessions.Revoke(ctx, session.ID)
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	newToken, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := primitive.NewDateTimeFromTime(time.Now().Add(a.Config.RefreshTokenTTL))
+	rotated, err := a.Sessions.Rotate(ctx, session.ID, hash, newHash, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	// Request refresh lain dengan token yang sama sudah lebih dulu diproses
+	if !rotated {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	accessToken, err := a.GenerateToken(user.Username, user.RoleID, user.JenisUserID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{AccessToken: accessToken, RefreshToken: newToken, ExpiresIn: int64(a.Config.AccessTokenTTL.Seconds())}, nil
+}
+
+// RevokeSession mencabut satu sesi sehingga access token dan refresh token-nya tidak berlaku
+func (a *Auth) RevokeSession(ctx context.Context, sessionID primitive.ObjectID) error {
+	return a.Sessions.Revoke(ctx, sessionID)
+}
+
+// RevokeUserSessions mencabut semua sesi milik user
+func (a *Auth) RevokeUserSessions(ctx context.Context, userID primitive.ObjectID) error {
+	return a.Sessions.RevokeAllForUser(ctx, userID)
+}
+
+// RevokeOtherSessions mencabut semua sesi milik user kecuali sesi yang sedang dipakai
+func (a *Auth) RevokeOtherSessions(ctx context.Context, userID, currentSessionID primitive.ObjectID) error {
+	return a.Sessions.RevokeOthersForUser(ctx, userID, currentSessionID)
+}
+
+// checkSession memastikan sesi pada token masih aktif dan user-nya masih ada
+func (a *Auth) checkSession(ctx context.Context, sessionID primitive.ObjectID) (*models.Session, error) {
+	session, err := a.Sessions.FindByID(ctx, sessionID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, ErrSessionRevoked
+		}
+		return nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+
+	exists, err := a.Users.Exists(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	return session, nil
+}
@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/apperror"
+	"project-crud/models"
+	"project-crud/repository"
+)
+
+// newTestAuth membuat Auth di atas repository memori dengan kunci di folder sementara
+func newTestAuth(t *testing.T, cfg Config) (*Auth, *repository.Store) {
+	t.Helper()
+	cfg.KeyDir = t.TempDir()
+	store := repository.NewMemoryStore()
+	auth := NewAuth(store, cfg)
+	if err := auth.LoadSigningKeys(); err != nil {
+		t.Fatal(err)
+	}
+	return auth, store
+}
+
+// seedUser menyimpan user dengan role dan jenis user acak
+func seedUser(t *testing.T, store *repository.Store, username string) models.User {
+	t.Helper()
+	user := models.User{
+		ID:          primitive.NewObjectID(),
+		Username:    username,
+		Email:       username + "@unair.ac.id",
+		RoleID:      primitive.NewObjectID(),
+		JenisUserID: primitive.NewObjectID(),
+		UserModul:   []models.UserModul{},
+	}
+	if err := store.Users.Create(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// authorize menjalankan JWTAuth untuk token dan mengembalikan status serta kode error-nya
+func authorize(t *testing.T, auth *Auth, token string) (int, string) {
+	t.Helper()
+	app := fiber.New(fiber.Config{ErrorHandler: apperror.Handler(false)})
+	app.Get("/", auth.JWTAuth, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var problem struct {
+		Code string `json:"code"`
+	}
+	json.NewDecoder(resp.Body).Decode(&problem)
+	return resp.StatusCode, problem.Code
+}
+
+func TestRotateSession(t *testing.T) {
+	ctx := context.Background()
+	auth, store := newTestAuth(t, DefaultConfig())
+	user := seedUser(t, store, "budi")
+
+	first, err := auth.CreateSession(ctx, user, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := auth.RotateSession(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("RotateSession() error = %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("RotateSession() returned the same refresh token")
+	}
+	if status, code := authorize(t, auth, second.AccessToken); status != fiber.StatusOK {
+		t.Fatalf("new access token = %d %s, want 200", status, code)
+	}
+
+	// Refresh token lama dipakai ulang: seluruh sesi dicabut, termasuk token hasil rotasi
+	if _, err := auth.RotateSession(ctx, first.RefreshToken); err != ErrRefreshTokenReused {
+		t.Fatalf("reused refresh token: error = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := auth.RotateSession(ctx, second.RefreshToken); err != ErrSessionRevoked {
+		t.Errorf("refresh after reuse: error = %v, want ErrSessionRevoked", err)
+	}
+	for _, token := range []string{first.AccessToken, second.AccessToken} {
+		if status, code := authorize(t, auth, token); status != fiber.StatusUnauthorized || code != ErrCodeSessionRevoked {
+			t.Errorf("access token after reuse = %d %s, want 401 %s", status, code, ErrCodeSessionRevoked)
+		}
+	}
+}
+
+func TestRotateSessionRejected(t *testing.T) {
+	ctx := context.Background()
+	expired := DefaultConfig()
+	expired.RefreshTokenTTL = -1
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		prepare func(t *testing.T, auth *Auth, store *repository.Store, user models.User, tokens *TokenPair) string
+		wantErr error
+	}{
+		{
+			name: "token tidak dikenal",
+			cfg:  DefaultConfig(),
+			prepare: func(t *testing.T, auth *Auth, store *repository.Store, user models.User, tokens *TokenPair) string {
+				return "tidak-dikenal"
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "sesi kedaluwarsa",
+			cfg:  expired,
+			prepare: func(t *testing.T, auth *Auth, store *repository.Store, user models.User, tokens *TokenPair) string {
+				return tokens.RefreshToken
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "logout",
+			cfg:  DefaultConfig(),
+			prepare: func(t *testing.T, auth *Auth, store *repository.Store, user models.User, tokens *TokenPair) string {
+				if err := auth.RevokeUserSessions(ctx, user.ID); err != nil {
+					t.Fatal(err)
+				}
+				return tokens.RefreshToken
+			},
+			wantErr: ErrSessionRevoked,
+		},
+		{
+			name: "user dihapus",
+			cfg:  DefaultConfig(),
+			prepare: func(t *testing.T, auth *Auth, store *repository.Store, user models.User, tokens *TokenPair) string {
+				if err := store.Users.SoftDelete(ctx, user.ID, "admin"); err != nil {
+					t.Fatal(err)
+				}
+				return tokens.RefreshToken
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, store := newTestAuth(t, tt.cfg)
+			user := seedUser(t, store, "budi")
+			tokens, err := auth.CreateSession(ctx, user, "test", "127.0.0.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			refreshToken := tt.prepare(t, auth, store, user, tokens)
+			if _, err := auth.RotateSession(ctx, refreshToken); err != tt.wantErr {
+				t.Errorf("RotateSession() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTAuthRevokedSession(t *testing.T) {
+	ctx := context.Background()
+	auth, store := newTestAuth(t, DefaultConfig())
+	user := seedUser(t, store, "budi")
+
+	current, err := auth.CreateSession(ctx, user, "laptop", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := auth.CreateSession(ctx, user, "ponsel", "127.0.0.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Logout satu sesi tidak memengaruhi sesi lain milik user yang sama
+	var claims Claims
+	if _, _, err := jwt.NewParser().ParseUnverified(current.AccessToken, &claims); err != nil {
+		t.Fatal(err)
+	}
+	sessionID, _ := primitive.ObjectIDFromHex(claims.SessionID)
+	if err := auth.RevokeSession(ctx, sessionID); err != nil {
+		t.Fatal(err)
+	}
+	if status, code := authorize(t, auth, current.AccessToken); status != fiber.StatusUnauthorized || code != ErrCodeSessionRevoked {
+		t.Errorf("revoked session = %d %s, want 401 %s", status, code, ErrCodeSessionRevoked)
+	}
+	if status, code := authorize(t, auth, other.AccessToken); status != fiber.StatusOK {
+		t.Errorf("other session = %d %s, want 200", status, code)
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+
+	"project-crud/apperror"
+)
+
+// traceIDPattern membatasi X-Request-ID dari klien agar aman ditulis ke log dan respons
+var traceIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{8,64}$`)
+
+// TraceID memberi setiap request ID yang dikirim balik lewat header X-Request-ID,
+// ditulis ke log error dan dicantumkan pada respons problem+json. ID dari klien
+// (misalnya dari gateway) dipakai ulang jika formatnya valid.
+func TraceID(c *fiber.Ctx) error {
+	id := c.Get(fiber.HeaderXRequestID)
+	if !traceIDPattern.MatchString(id) {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return apperror.Wrap(err)
+		}
+		id = hex.EncodeToString(b)
+	}
+	c.Locals(apperror.TraceIDLocal, id)
+	c.Set(fiber.HeaderXRequestID, id)
+	return c.Next()
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Aksi yang dicatat pada audit log
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionUnlock  = "unlock" // Membuka kunci login user
+)
+
+// Entitas pada audit log, sama dengan nama koleksinya
+const (
+	AuditEntityRole          = "roles"
+	AuditEntityKategoriModul = "kategori_modul"
+	AuditEntityModul         = "moduls"
+	AuditEntityJenisUser     = "jenis_users"
+	AuditEntityUser          = "users"
+	AuditEntityOAuthClient   = "oauth_clients"
+)
+
+// AuditChange adalah perubahan satu field (nama field bson, bertingkat dipisah titik)
+type AuditChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before" json:"before"` // null jika field sebelumnya tidak ada
+	After  interface{} `bson:"after" json:"after"`   // null jika field dihapus
+}
+
+// AuditLog adalah catatan satu perubahan data oleh user yang sedang login
+type AuditLog struct {
+	ID        primitive.ObjectID     `bson:"_id" json:"id"`
+	Actor     string                 `bson:"actor" json:"actor"`                   // Username pelaku
+	ActorID   primitive.ObjectID     `bson:"actor_id" json:"actor_id"`             // Referensi ke User pelaku
+	IP        string                 `bson:"ip" json:"ip"`                         // Alamat IP pelaku
+	Method    string                 `bson:"method" json:"method"`                 // Method HTTP
+	Route     string                 `bson:"route" json:"route"`                   // Pola route, misalnya /api/admin/edit-roles/:id
+	Action    string                 `bson:"action" json:"action"`                 // create, update, delete atau restore
+	Entity    string                 `bson:"entity" json:"entity"`                 // Nama koleksi entitas yang berubah
+	EntityID  primitive.ObjectID     `bson:"entity_id" json:"entity_id"`           // ID entitas yang berubah
+	Changes   []AuditChange          `bson:"changes" json:"changes"`               // Perubahan per field
+	Meta      map[string]interface{} `bson:"meta,omitempty" json:"meta,omitempty"` // Keterangan tambahan, misalnya policy hapus
+	CreatedAt primitive.DateTime     `bson:"created_at" json:"created_at"`         // Waktu perubahan
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DashboardModul adalah satu tile modul pada portal
+type DashboardModul struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	AlamatURL   string             `bson:"alamat_url" json:"alamat_url"`
+	GbrIcon     string             `bson:"gbr_icon" json:"gbr_icon"`
+}
+
+// DashboardKategori adalah satu kelompok tile modul pada portal berdasarkan KategoriModul.
+// Modul tanpa kategori (atau kategorinya sudah dihapus) dikelompokkan dengan ID kosong.
+type DashboardKategori struct {
+	ID     primitive.ObjectID `bson:"_id" json:"id"`
+	Name   string             `bson:"name" json:"name"`
+	Moduls []DashboardModul   `bson:"moduls" json:"moduls"`
+}
@@ -18,4 +18,7 @@ type JenisUser struct {
     UpdatedAt   primitive.DateTime `json:"updated_at" bson:"updated_at"` // Waktu terakhir diperbarui
     CreatedBy   string              `json:"created_by" bson:"created_by"` // User yang membuat
     UpdatedBy   string              `json:"updated_by" bson:"updated_by"` // User yang memperbarui
+    Version     int64               `json:"version" bson:"version"`       // Naik setiap kali dokumen diubah
+    DeletedAt   *primitive.DateTime `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"` // Waktu dipindahkan ke trash
+    DeletedBy   string              `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"` // User yang menghapus
 }
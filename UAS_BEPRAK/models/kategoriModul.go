@@ -12,4 +12,7 @@ type KategoriModul struct {
     UpdatedAt   primitive.DateTime `bson:"updated_at" json:"updated_at"`   // Tanggal pembaruan
     CreatedBy   string             `bson:"created_by" json:"created_by"`   // Pembuat
     UpdatedBy   string             `bson:"updated_by" json:"updated_by"`   // Yang memperbarui}
+    Version     int64              `bson:"version" json:"version"`         // Naik setiap kali dokumen diubah
+    DeletedAt   *primitive.DateTime `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // Waktu dipindahkan ke trash
+    DeletedBy   string             `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"` // Yang menghapus
 }
\ No newline at end of file
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Hasil percobaan login pada LoginRecord
+const (
+	LoginResultSuccess            = "success"
+	LoginResultInvalidCredentials = "invalid_credentials" // Username tidak ada atau password salah
+	LoginResultAccountLocked      = "account_locked"      // Ditolak karena username sedang dikunci
+	LoginResultTooManyAttempts    = "too_many_attempts"   // Ditolak karena tunda progresif atau IP diblokir
+	LoginResultAccountIncomplete  = "account_incomplete"  // Password benar tetapi role atau jenis user tidak ada
+	LoginResultMFARequired        = "mfa_required"        // Password benar, menunggu kode dua faktor
+	LoginResultInvalidMFACode     = "invalid_mfa_code"    // Kode TOTP atau recovery code salah
+)
+
+// LoginRecord adalah catatan satu percobaan login, berhasil maupun gagal
+type LoginRecord struct {
+	ID        primitive.ObjectID  `bson:"_id" json:"id"`
+	Username  string              `bson:"username" json:"username"`                   // Username yang dikirim
+	UserID    *primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"` // Kosong jika username tidak dikenal
+	IP        string              `bson:"ip" json:"ip"`                               // Alamat IP pengirim
+	UserAgent string              `bson:"user_agent" json:"user_agent"`               // User agent pengirim
+	Success   bool                `bson:"success" json:"success"`                     // true jika token diterbitkan
+	Result    string              `bson:"result" json:"result"`                       // Salah satu LoginResult*
+	CreatedAt primitive.DateTime  `bson:"created_at" json:"created_at"`               // Waktu percobaan
+	ExpiresAt primitive.DateTime  `bson:"expires_at" json:"-"`                        // Dihapus index TTL setelah waktu ini
+}
+
+// LoginThrottle adalah penghitung login gagal untuk satu username atau satu IP.
+// Dokumen dihapus index TTL setelah ExpiresAt sehingga penghitung kembali ke nol.
+type LoginThrottle struct {
+	Key           string              `bson:"_id" json:"key"`                                             // "user:<username>" atau "ip:<alamat>"
+	Failures      int                 `bson:"failures" json:"failures"`                                   // Jumlah gagal dalam jendela waktu
+	LastFailureAt primitive.DateTime  `bson:"last_failure_at" json:"last_failure_at"`                     // Waktu gagal terakhir
+	NextAttemptAt *primitive.DateTime `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"` // Percobaan sebelum waktu ini ditolak
+	LockedUntil   *primitive.DateTime `bson:"locked_until,omitempty" json:"locked_until,omitempty"`       // Dikunci sampai waktu ini
+	ExpiresAt     primitive.DateTime  `bson:"expires_at" json:"expires_at"`                               // Penghitung kedaluwarsa
+}
@@ -16,4 +16,7 @@ type Modul struct {
     UpdatedBy     string             `bson:"updated_by" json:"updated_by"` 
     AlamatURL     string             `bson:"alamat_url" json:"alamat_url"`
     GbrIcon       string             `bson:"gbr_icon" json:"gbr_icon"`           // Waktu pembuatan
+    Version       int64              `bson:"version" json:"version"`           // Naik setiap kali dokumen diubah
+    DeletedAt     *primitive.DateTime `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // Waktu dipindahkan ke trash
+    DeletedBy     string             `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"` // Yang menghapus
 }
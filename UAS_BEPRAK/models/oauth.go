@@ -0,0 +1,86 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scope OpenID Connect yang didukung provider
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+	ScopePhone   = "phone"
+)
+
+// SupportedScopes berisi semua scope yang dikenal, scope lain pada permintaan diabaikan
+var SupportedScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopePhone}
+
+// OAuthClient adalah registrasi aplikasi modul sebagai client OpenID Connect.
+// Satu modul memiliki paling banyak satu client dan client_id sama dengan ID modul.
+type OAuthClient struct {
+	ModulID      primitive.ObjectID `bson:"_id" json:"modul_id"`
+	ClientID     string             `bson:"client_id" json:"client_id"`
+	SecretHash   string             `bson:"secret_hash,omitempty" json:"-"` // Hash SHA-256 client secret, kosong untuk public client
+	Public       bool               `bson:"public" json:"public"`           // Client tanpa secret (SPA atau aplikasi mobile)
+	RedirectURIs []string           `bson:"redirect_uris" json:"redirect_uris"`
+	CreatedAt    primitive.DateTime `bson:"created_at" json:"created_at"`
+	CreatedBy    string             `bson:"created_by" json:"created_by"`
+	UpdatedAt    primitive.DateTime `bson:"updated_at" json:"updated_at"`
+	UpdatedBy    string             `bson:"updated_by" json:"updated_by"`
+	Version      int64              `bson:"version" json:"version"` // Naik setiap kali dokumen diubah
+}
+
+// HasRedirectURI memeriksa apakah redirect_uri terdaftar persis sama pada client
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	for _, registered := range c.RedirectURIs {
+		if registered == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthCode adalah authorization code sekali pakai. Code asli hanya dikirim ke redirect_uri,
+// yang disimpan hanya hash SHA-256-nya bersama code_challenge PKCE.
+type OAuthCode struct {
+	ID            primitive.ObjectID  `bson:"_id"`
+	CodeHash      string              `bson:"code_hash"`
+	ClientID      string              `bson:"client_id"`
+	UserID        primitive.ObjectID  `bson:"user_id"`
+	RedirectURI   string              `bson:"redirect_uri"`
+	Scopes        []string            `bson:"scopes"`
+	Nonce         string              `bson:"nonce,omitempty"`
+	CodeChallenge string              `bson:"code_challenge"` // BASE64URL(SHA256(code_verifier))
+	AuthTime      primitive.DateTime  `bson:"auth_time"`      // Waktu user login ke portal
+	CreatedAt     primitive.DateTime  `bson:"created_at"`
+	ExpiresAt     primitive.DateTime  `bson:"expires_at"` // Dihapus index TTL setelah waktu ini
+	UsedAt        *primitive.DateTime `bson:"used_at,omitempty"`
+}
+
+// OAuthConsent adalah persetujuan user agar aplikasi modul menerima data pada scope tertentu.
+// Selama scope yang diminta sudah disetujui, user tidak ditanya lagi.
+type OAuthConsent struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ClientID  string             `bson:"client_id" json:"client_id"`
+	Scopes    []string           `bson:"scopes" json:"scopes"`
+	CreatedAt primitive.DateTime `bson:"created_at" json:"created_at"`
+	UpdatedAt primitive.DateTime `bson:"updated_at" json:"updated_at"`
+}
+
+// Covers memeriksa apakah semua scope sudah pernah disetujui
+func (c *OAuthConsent) Covers(scopes []string) bool {
+	for _, scope := range scopes {
+		found := false
+		for _, granted := range c.Scopes {
+			if granted == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
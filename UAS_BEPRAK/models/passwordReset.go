@@ -0,0 +1,17 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PasswordReset adalah token reset password sekali pakai. Token asli hanya dikirim lewat email,
+// yang disimpan hanya hash SHA-256-nya.
+type PasswordReset struct {
+	ID        primitive.ObjectID  `bson:"_id" json:"id"`
+	UserID    primitive.ObjectID  `bson:"user_id" json:"user_id"`                     // Pemilik token
+	TokenHash string              `bson:"token_hash" json:"-"`                        // Hash SHA-256 token
+	IP        string              `bson:"ip" json:"ip"`                               // IP yang meminta reset
+	CreatedAt primitive.DateTime  `bson:"created_at" json:"created_at"`               // Waktu permintaan
+	ExpiresAt primitive.DateTime  `bson:"expires_at" json:"expires_at"`               // Token tidak berlaku dan dihapus index TTL setelah waktu ini
+	UsedAt    *primitive.DateTime `bson:"used_at,omitempty" json:"used_at,omitempty"` // Waktu token dipakai atau dibatalkan
+}
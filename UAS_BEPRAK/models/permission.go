@@ -0,0 +1,84 @@
+package models
+
+import "strings"
+
+// Daftar permission yang dapat diberikan kepada sebuah role.
+// Format permission adalah "<resource>:<aksi>", misalnya "user:create".
+const (
+	PermRoleCreate = "role:create"
+	PermRoleRead   = "role:read"
+	PermRoleUpdate = "role:update"
+	PermRoleDelete = "role:delete"
+
+	PermKategoriModulCreate = "kategori_modul:create"
+	PermKategoriModulRead   = "kategori_modul:read"
+	PermKategoriModulUpdate = "kategori_modul:update"
+	PermKategoriModulDelete = "kategori_modul:delete"
+
+	PermModulCreate = "modul:create"
+	PermModulRead   = "modul:read"
+	PermModulUpdate = "modul:update"
+	PermModulDelete = "modul:delete"
+
+	PermJenisUserCreate = "jenis_user:create"
+	PermJenisUserRead   = "jenis_user:read"
+	PermJenisUserUpdate = "jenis_user:update"
+	PermJenisUserDelete = "jenis_user:delete"
+
+	PermUserCreate = "user:create"
+	PermUserRead   = "user:read"
+	PermUserUpdate = "user:update"
+	PermUserDelete = "user:delete"
+
+	PermAuditRead = "audit:read"
+
+	// PermMFAReset menghapus dua faktor milik user lain. Sengaja terpisah dari resource user
+	// agar tidak ikut pada "user:*", karena bisa melemahkan akun admin lain.
+	PermMFAReset = "mfa:reset"
+
+	// PermAll memberikan seluruh permission (super admin)
+	PermAll = "*"
+)
+
+// AllPermissions berisi semua permission yang dikenal oleh aplikasi
+var AllPermissions = []string{
+	PermRoleCreate, PermRoleRead, PermRoleUpdate, PermRoleDelete,
+	PermKategoriModulCreate, PermKategoriModulRead, PermKategoriModulUpdate, PermKategoriModulDelete,
+	PermModulCreate, PermModulRead, PermModulUpdate, PermModulDelete,
+	PermJenisUserCreate, PermJenisUserRead, PermJenisUserUpdate, PermJenisUserDelete,
+	PermUserCreate, PermUserRead, PermUserUpdate, PermUserDelete,
+	PermAuditRead,
+	PermMFAReset,
+}
+
+// IsValidPermission mengecek apakah permission dikenal, termasuk wildcard
+// "*" dan "<resource>:*"
+func IsValidPermission(permission string) bool {
+	if permission == PermAll {
+		return true
+	}
+	for _, p := range AllPermissions {
+		if p == permission {
+			return true
+		}
+		if strings.HasSuffix(permission, ":*") && strings.HasPrefix(p, strings.TrimSuffix(permission, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission mengecek apakah daftar permission milik role mencakup
+// permission yang dibutuhkan
+func (r Role) HasPermission(required string) bool {
+	resource := required
+	if i := strings.Index(required, ":"); i >= 0 {
+		resource = required[:i]
+	}
+	for _, p := range r.Permissions {
+		if p == PermAll || p == required || p == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Aksi yang menyebabkan revisi dibuat
+const (
+	RevisionActionUpdate   = "update"   // Edit biasa
+	RevisionActionRollback = "rollback" // Dokumen dikembalikan ke revisi lain
+)
+
+// Revision adalah salinan dokumen sebelum diubah. Nomor revisi berurutan per dokumen mulai dari 1.
+type Revision[T any] struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	EntityID   primitive.ObjectID `bson:"entity_id" json:"entity_id"`                         // ID dokumen asal
+	Revision   int                `bson:"revision" json:"revision"`                           // Nomor revisi
+	Action     string             `bson:"action" json:"action"`                               // Aksi yang menggantikan versi ini
+	RollbackTo int                `bson:"rollback_to,omitempty" json:"rollback_to,omitempty"` // Revisi tujuan jika action rollback
+	Document   T                  `bson:"document" json:"document"`                           // Isi dokumen pada versi ini
+	CreatedAt  primitive.DateTime `bson:"created_at" json:"created_at"`                       // Waktu versi ini digantikan
+	CreatedBy  string             `bson:"created_by" json:"created_by"`                       // User yang menggantikan versi ini
+}
@@ -8,8 +8,13 @@ import (
 type Role struct {
     ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
     Name        string             `bson:"name" json:"name"`               // Nama role
+    Permissions []string           `bson:"permissions" json:"permissions"` // Daftar permission, misalnya "user:create"
+    RequireMFA  bool               `bson:"require_mfa" json:"require_mfa"` // User dengan role ini wajib memakai autentikasi dua faktor
     CreatedAt   primitive.DateTime `bson:"created_at" json:"created_at"`   // Tanggal pembuatan
     UpdatedAt   primitive.DateTime `bson:"updated_at" json:"updated_at"`   // Tanggal pembaruan
     CreatedBy   string             `bson:"created_by" json:"created_by"`   // Pembuat
     UpdatedBy   string             `bson:"updated_by" json:"updated_by"`   // Yang memperbarui
+    Version     int64              `bson:"version" json:"version"`         // Naik setiap kali dokumen diubah
+    DeletedAt   *primitive.DateTime `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // Waktu dipindahkan ke trash
+    DeletedBy   string             `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"` // Yang menghapus
 }
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session adalah struktur untuk menyimpan sesi login beserta refresh token-nya
+type Session struct {
+	ID               primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`                // ID sesi (claim "sid" pada token)
+	UserID           primitive.ObjectID  `bson:"user_id" json:"user_id"`                           // Referensi ke User
+	Username         string              `bson:"username" json:"username"`                         // Username pemilik sesi
+	RefreshTokenHash string              `bson:"refresh_token_hash" json:"-"`                      // Hash SHA-256 refresh token yang berlaku
+	PreviousHashes   []string            `bson:"previous_hashes" json:"-"`                         // Hash refresh token lama (deteksi reuse)
+	UserAgent        string              `bson:"user_agent" json:"user_agent"`                     // User agent saat login
+	IP               string              `bson:"ip" json:"ip"`                                     // Alamat IP saat login
+	ExpiresAt        primitive.DateTime  `bson:"expires_at" json:"expires_at"`                     // Waktu kedaluwarsa refresh token
+	RevokedAt        *primitive.DateTime `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"` // Waktu sesi dicabut (logout)
+	CreatedAt        primitive.DateTime  `bson:"created_at" json:"created_at"`                     // Waktu pembuatan
+	UpdatedAt        primitive.DateTime  `bson:"updated_at" json:"updated_at"`                     // Waktu terakhir refresh
+}
@@ -4,10 +4,28 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Asal sebuah UserModul
+const (
+	UserModulSourceTemplate = "template" // Disalin dari template_modul jenis user
+	UserModulSourceManual   = "manual"   // Diberikan khusus untuk user lewat AddUserModule
+)
+
+// Nilai jenis_kelamin yang valid
+const (
+	JenisKelaminLakiLaki  = "L"
+	JenisKelaminPerempuan = "P"
+)
+
+// IsValidJenisKelamin memeriksa apakah nilai jenis_kelamin dikenal
+func IsValidJenisKelamin(value string) bool {
+	return value == JenisKelaminLakiLaki || value == JenisKelaminPerempuan
+}
+
 // UserModul adalah struktur untuk menyimpan hubungan antara user dan modul
 type UserModul struct {
 	ModulID    primitive.ObjectID `json:"modul_id" bson:"modul_id"`       // Referensi ke Modul
 	NamaModul	string				`json:"nm_modul" bson:"nm_modul"` 
+	Source     string             `json:"source,omitempty" bson:"source,omitempty"` // Asal modul: template atau manual (kosong untuk data lama, diperlakukan sebagai manual)
 	CreatedAt  primitive.DateTime `json:"created_at" bson:"created_at"`   // Waktu pembuatan
 	CreatedBy  string             `json:"created_by" bson:"created_by"`   // User yang membuat
 	UpdatedAt  primitive.DateTime `json:"updated_at" bson:"updated_at"`   // Waktu terakhir diperbarui
@@ -26,10 +44,30 @@ type User struct {
     RoleID       primitive.ObjectID `bson:"role_id" json:"role_id"`                   // Referensi ke Role
 	JenisKelamin string             `bson:"jenis_kelamin" json:"jenis_kelamin"`       // Jenis kelamin
 	JenisUserID  primitive.ObjectID `bson:"jenis_user_id" json:"jenis_user_id"`       // Referensi ke JenisUser
-	Token        string             `bson:"token,omitempty" json:"token,omitempty"`   // Token JWT (opsional)
 	UserModul    []UserModul        `bson:"user_modul" json:"user_modul"`             // Modul yang diakses oleh user
 	CreatedAt    primitive.DateTime `bson:"created_at" json:"created_at"`             // Waktu pembuatan
     CreatedBy     string             `bson:"created_by" json:"created_by"`
     UpdatedAt     primitive.DateTime `bson:"updated_at" json:"updated_at"`
     UpdatedBy     string             `bson:"updated_by" json:"updated_by"`
+    Version       int64              `bson:"version" json:"version"`           // Naik setiap kali dokumen diubah
+    DeletedAt     *primitive.DateTime `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // Waktu dipindahkan ke trash
+    DeletedBy     string             `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"` // Yang menghapus
+    MFA           *UserMFA           `bson:"mfa,omitempty" json:"mfa,omitempty"`               // Autentikasi dua faktor, nil jika belum pernah didaftarkan
+    PasswordHistory []string         `bson:"password_history,omitempty" json:"-"`               // Hash password sebelumnya, terbaru lebih dulu
+}
+
+// UserMFA adalah pengaturan autentikasi dua faktor (TOTP) milik user.
+// Secret dan recovery code tidak pernah ikut JSON.
+type UserMFA struct {
+	Enabled       bool                `bson:"enabled" json:"enabled"`
+	EnabledAt     *primitive.DateTime `bson:"enabled_at,omitempty" json:"enabled_at,omitempty"`
+	Secret        string              `bson:"secret,omitempty" json:"-"`         // Secret TOTP aktif (base32)
+	PendingSecret string              `bson:"pending_secret,omitempty" json:"-"` // Secret yang sedang didaftarkan, aktif setelah dikonfirmasi
+	RecoveryCodes []string            `bson:"recovery_codes,omitempty" json:"-"` // Hash SHA-256 recovery code yang belum dipakai
+	LastUsedStep  int64               `bson:"last_used_step,omitempty" json:"-"` // Time step TOTP terakhir yang diterima, mencegah kode dipakai ulang
+}
+
+// MFAEnabled memeriksa apakah user sudah mengaktifkan autentikasi dua faktor
+func (u *User) MFAEnabled() bool {
+	return u.MFA != nil && u.MFA.Enabled
 }
\ No newline at end of file
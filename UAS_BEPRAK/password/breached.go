@@ -0,0 +1,104 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Panjang prefix hash pada model k-anonymity Pwned Passwords
+const prefixLength = 5
+
+//go:embed breached.txt
+var bundledBreached string
+
+// BreachedList adalah daftar hash SHA-1 password yang bocor, dikelompokkan per prefix
+// 5 karakter seperti range API Pwned Passwords. Lookup hanya membuka kelompok prefix
+// yang sama sehingga sumber daftar bisa diganti ke range API tanpa mengubah pemanggil.
+type BreachedList struct {
+	ranges map[string]map[string]struct{}
+}
+
+var (
+	bundledOnce sync.Once
+	bundled     *BreachedList
+)
+
+// BundledBreachedList mengembalikan daftar bawaan yang ikut dikompilasi ke binary
+func BundledBreachedList() *BreachedList {
+	bundledOnce.Do(func() {
+		list := &BreachedList{ranges: map[string]map[string]struct{}{}}
+		if err := list.read(strings.NewReader(bundledBreached)); err != nil {
+			panic("password: invalid bundled breached list: " + err.Error())
+		}
+		bundled = list
+	})
+	return bundled
+}
+
+// LoadBreachedList membaca daftar bawaan ditambah file opsional berformat Pwned Passwords
+// (satu "HASH" atau "HASH:JUMLAH" SHA-1 per baris). Seluruh isi file dimuat ke memori,
+// jadi gunakan subset daftar tersebut, bukan dump lengkapnya.
+func LoadBreachedList(file string) (*BreachedList, error) {
+	list := &BreachedList{ranges: map[string]map[string]struct{}{}}
+	if err := list.read(strings.NewReader(bundledBreached)); err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return list, nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := list.read(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return list, nil
+}
+
+func (l *BreachedList) read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash := strings.ToUpper(strings.SplitN(text, ":", 2)[0])
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+			return fmt.Errorf("line %d: not a SHA-1 hash", line)
+		}
+		prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+		if l.ranges[prefix] == nil {
+			l.ranges[prefix] = map[string]struct{}{}
+		}
+		l.ranges[prefix][suffix] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Len mengembalikan jumlah hash pada daftar
+func (l *BreachedList) Len() int {
+	n := 0
+	for _, suffixes := range l.ranges {
+		n += len(suffixes)
+	}
+	return n
+}
+
+// Contains memeriksa apakah password ada di daftar
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, found := l.ranges[hash[:prefixLength]][hash[prefixLength:]]
+	return found
+}
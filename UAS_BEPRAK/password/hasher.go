@@ -0,0 +1,95 @@
+package password
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrUnknownHash dikembalikan jika hash tersimpan tidak dikenali oleh algoritma mana pun
+var ErrUnknownHash = errors.New("password: unknown hash format")
+
+// Algorithm adalah satu algoritma hash password. Hash yang dihasilkan membawa identitas
+// algoritma dan parameternya sendiri ("$argon2id$v=19$m=...", "$2a$10$..."), sehingga hash
+// lama tetap bisa diverifikasi setelah algoritma atau parameter default diganti.
+type Algorithm interface {
+	// Name adalah identitas algoritma pada hash, misalnya "argon2id" atau "bcrypt"
+	Name() string
+	Hash(password string) (string, error)
+	// Identifies memeriksa apakah hash dibuat oleh algoritma ini
+	Identifies(encoded string) bool
+	Verify(password, encoded string) (bool, error)
+	// Outdated memeriksa apakah hash memakai parameter yang berbeda dari konfigurasi saat ini
+	Outdated(encoded string) bool
+}
+
+// Hasher membuat hash dengan algoritma Default dan memverifikasi hash dari Default
+// maupun algoritma Legacy. Hash dari algoritma Legacy atau dengan parameter lama
+// ditandai perlu di-rehash setelah password berhasil diverifikasi.
+type Hasher struct {
+	Default Algorithm
+	Legacy  []Algorithm
+
+	dummyOnce sync.Once
+	dummy     string
+}
+
+// NewHasher membuat Hasher dengan algoritma default dan algoritma lama yang masih diterima
+func NewHasher(def Algorithm, legacy ...Algorithm) *Hasher {
+	return &Hasher{Default: def, Legacy: legacy}
+}
+
+// DefaultHasher memakai Argon2id dengan parameter bawaan dan tetap menerima hash bcrypt
+func DefaultHasher() *Hasher {
+	return NewHasher(DefaultArgon2id(), Bcrypt{Cost: DefaultBcryptCost})
+}
+
+// Hash membuat hash password dengan algoritma default
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.Default.Hash(password)
+}
+
+// Verify memeriksa password terhadap hash tersimpan. rehash bernilai true jika password
+// cocok tetapi hash dibuat dengan algoritma lain atau parameter lama.
+func (h *Hasher) Verify(password, encoded string) (match, rehash bool, err error) {
+	algorithm := h.algorithmFor(encoded)
+	if algorithm == nil {
+		return false, false, ErrUnknownHash
+	}
+	match, err = algorithm.Verify(password, encoded)
+	if err != nil || !match {
+		return false, false, err
+	}
+	rehash = algorithm.Name() != h.Default.Name() || algorithm.Outdated(encoded)
+	return true, rehash, nil
+}
+
+// VerifyDummy menjalankan verifikasi terhadap hash pengganti dengan algoritma default.
+// Dipakai saat user tidak ditemukan agar waktu respons sama dengan user yang ada.
+func (h *Hasher) VerifyDummy(password string) {
+	h.dummyOnce.Do(func() {
+		h.dummy, _ = h.Default.Hash("dummy-password-for-timing")
+	})
+	h.Verify(password, h.dummy)
+}
+
+// Reused memeriksa apakah password cocok dengan salah satu hash lama
+func (h *Hasher) Reused(password string, hashes []string) bool {
+	for _, encoded := range hashes {
+		if match, _, _ := h.Verify(password, encoded); match {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Hasher) algorithmFor(encoded string) Algorithm {
+	if h.Default.Identifies(encoded) {
+		return h.Default
+	}
+	for _, algorithm := range h.Legacy {
+		if algorithm.Identifies(encoded) {
+			return algorithm
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package password
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Parameter kecil agar test cepat, formatnya tetap sama dengan parameter produksi
+var testArgon2id = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func mustHash(t *testing.T, algorithm Algorithm, pass string) string {
+	t.Helper()
+	hash, err := algorithm.Hash(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestHasherVerify(t *testing.T) {
+	hasher := NewHasher(testArgon2id, Bcrypt{Cost: bcrypt.MinCost})
+	oldArgon2id := testArgon2id
+	oldArgon2id.Iterations = 2
+
+	tests := []struct {
+		name       string
+		encoded    string
+		pass       string
+		wantMatch  bool
+		wantRehash bool
+		wantErr    error
+	}{
+		{"argon2id cocok", mustHash(t, testArgon2id, "Rahasia#123"), "Rahasia#123", true, false, nil},
+		{"argon2id salah", mustHash(t, testArgon2id, "Rahasia#123"), "Rahasia#124", false, false, nil},
+		{"argon2id parameter lama", mustHash(t, oldArgon2id, "Rahasia#123"), "Rahasia#123", true, true, nil},
+		{"argon2id parameter lama salah", mustHash(t, oldArgon2id, "Rahasia#123"), "salah", false, false, nil},
+		{"bcrypt legacy cocok", mustHash(t, Bcrypt{Cost: bcrypt.MinCost}, "Rahasia#123"), "Rahasia#123", true, true, nil},
+		{"bcrypt legacy salah", mustHash(t, Bcrypt{Cost: bcrypt.MinCost}, "Rahasia#123"), "salah", false, false, nil},
+		{"format tidak dikenal", "plaintext", "plaintext", false, false, ErrUnknownHash},
+		{"hash kosong", "", "", false, false, ErrUnknownHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, rehash, err := hasher.Verify(tt.pass, tt.encoded)
+			if err != tt.wantErr {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if match != tt.wantMatch || rehash != tt.wantRehash {
+				t.Errorf("Verify() = (%v, %v), want (%v, %v)", match, rehash, tt.wantMatch, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestHasherVerifyMalformedArgon2id(t *testing.T) {
+	hasher := NewHasher(testArgon2id)
+	tests := []string{
+		"$argon2id$",
+		"$argon2id$v=19$m=64,t=1,p=1$salt",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5",
+	}
+	for _, encoded := range tests {
+		match, _, err := hasher.Verify("pass", encoded)
+		if match || err == nil {
+			t.Errorf("Verify(%q) = (%v, %v), want an error", encoded, match, err)
+		}
+	}
+}
+
+func TestHasherReused(t *testing.T) {
+	hasher := NewHasher(testArgon2id, Bcrypt{Cost: bcrypt.MinCost})
+	history := []string{
+		mustHash(t, testArgon2id, "Lama#111"),
+		mustHash(t, Bcrypt{Cost: bcrypt.MinCost}, "Lama#222"),
+		"hash-rusak",
+	}
+
+	tests := []struct {
+		pass string
+		want bool
+	}{
+		{"Lama#111", true},
+		{"Lama#222", true},
+		{"Baru#333", false},
+	}
+	for _, tt := range tests {
+		if got := hasher.Reused(tt.pass, history); got != tt.want {
+			t.Errorf("Reused(%q) = %v, want %v", tt.pass, got, tt.want)
+		}
+	}
+}
+
+func TestHasherHashUsesDefault(t *testing.T) {
+	hasher := NewHasher(testArgon2id, Bcrypt{Cost: bcrypt.MinCost})
+	hash, err := hasher.Hash("Rahasia#123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !testArgon2id.Identifies(hash) || testArgon2id.Outdated(hash) {
+		t.Errorf("Hash() = %q, want an argon2id hash with current parameters", hash)
+	}
+	other, _ := hasher.Hash("Rahasia#123")
+	if hash == other {
+		t.Error("two hashes of the same password are equal, salt is not random")
+	}
+}
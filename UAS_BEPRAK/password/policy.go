@@ -0,0 +1,162 @@
+// Package password berisi aturan password: policy kekuatan, pemeriksaan daftar password
+// yang bocor dan riwayat password lama.
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kode pelanggaran policy, dipakai sebagai code pada respons 422
+const (
+	CodeTooShort     = "password_too_short"
+	CodeTooLong      = "password_too_long"
+	CodeMissingUpper = "password_missing_upper"
+	CodeMissingLower = "password_missing_lower"
+	CodeMissingDigit = "password_missing_digit"
+	CodeMissingSym   = "password_missing_symbol"
+	CodePersonalInfo = "password_contains_personal_info"
+	CodeReused       = "password_reused"
+	CodeBreached     = "password_breached"
+)
+
+// Panjang maksimal dalam byte, batas input bcrypt yang masih bisa dipilih sebagai algoritma
+const MaxLength = 72
+
+// Panjang minimal bagian data pribadi yang diperiksa, agar nama pendek seperti "Al" tidak
+// membuat banyak password ditolak
+const minPersonalLength = 3
+
+// Violation adalah satu aturan policy yang tidak dipenuhi
+type Violation struct {
+	Code    string
+	Message string
+}
+
+// Policy adalah aturan kekuatan password
+type Policy struct {
+	MinLength            int
+	RequireUpper         bool
+	RequireLower         bool
+	RequireDigit         bool
+	RequireSymbol        bool
+	DisallowPersonalInfo bool
+	// History adalah jumlah password terakhir (termasuk yang sedang dipakai) yang tidak boleh dipakai lagi
+	History int
+	// Breached berisi password yang diketahui bocor, nil berarti tidak diperiksa
+	Breached *BreachedList
+}
+
+// DefaultPolicy adalah policy bawaan dengan daftar password bocor bawaan
+func DefaultPolicy() *Policy {
+	return &Policy{
+		MinLength:            8,
+		RequireUpper:         true,
+		RequireLower:         true,
+		RequireDigit:         true,
+		DisallowPersonalInfo: true,
+		History:              5,
+		Breached:             BundledBreachedList(),
+	}
+}
+
+// Check memeriksa password terhadap policy. personal berisi data user (username, nama,
+// email) yang tidak boleh muncul di dalam password. Riwayat diperiksa terpisah dengan
+// Hasher.Reused karena membutuhkan hash yang tersimpan.
+func (p *Policy) Check(password string, personal ...string) []Violation {
+	violations := []Violation{}
+	add := func(code, format string, args ...interface{}) {
+		violations = append(violations, Violation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		add(CodeTooShort, "must be at least %d characters", p.MinLength)
+	}
+	if len(password) > MaxLength {
+		add(CodeTooLong, "must be at most %d bytes", MaxLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		add(CodeMissingUpper, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		add(CodeMissingLower, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		add(CodeMissingDigit, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		add(CodeMissingSym, "must contain a symbol")
+	}
+
+	if p.DisallowPersonalInfo && containsPersonalInfo(password, personal) {
+		add(CodePersonalInfo, "must not contain your username, name or email")
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		add(CodeBreached, "has appeared in a data breach, choose a different password")
+	}
+	return violations
+}
+
+// containsPersonalInfo memeriksa (tanpa membedakan huruf besar) apakah password memuat
+// username, salah satu kata pada nama, atau bagian sebelum @ pada email
+func containsPersonalInfo(password string, personal []string) bool {
+	lowered := strings.ToLower(password)
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if at := strings.Index(value, "@"); at >= 0 {
+			value = value[:at]
+		}
+		parts := strings.FieldsFunc(value, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		parts = append(parts, value)
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) >= minPersonalLength && strings.Contains(lowered, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NextHistory mengembalikan riwayat hash setelah password diganti: hash yang sedang dipakai
+// masuk paling depan, dan hanya History-1 hash yang disimpan karena hash baru sudah ada di pass
+func (p *Policy) NextHistory(current string, history []string) []string {
+	keep := p.History - 1
+	if keep <= 0 || current == "" {
+		return []string{}
+	}
+	next := append([]string{current}, history...)
+	if len(next) > keep {
+		next = next[:keep]
+	}
+	return next
+}
+
+// RecentHashes mengembalikan hash yang tidak boleh dipakai lagi sesuai History
+func (p *Policy) RecentHashes(current string, history []string) []string {
+	if p.History <= 0 {
+		return nil
+	}
+	recent := append([]string{current}, history...)
+	if len(recent) > p.History {
+		recent = recent[:p.History]
+	}
+	return recent
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AuditRange membatasi audit log berdasarkan created_at, nilai nol berarti tidak dibatasi
+type AuditRange struct {
+	From time.Time // Inklusif
+	To   time.Time // Eksklusif
+}
+
+// AuditRepository menyimpan audit log. Audit log hanya ditambah, tidak pernah diubah atau dihapus.
+type AuditRepository interface {
+	Record(ctx context.Context, entry *models.AuditLog) error
+	List(ctx context.Context, r AuditRange, q ListQuery) (*Page[models.AuditLog], error)
+}
+
+// mongoAuditRepository adalah AuditRepository di koleksi "audit_logs"
+type mongoAuditRepository struct {
+	mongoCollection[models.AuditLog]
+}
+
+func NewMongoAuditRepository(db *mongo.Database) AuditRepository {
+	return &mongoAuditRepository{newMongoCollection[models.AuditLog](db, "audit_logs")}
+}
+
+func (r *mongoAuditRepository) Record(ctx context.Context, entry *models.AuditLog) error {
+	return r.insert(ctx, entry)
+}
+
+func (r *mongoAuditRepository) List(ctx context.Context, rng AuditRange, q ListQuery) (*Page[models.AuditLog], error) {
+	createdAt := bson.M{}
+	if !rng.From.IsZero() {
+		createdAt["$gte"] = rng.From
+	}
+	if !rng.To.IsZero() {
+		createdAt["$lt"] = rng.To
+	}
+	base := bson.M{}
+	if len(createdAt) > 0 {
+		base["created_at"] = createdAt
+	}
+	return r.list(ctx, base, q)
+}
+
+// memoryAuditRepository adalah AuditRepository di memori
+type memoryAuditRepository struct {
+	docs *memoryCollection[models.AuditLog]
+}
+
+func NewMemoryAuditRepository() AuditRepository {
+	return &memoryAuditRepository{newMemoryCollection[models.AuditLog]()}
+}
+
+func (r *memoryAuditRepository) Record(ctx context.Context, entry *models.AuditLog) error {
+	return r.docs.insert(entry.ID, *entry)
+}
+
+func (r *memoryAuditRepository) List(ctx context.Context, rng AuditRange, q ListQuery) (*Page[models.AuditLog], error) {
+	return r.docs.list(func(entry *models.AuditLog) bool {
+		createdAt := entry.CreatedAt.Time()
+		if !rng.From.IsZero() && createdAt.Before(rng.From) {
+			return false
+		}
+		return rng.To.IsZero() || createdAt.Before(rng.To)
+	}, q)
+}
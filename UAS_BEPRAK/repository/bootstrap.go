@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EnsureAdminRole memastikan role admin memiliki permission "*". Route admin memeriksa
+// permission role, sehingga tanpa ini role lama yang belum punya permission tidak bisa
+// mengakses API apa pun, termasuk API untuk memberi permission.
+// Mengembalikan true jika permission baru ditambahkan.
+func EnsureAdminRole(ctx context.Context, roles RoleRepository, id primitive.ObjectID) (bool, error) {
+	role, err := roles.FindByID(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if role.HasPermission(models.PermAll) {
+		return false, nil
+	}
+	permissions := append([]string{models.PermAll}, role.Permissions...)
+	if err := roles.Update(ctx, id, AnyVersion, Fields{"permissions": permissions}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sortIndex adalah index untuk filter atau sort satu field.
+// Diakhiri _id karena paginasi cursor selalu mengurutkan dengan _id.
+func sortIndex(field string) mongo.IndexModel {
+	return mongo.IndexModel{Keys: bson.D{{Key: field, Value: 1}, {Key: "_id", Value: 1}}}
+}
+
+// ttlIndex menghapus dokumen otomatis saat waktu pada field sudah lewat
+func ttlIndex(field string) mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys:    bson.D{{Key: field, Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+}
+
+// activeUniqueIndex menjamin nilai field unik di antara dokumen yang belum di-soft delete.
+// Partial index tidak bisa memakai {deleted_at: {$exists: false}}, sehingga deleted_at ikut
+// menjadi key: semua dokumen aktif bernilai null dan saling bentrok, sedangkan dokumen di trash
+// memiliki waktu hapus masing-masing. Nilai kosong pada data lama tidak ikut diindex.
+func activeUniqueIndex(field string) mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys: bson.D{{Key: field, Value: 1}, {Key: deletedAtField, Value: 1}},
+		Options: options.Index().
+			SetName(field + "_active_unique").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{field: bson.M{"$gt": ""}}),
+	}
+}
+
+// searchCopyIndex adalah index salinan huruf kecil untuk pencarian awalan, lihat prefixFilter
+func searchCopyIndex(field string) mongo.IndexModel {
+	return mongo.IndexModel{Keys: bson.D{{Key: searchCopyPath(field), Value: 1}}}
+}
+
+// textIndex adalah index teks untuk pencarian dengan bobot yang sama seperti skor relevansi
+func textIndex(name string, fields []searchField) mongo.IndexModel {
+	keys := bson.D{}
+	weights := bson.D{}
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field.Name, Value: "text"})
+		weights = append(weights, bson.E{Key: field.Name, Value: int(field.Weight)})
+	}
+	return mongo.IndexModel{
+		Keys: keys,
+		Options: options.Index().
+			SetName(name).
+			SetWeights(weights).
+			SetDefaultLanguage("none"),
+	}
+}
+
+// collectionIndexes adalah index yang dibutuhkan repository per koleksi
+var collectionIndexes = map[string][]mongo.IndexModel{
+	"users": {
+		sortIndex("role_id"),
+		sortIndex("jenis_user_id"),
+		sortIndex("jenis_kelamin"),
+		sortIndex("username"),
+		sortIndex("nm_user"),
+		sortIndex("email"),
+		sortIndex("created_at"),
+		activeUniqueIndex("username"),
+		activeUniqueIndex("email"),
+		searchCopyIndex("username"),
+		searchCopyIndex("nm_user"),
+		searchCopyIndex("email"),
+		textIndex("users_search", userSearchFields),
+		sortIndex(deletedAtField),
+	},
+	"moduls": {
+		sortIndex("kategori_modul"),
+		sortIndex("name"),
+		sortIndex("created_at"),
+		searchCopyIndex("name"),
+		textIndex("moduls_search", modulSearchFields),
+		sortIndex(deletedAtField),
+	},
+	"roles": {
+		sortIndex("name"),
+		sortIndex(deletedAtField),
+	},
+	"kategori_modul": {
+		sortIndex("name"),
+		sortIndex(deletedAtField),
+	},
+	"jenis_users": {
+		sortIndex("nm_jenis_user"),
+		sortIndex(deletedAtField),
+	},
+	"audit_logs": {
+		sortIndex("actor"),
+		sortIndex("action"),
+		sortIndex("route"),
+		{Keys: bson.D{{Key: "entity", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "_id", Value: 1}}},
+		sortIndex("created_at"),
+	},
+	"sessions": {
+		{Keys: bson.D{{Key: "refresh_token_hash", Value: 1}}},
+		{Keys: bson.D{{Key: "previous_hashes", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		ttlIndex("expires_at"),
+	},
+	"login_throttles": {
+		ttlIndex("expires_at"),
+	},
+	"login_records": {
+		sortIndex("username"),
+		sortIndex("user_id"),
+		sortIndex("ip"),
+		sortIndex("result"),
+		sortIndex("created_at"),
+		ttlIndex("expires_at"),
+	},
+	"password_resets": {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		ttlIndex("expires_at"),
+	},
+	"oauth_clients": {
+		{Keys: bson.D{{Key: "client_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	"oauth_codes": {
+		{Keys: bson.D{{Key: "code_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		ttlIndex("expires_at"),
+	},
+	"oauth_consents": {
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "client_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	"modul_revisions":      revisionIndexes,
+	"jenis_user_revisions": revisionIndexes,
+}
+
+// revisionIndexes menjamin nomor revisi unik per dokumen dan dipakai untuk daftar revisi
+var revisionIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "entity_id", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	},
+	{Keys: bson.D{{Key: "entity_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+}
+
+// searchCopyCollections adalah koleksi yang menyimpan salinan huruf kecil field pencarian
+var searchCopyCollections = map[string][]searchField{
+	"users":  userSearchFields,
+	"moduls": modulSearchFields,
+}
+
+// EnsureIndexes membuat index MongoDB yang dibutuhkan repository dan mengisi salinan huruf kecil
+// untuk dokumen lama. Aman dipanggil setiap start karena CreateMany tidak mengubah index yang
+// sudah ada dan hanya dokumen tanpa salinan yang diisi.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	for collection, indexes := range collectionIndexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes); err != nil {
+			return err
+		}
+	}
+	for collection, fields := range searchCopyCollections {
+		if err := backfillSearchCopies(ctx, db.Collection(collection), fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillSearchCopies mengisi searchCopyField pada dokumen yang dibuat sebelum salinan ada.
+// $toLower hanya mengubah huruf ASCII; huruf lain disamakan dengan strings.ToLower begitu
+// dokumen disimpan ulang lewat repository.
+func backfillSearchCopies(ctx context.Context, coll *mongo.Collection, fields []searchField) error {
+	set := bson.M{}
+	for _, field := range fields {
+		if field.Prefix {
+			set[searchCopyPath(field.Name)] = bson.M{"$toLower": bson.M{"$ifNull": bson.A{"$" + field.Name, ""}}}
+		}
+	}
+	_, err := coll.UpdateMany(ctx,
+		bson.M{searchCopyField: bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: set}}},
+	)
+	return err
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// JenisUserRepository mengelola data jenis user beserta template modulnya
+type JenisUserRepository interface {
+	Referrer
+	Trash[models.JenisUser]
+
+	Create(ctx context.Context, jenisUser *models.JenisUser) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.JenisUser, error)
+	List(ctx context.Context, q ListQuery) (*Page[models.JenisUser], error)
+	// Update men-$set fields dan menambahkan templates ke template_modul tanpa duplikasi.
+	// Seperti ModulRepository.Update, update hanya dijalankan jika versinya sama dengan version.
+	Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields, templates []models.TemplateModul) error
+	// RemoveTemplateModul mengembalikan false jika modul tidak ada di template_modul
+	RemoveTemplateModul(ctx context.Context, id, modulID primitive.ObjectID) (bool, error)
+}
+
+// mongoJenisUserRepository adalah JenisUserRepository di koleksi "jenis_users"
+type mongoJenisUserRepository struct {
+	mongoCollection[models.JenisUser]
+}
+
+func NewMongoJenisUserRepository(db *mongo.Database) JenisUserRepository {
+	return &mongoJenisUserRepository{newMongoCollection[models.JenisUser](db, "jenis_users").withSoftDelete().withVersion()}
+}
+
+func (r *mongoJenisUserRepository) Create(ctx context.Context, jenisUser *models.JenisUser) error {
+	return r.insert(ctx, jenisUser)
+}
+
+func (r *mongoJenisUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.JenisUser, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoJenisUserRepository) List(ctx context.Context, q ListQuery) (*Page[models.JenisUser], error) {
+	return r.list(ctx, bson.M{}, q)
+}
+
+func (r *mongoJenisUserRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields, templates []models.TemplateModul) error {
+	update := bson.M{"$set": bson.M(fields)}
+	if len(templates) > 0 {
+		update["$addToSet"] = bson.M{"template_modul": bson.M{"$each": templates}}
+	}
+	return r.updateVersion(ctx, id, version, update)
+}
+
+func (r *mongoJenisUserRepository) RemoveTemplateModul(ctx context.Context, id, modulID primitive.ObjectID) (bool, error) {
+	// Modul ikut di filter agar versi tidak naik jika tidak ada yang dihapus
+	_, err := r.updateOne(ctx, bson.M{"_id": id, "template_modul.modul_id": modulID}, bson.M{
+		"$pull": bson.M{"template_modul": bson.M{"modul_id": modulID}},
+	})
+	if err == ErrNotFound {
+		// Bedakan jenis user yang tidak ada dengan modul yang tidak ada di template
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// memoryJenisUserRepository adalah JenisUserRepository di memori
+type memoryJenisUserRepository struct {
+	docs *memoryCollection[models.JenisUser]
+	Referrer
+	Trash[models.JenisUser]
+}
+
+func NewMemoryJenisUserRepository() JenisUserRepository {
+	docs := newMemoryCollection[models.JenisUser]().withSoftDelete().withVersion()
+	return &memoryJenisUserRepository{docs: docs, Referrer: docs, Trash: docs}
+}
+
+func (r *memoryJenisUserRepository) Create(ctx context.Context, jenisUser *models.JenisUser) error {
+	return r.docs.insert(jenisUser.ID, *jenisUser)
+}
+
+func (r *memoryJenisUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.JenisUser, error) {
+	return r.docs.get(id)
+}
+
+func (r *memoryJenisUserRepository) List(ctx context.Context, q ListQuery) (*Page[models.JenisUser], error) {
+	return r.docs.list(nil, q)
+}
+
+func (r *memoryJenisUserRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields, templates []models.TemplateModul) error {
+	return r.docs.updateVersion(id, version, func(jenisUser *models.JenisUser) error {
+		if err := setFields(jenisUser, fields); err != nil {
+			return err
+		}
+		for _, tmpl := range templates {
+			if !hasTemplateModul(jenisUser.TemplateModul, tmpl.ModulID) {
+				jenisUser.TemplateModul = append(jenisUser.TemplateModul, tmpl)
+			}
+		}
+		return nil
+	})
+}
+
+// errNotInTemplate membatalkan update memori agar versi tidak naik
+var errNotInTemplate = errors.New("modul not in template_modul")
+
+func (r *memoryJenisUserRepository) RemoveTemplateModul(ctx context.Context, id, modulID primitive.ObjectID) (bool, error) {
+	err := r.docs.update(id, func(jenisUser *models.JenisUser) error {
+		if !hasTemplateModul(jenisUser.TemplateModul, modulID) {
+			return errNotInTemplate
+		}
+		kept := []models.TemplateModul{}
+		for _, tmpl := range jenisUser.TemplateModul {
+			if tmpl.ModulID != modulID {
+				kept = append(kept, tmpl)
+			}
+		}
+		jenisUser.TemplateModul = kept
+		return nil
+	})
+	if err == errNotInTemplate {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func hasTemplateModul(templates []models.TemplateModul, modulID primitive.ObjectID) bool {
+	for _, tmpl := range templates {
+		if tmpl.ModulID == modulID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/models"
+)
+
+func TestRemoveTemplateModul(t *testing.T) {
+	ctx := context.Background()
+	modulA, modulB := primitive.NewObjectID(), primitive.NewObjectID()
+
+	tests := []struct {
+		name        string
+		id          func(primitive.ObjectID) primitive.ObjectID
+		modulID     primitive.ObjectID
+		wantRemoved bool
+		wantErr     error
+		wantVersion int64
+		wantModuls  int
+	}{
+		{"modul ada di template", func(id primitive.ObjectID) primitive.ObjectID { return id }, modulA, true, nil, 1, 1},
+		{"modul tidak ada di template", func(id primitive.ObjectID) primitive.ObjectID { return id }, primitive.NewObjectID(), false, nil, 0, 2},
+		{"jenis user tidak ada", func(primitive.ObjectID) primitive.ObjectID { return primitive.NewObjectID() }, modulA, false, ErrNotFound, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryJenisUserRepository()
+			jenisUser := models.JenisUser{
+				ID:            primitive.NewObjectID(),
+				NmJenisUser:   "mhs",
+				TemplateModul: []models.TemplateModul{{ModulID: modulA}, {ModulID: modulB}},
+			}
+			if err := repo.Create(ctx, &jenisUser); err != nil {
+				t.Fatal(err)
+			}
+
+			removed, err := repo.RemoveTemplateModul(ctx, tt.id(jenisUser.ID), tt.modulID)
+			if err != tt.wantErr || removed != tt.wantRemoved {
+				t.Fatalf("RemoveTemplateModul() = (%v, %v), want (%v, %v)", removed, err, tt.wantRemoved, tt.wantErr)
+			}
+
+			got, err := repo.FindByID(ctx, jenisUser.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Version != tt.wantVersion || len(got.TemplateModul) != tt.wantModuls {
+				t.Errorf("version = %d, template_modul = %d, want %d, %d", got.Version, len(got.TemplateModul), tt.wantVersion, tt.wantModuls)
+			}
+		})
+	}
+}
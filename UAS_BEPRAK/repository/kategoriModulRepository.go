@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// KategoriModulRepository mengelola data kategori modul
+type KategoriModulRepository interface {
+	Trash[models.KategoriModul]
+
+	Create(ctx context.Context, kategori *models.KategoriModul) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.KategoriModul, error)
+	List(ctx context.Context, q ListQuery) (*Page[models.KategoriModul], error)
+	// Update mengembalikan ErrVersionConflict jika version bukan AnyVersion dan berbeda dengan versi dokumen
+	Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error
+}
+
+// mongoKategoriModulRepository adalah KategoriModulRepository di koleksi "kategori_modul"
+type mongoKategoriModulRepository struct {
+	mongoCollection[models.KategoriModul]
+}
+
+func NewMongoKategoriModulRepository(db *mongo.Database) KategoriModulRepository {
+	return &mongoKategoriModulRepository{newMongoCollection[models.KategoriModul](db, "kategori_modul").withSoftDelete().withVersion()}
+}
+
+func (r *mongoKategoriModulRepository) Create(ctx context.Context, kategori *models.KategoriModul) error {
+	return r.insert(ctx, kategori)
+}
+
+func (r *mongoKategoriModulRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.KategoriModul, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoKategoriModulRepository) List(ctx context.Context, q ListQuery) (*Page[models.KategoriModul], error) {
+	return r.list(ctx, bson.M{}, q)
+}
+
+func (r *mongoKategoriModulRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error {
+	return r.updateVersion(ctx, id, version, bson.M{"$set": bson.M(fields)})
+}
+
+// memoryKategoriModulRepository adalah KategoriModulRepository di memori
+type memoryKategoriModulRepository struct {
+	docs *memoryCollection[models.KategoriModul]
+	Trash[models.KategoriModul]
+}
+
+func NewMemoryKategoriModulRepository() KategoriModulRepository {
+	docs := newMemoryCollection[models.KategoriModul]().withSoftDelete().withVersion()
+	return &memoryKategoriModulRepository{docs: docs, Trash: docs}
+}
+
+func (r *memoryKategoriModulRepository) Create(ctx context.Context, kategori *models.KategoriModul) error {
+	return r.docs.insert(kategori.ID, *kategori)
+}
+
+func (r *memoryKategoriModulRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.KategoriModul, error) {
+	return r.docs.get(id)
+}
+
+func (r *memoryKategoriModulRepository) List(ctx context.Context, q ListQuery) (*Page[models.KategoriModul], error) {
+	return r.docs.list(nil, q)
+}
+
+func (r *memoryKategoriModulRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error {
+	return r.docs.updateVersion(id, version, func(kategori *models.KategoriModul) error { return setFields(kategori, fields) })
+}
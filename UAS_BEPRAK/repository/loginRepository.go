@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoginThrottleRepository menyimpan penghitung login gagal per username dan per IP.
+// Penghitung yang sudah melewati expires_at dianggap tidak ada.
+type LoginThrottleRepository interface {
+	Find(ctx context.Context, key string) (*models.LoginThrottle, error)
+	// RecordFailure menambah penghitung secara atomik dan memperpanjang expires_at sampai
+	// now+window. Penghitung yang sudah kedaluwarsa dimulai lagi dari satu.
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginThrottle, error)
+	// Block mengisi next_attempt_at dan/atau locked_until (nil berarti tidak diubah).
+	// expires_at ikut dimundurkan agar penghitung tidak hilang sebelum blokir berakhir.
+	Block(ctx context.Context, key string, nextAttemptAt, lockedUntil *time.Time) error
+	Delete(ctx context.Context, key string) error
+}
+
+// LoginRecordRepository menyimpan catatan percobaan login
+type LoginRecordRepository interface {
+	Record(ctx context.Context, record *models.LoginRecord) error
+	List(ctx context.Context, r AuditRange, q ListQuery) (*Page[models.LoginRecord], error)
+}
+
+// Batas percobaan ulang RecordFailure saat dua request membuat penghitung bersamaan
+const throttleRetries = 3
+
+// mongoLoginThrottleRepository adalah LoginThrottleRepository di koleksi "login_throttles"
+type mongoLoginThrottleRepository struct {
+	mongoCollection[models.LoginThrottle]
+}
+
+func NewMongoLoginThrottleRepository(db *mongo.Database) LoginThrottleRepository {
+	return &mongoLoginThrottleRepository{newMongoCollection[models.LoginThrottle](db, "login_throttles")}
+}
+
+// active membatasi filter ke penghitung yang belum kedaluwarsa, karena monitor TTL
+// MongoDB hanya menghapus dokumen sekitar sekali per menit
+func active(key string, now time.Time) bson.M {
+	return bson.M{"_id": key, "expires_at": bson.M{"$gt": primitive.NewDateTimeFromTime(now)}}
+}
+
+func (r *mongoLoginThrottleRepository) Find(ctx context.Context, key string) (*models.LoginThrottle, error) {
+	return r.findOne(ctx, active(key, timeNow()))
+}
+
+func (r *mongoLoginThrottleRepository) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	at := primitive.NewDateTimeFromTime(now)
+	expiresAt := primitive.NewDateTimeFromTime(now.Add(window))
+	for attempt := 0; attempt < throttleRetries; attempt++ {
+		var throttle models.LoginThrottle
+		err := r.coll.FindOneAndUpdate(ctx, active(key, now),
+			bson.M{
+				"$inc": bson.M{"failures": 1},
+				"$set": bson.M{"last_failure_at": at},
+				"$max": bson.M{"expires_at": expiresAt},
+			},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&throttle)
+		if err == nil {
+			return &throttle, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+
+		// Belum ada penghitung aktif, ganti dokumen kedaluwarsa (jika ada) dengan yang baru
+		throttle = models.LoginThrottle{Key: key, Failures: 1, LastFailureAt: at, ExpiresAt: expiresAt}
+		_, err = r.coll.ReplaceOne(ctx,
+			bson.M{"_id": key, "expires_at": bson.M{"$lte": at}},
+			throttle,
+			options.Replace().SetUpsert(true),
+		)
+		if err == nil {
+			return &throttle, nil
+		}
+		// Request lain baru saja membuat penghitung, ulangi dengan $inc
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+	}
+	return nil, ErrDuplicate
+}
+
+func (r *mongoLoginThrottleRepository) Block(ctx context.Context, key string, nextAttemptAt, lockedUntil *time.Time) error {
+	set, extend := bson.M{}, bson.M{}
+	if nextAttemptAt != nil {
+		set["next_attempt_at"] = primitive.NewDateTimeFromTime(*nextAttemptAt)
+		extend["expires_at"] = primitive.NewDateTimeFromTime(*nextAttemptAt)
+	}
+	if lockedUntil != nil {
+		set["locked_until"] = primitive.NewDateTimeFromTime(*lockedUntil)
+		extend["expires_at"] = primitive.NewDateTimeFromTime(*lockedUntil)
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	_, err := r.coll.UpdateOne(ctx, bson.M{"_id": key}, bson.M{"$set": set, "$max": extend})
+	return err
+}
+
+func (r *mongoLoginThrottleRepository) Delete(ctx context.Context, key string) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// mongoLoginRecordRepository adalah LoginRecordRepository di koleksi "login_records"
+type mongoLoginRecordRepository struct {
+	mongoCollection[models.LoginRecord]
+}
+
+func NewMongoLoginRecordRepository(db *mongo.Database) LoginRecordRepository {
+	return &mongoLoginRecordRepository{newMongoCollection[models.LoginRecord](db, "login_records")}
+}
+
+func (r *mongoLoginRecordRepository) Record(ctx context.Context, record *models.LoginRecord) error {
+	return r.insert(ctx, record)
+}
+
+func (r *mongoLoginRecordRepository) List(ctx context.Context, rng AuditRange, q ListQuery) (*Page[models.LoginRecord], error) {
+	createdAt := bson.M{}
+	if !rng.From.IsZero() {
+		createdAt["$gte"] = rng.From
+	}
+	if !rng.To.IsZero() {
+		createdAt["$lt"] = rng.To
+	}
+	base := bson.M{}
+	if len(createdAt) > 0 {
+		base["created_at"] = createdAt
+	}
+	return r.list(ctx, base, q)
+}
+
+// memoryLoginThrottleRepository adalah LoginThrottleRepository di memori
+type memoryLoginThrottleRepository struct {
+	mu   sync.Mutex
+	docs map[string]models.LoginThrottle
+}
+
+func NewMemoryLoginThrottleRepository() LoginThrottleRepository {
+	return &memoryLoginThrottleRepository{docs: map[string]models.LoginThrottle{}}
+}
+
+// activeLocked mengembalikan penghitung yang belum kedaluwarsa, mu harus sudah dikunci
+func (r *memoryLoginThrottleRepository) activeLocked(key string, now time.Time) (models.LoginThrottle, bool) {
+	throttle, ok := r.docs[key]
+	if !ok || !throttle.ExpiresAt.Time().After(now) {
+		return models.LoginThrottle{}, false
+	}
+	return throttle, true
+}
+
+func (r *memoryLoginThrottleRepository) Find(ctx context.Context, key string) (*models.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	throttle, ok := r.activeLocked(key, timeNow())
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &throttle, nil
+}
+
+func (r *memoryLoginThrottleRepository) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	throttle, ok := r.activeLocked(key, now)
+	if !ok {
+		throttle = models.LoginThrottle{Key: key}
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = primitive.NewDateTimeFromTime(now)
+	if expiresAt := now.Add(window); expiresAt.After(throttle.ExpiresAt.Time()) {
+		throttle.ExpiresAt = primitive.NewDateTimeFromTime(expiresAt)
+	}
+	r.docs[key] = throttle
+	return &throttle, nil
+}
+
+func (r *memoryLoginThrottleRepository) Block(ctx context.Context, key string, nextAttemptAt, lockedUntil *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	throttle, ok := r.docs[key]
+	if !ok {
+		return nil
+	}
+	for _, until := range []*time.Time{nextAttemptAt, lockedUntil} {
+		if until != nil && until.After(throttle.ExpiresAt.Time()) {
+			throttle.ExpiresAt = primitive.NewDateTimeFromTime(*until)
+		}
+	}
+	if nextAttemptAt != nil {
+		at := primitive.NewDateTimeFromTime(*nextAttemptAt)
+		throttle.NextAttemptAt = &at
+	}
+	if lockedUntil != nil {
+		at := primitive.NewDateTimeFromTime(*lockedUntil)
+		throttle.LockedUntil = &at
+	}
+	r.docs[key] = throttle
+	return nil
+}
+
+func (r *memoryLoginThrottleRepository) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.docs, key)
+	return nil
+}
+
+// memoryLoginRecordRepository adalah LoginRecordRepository di memori
+type memoryLoginRecordRepository struct {
+	docs *memoryCollection[models.LoginRecord]
+}
+
+func NewMemoryLoginRecordRepository() LoginRecordRepository {
+	return &memoryLoginRecordRepository{newMemoryCollection[models.LoginRecord]()}
+}
+
+func (r *memoryLoginRecordRepository) Record(ctx context.Context, record *models.LoginRecord) error {
+	return r.docs.insert(record.ID, *record)
+}
+
+func (r *memoryLoginRecordRepository) List(ctx context.Context, rng AuditRange, q ListQuery) (*Page[models.LoginRecord], error) {
+	now := timeNow()
+	return r.docs.list(func(record *models.LoginRecord) bool {
+		createdAt := record.CreatedAt.Time()
+		if !record.ExpiresAt.Time().After(now) {
+			return false
+		}
+		if !rng.From.IsZero() && createdAt.Before(rng.From) {
+			return false
+		}
+		return rng.To.IsZero() || createdAt.Before(rng.To)
+	}, q)
+}
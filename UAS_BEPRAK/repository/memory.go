@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryCollection menyimpan dokumen di memori dengan urutan insert.
+// Dokumen selalu di-clone saat disimpan dan dibaca agar tidak berbagi slice.
+type memoryCollection[T any] struct {
+	mu         sync.RWMutex
+	ids        []primitive.ObjectID
+	docs       map[primitive.ObjectID]T
+	softDelete bool
+	versioned  bool
+}
+
+func newMemoryCollection[T any]() *memoryCollection[T] {
+	return &memoryCollection[T]{docs: map[primitive.ObjectID]T{}}
+}
+
+// withSoftDelete membuat get, filter, update dan turunannya mengabaikan dokumen di trash
+func (m *memoryCollection[T]) withSoftDelete() *memoryCollection[T] {
+	m.softDelete = true
+	return m
+}
+
+// inTrash mengecek apakah dokumen sudah di-soft delete (deleted_at terisi)
+func (m *memoryCollection[T]) inTrash(doc T) (bool, error) {
+	if !m.softDelete {
+		return false, nil
+	}
+	fields, err := toDocument(doc)
+	if err != nil {
+		return false, err
+	}
+	return fields[deletedAtField] != nil, nil
+}
+
+func (m *memoryCollection[T]) insert(id primitive.ObjectID, doc T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.docs[id]; exists {
+		return ErrDuplicate
+	}
+	cloned, err := clone(doc)
+	if err != nil {
+		return err
+	}
+	m.ids = append(m.ids, id)
+	m.docs[id] = cloned
+	return nil
+}
+
+func (m *memoryCollection[T]) get(id primitive.ObjectID) (*T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	doc, ok := m.docs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if deleted, err := m.inTrash(doc); err != nil || deleted {
+		return nil, notFoundOr(err)
+	}
+	cloned, err := clone(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &cloned, nil
+}
+
+// remove menghapus dokumen secara permanen, ErrNotFound jika tidak ada
+func (m *memoryCollection[T]) remove(id primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.docs[id]; !exists {
+		return ErrNotFound
+	}
+	delete(m.docs, id)
+	for i, existing := range m.ids {
+		if existing == id {
+			m.ids = append(m.ids[:i], m.ids[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// findFirst mengembalikan dokumen pertama yang cocok dengan match
+func (m *memoryCollection[T]) findFirst(match func(*T) bool) (*T, error) {
+	docs, err := m.filter(match)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrNotFound
+	}
+	return &docs[0], nil
+}
+
+// filter mengembalikan semua dokumen yang cocok, match nil berarti semua dokumen
+func (m *memoryCollection[T]) filter(match func(*T) bool) ([]T, error) {
+	return m.scan(match, false)
+}
+
+// scan seperti filter, tetapi hanya mengembalikan dokumen di trash jika trashed
+func (m *memoryCollection[T]) scan(match func(*T) bool, trashed bool) ([]T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	docs := []T{}
+	for _, id := range m.ids {
+		doc := m.docs[id]
+		if deleted, err := m.inTrash(doc); err != nil {
+			return nil, err
+		} else if deleted != trashed {
+			continue
+		}
+		if match != nil && !match(&doc) {
+			continue
+		}
+		cloned, err := clone(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, cloned)
+	}
+	return docs, nil
+}
+
+// update menjalankan fn terhadap salinan dokumen lalu menyimpannya jika fn tidak error
+func (m *memoryCollection[T]) update(id primitive.ObjectID, fn func(*T) error) error {
+	return m.updateVersion(id, AnyVersion, fn)
+}
+
+// updateVersion seperti update, tetapi mengembalikan ErrVersionConflict jika versi dokumen
+// berbeda dengan version (kecuali AnyVersion)
+func (m *memoryCollection[T]) updateVersion(id primitive.ObjectID, version int64, fn func(*T) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	doc, ok := m.docs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if deleted, err := m.inTrash(doc); err != nil || deleted {
+		return notFoundOr(err)
+	}
+	if version != AnyVersion {
+		fields, err := toDocument(doc)
+		if err != nil {
+			return err
+		}
+		if versionOf(fields) != version {
+			return ErrVersionConflict
+		}
+	}
+	return m.apply(id, doc, fn)
+}
+
+// updateWhere menjalankan fn pada setiap dokumen yang cocok dan mengembalikan jumlahnya
+func (m *memoryCollection[T]) updateWhere(match func(*T) bool, fn func(*T) error) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	for _, id := range m.ids {
+		doc := m.docs[id]
+		if deleted, err := m.inTrash(doc); err != nil {
+			return count, err
+		} else if deleted || !match(&doc) {
+			continue
+		}
+		if err := m.apply(id, doc, fn); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (m *memoryCollection[T]) apply(id primitive.ObjectID, doc T, fn func(*T) error) error {
+	cloned, err := clone(doc)
+	if err != nil {
+		return err
+	}
+	if err := fn(&cloned); err != nil {
+		return err
+	}
+	if m.versioned {
+		fields, err := toDocument(cloned)
+		if err != nil {
+			return err
+		}
+		if err := setFields(&cloned, Fields{versionField: versionOf(fields) + 1}); err != nil {
+			return err
+		}
+	}
+	m.docs[id] = cloned
+	return nil
+}
+
+// clone membuat salinan dokumen melalui encode/decode BSON
+func clone[T any](doc T) (T, error) {
+	var out T
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return out, err
+	}
+	err = bson.Unmarshal(raw, &out)
+	return out, err
+}
+
+// setFields menerapkan Fields (seperti $set) pada dokumen melalui representasi BSON-nya
+func setFields[T any](doc *T, fields Fields) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var m bson.M
+	if err := bson.Unmarshal(raw, &m); err != nil {
+		return err
+	}
+	for key, value := range fields {
+		m[key] = value
+	}
+	raw, err = bson.Marshal(m)
+	if err != nil {
+		return err
+	}
+	var out T
+	if err := bson.Unmarshal(raw, &out); err != nil {
+		return err
+	}
+	*doc = out
+	return nil
+}
+
+// list adalah padanan mongoCollection.list untuk data di memori
+func (m *memoryCollection[T]) list(match func(*T) bool, q ListQuery) (*Page[T], error) {
+	docs, err := m.filter(match)
+	if err != nil {
+		return nil, err
+	}
+	return paginate(docs, q)
+}
+
+// paginate menerapkan filter, sort dan paginasi ListQuery pada docs
+func paginate[T any](docs []T, q ListQuery) (*Page[T], error) {
+	q = q.normalize()
+
+	type entry struct {
+		doc    T
+		fields bson.M
+	}
+	entries := []entry{}
+	for _, doc := range docs {
+		fields, err := toDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		if matchFilter(fields, q.Filter) {
+			entries = append(entries, entry{doc, fields})
+		}
+	}
+
+	// compare mengurutkan berdasarkan field sort lalu _id, dibalik jika Desc
+	compare := func(value interface{}, id primitive.ObjectID, fields bson.M) int {
+		result := compareValues(value, lookupField(fields, q.Sort))
+		if result == 0 {
+			result = compareValues(id, fields["_id"])
+		}
+		if q.Desc {
+			result = -result
+		}
+		return result
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		id, _ := entries[i].fields["_id"].(primitive.ObjectID)
+		return compare(lookupField(entries[i].fields, q.Sort), id, entries[j].fields) < 0
+	})
+
+	start := (q.Page - 1) * q.Limit
+	if q.Cursor != "" {
+		cursor, err := decodeCursor(q.Cursor, q.Sort)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(entries), func(i int) bool {
+			return compare(cursor.Value, cursor.ID, entries[i].fields) < 0
+		})
+	}
+
+	items := []T{}
+	for i := start; i < len(entries) && i <= start+q.Limit; i++ {
+		items = append(items, entries[i].doc)
+	}
+	return newPage(items, int64(len(entries)), q)
+}
+
+// search adalah padanan mongoCollection.search tanpa textScore
+func (m *memoryCollection[T]) search(fields []searchField, q SearchQuery) ([]SearchHit[T], error) {
+	terms := searchTerms(q.Text)
+	hits := []SearchHit[T]{}
+	if len(terms) == 0 {
+		return hits, nil
+	}
+
+	docs, err := m.filter(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		values, err := toDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		if !matchFilter(values, q.Filter) {
+			continue
+		}
+		if score := scoreDocument(values, fields, terms); score > 0 {
+			hits = append(hits, SearchHit[T]{Item: doc, Score: score})
+		}
+	}
+	return rankHits(hits, searchLimit(q.Limit)), nil
+}
+
+// updateMatching mengembalikan id dokumen yang cocok lalu menjalankan fn padanya, kecuali dryRun.
+// Seperti updateManyIDs, dokumen di trash ikut diperbarui.
+func (m *memoryCollection[T]) updateMatching(match func(*T) bool, fn func(*T) error, dryRun bool) ([]primitive.ObjectID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := []primitive.ObjectID{}
+	for _, id := range m.ids {
+		doc := m.docs[id]
+		if !match(&doc) {
+			continue
+		}
+		ids = append(ids, id)
+		if dryRun {
+			continue
+		}
+		if err := m.apply(id, doc, fn); err != nil {
+			return ids, err
+		}
+	}
+	return ids, nil
+}
+
+// updateRaw menjalankan fn pada representasi BSON setiap dokumen. fn mengubah doc dan
+// mengembalikan true jika dokumen cocok; perubahan disimpan kecuali dryRun.
+func (m *memoryCollection[T]) updateRaw(fn func(doc bson.M) bool, dryRun bool) ([]primitive.ObjectID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := []primitive.ObjectID{}
+	for _, id := range m.ids {
+		doc, err := toDocument(m.docs[id])
+		if err != nil {
+			return ids, err
+		}
+		if !fn(doc) {
+			continue
+		}
+		ids = append(ids, id)
+		if dryRun {
+			continue
+		}
+		m.bumpVersion(doc)
+		var out T
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return ids, err
+		}
+		if err := bson.Unmarshal(raw, &out); err != nil {
+			return ids, err
+		}
+		m.docs[id] = out
+	}
+	return ids, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ModulRepository mengelola data modul
+type ModulRepository interface {
+	Referrer
+	Trash[models.Modul]
+
+	Create(ctx context.Context, modul *models.Modul) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Modul, error)
+	// FindByIDs mengembalikan modul yang ditemukan dengan urutan sesuai ids,
+	// id yang tidak ditemukan dilewati
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Modul, error)
+	List(ctx context.Context, q ListQuery) (*Page[models.Modul], error)
+	// Search mencari modul berdasarkan teks dan mengurutkannya sesuai relevansi
+	Search(ctx context.Context, q SearchQuery) ([]SearchHit[models.Modul], error)
+	// Update men-$set fields jika versi dokumen sama dengan version (AnyVersion untuk update
+	// tanpa syarat) dan menaikkan versinya. Mengembalikan ErrVersionConflict jika versinya berbeda.
+	Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error
+}
+
+// mongoModulRepository adalah ModulRepository di koleksi "moduls"
+type mongoModulRepository struct {
+	mongoCollection[models.Modul]
+}
+
+func NewMongoModulRepository(db *mongo.Database) ModulRepository {
+	return &mongoModulRepository{newMongoCollection[models.Modul](db, "moduls").withSoftDelete().withVersion().withSearchCopies(modulSearchFields)}
+}
+
+func (r *mongoModulRepository) Create(ctx context.Context, modul *models.Modul) error {
+	return r.insert(ctx, modul)
+}
+
+func (r *mongoModulRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Modul, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoModulRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Modul, error) {
+	moduls, err := r.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	return orderModuls(moduls, ids), nil
+}
+
+func (r *mongoModulRepository) List(ctx context.Context, q ListQuery) (*Page[models.Modul], error) {
+	return r.list(ctx, bson.M{}, q)
+}
+
+func (r *mongoModulRepository) Search(ctx context.Context, q SearchQuery) ([]SearchHit[models.Modul], error) {
+	return r.search(ctx, modulSearchFields, q)
+}
+
+func (r *mongoModulRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error {
+	return r.updateVersion(ctx, id, version, bson.M{"$set": bson.M(fields)})
+}
+
+// memoryModulRepository adalah ModulRepository di memori
+type memoryModulRepository struct {
+	docs *memoryCollection[models.Modul]
+	Referrer
+	Trash[models.Modul]
+}
+
+func NewMemoryModulRepository() ModulRepository {
+	docs := newMemoryCollection[models.Modul]().withSoftDelete().withVersion()
+	return &memoryModulRepository{docs: docs, Referrer: docs, Trash: docs}
+}
+
+func (r *memoryModulRepository) Create(ctx context.Context, modul *models.Modul) error {
+	return r.docs.insert(modul.ID, *modul)
+}
+
+func (r *memoryModulRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Modul, error) {
+	return r.docs.get(id)
+}
+
+func (r *memoryModulRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Modul, error) {
+	moduls := []models.Modul{}
+	for _, id := range ids {
+		modul, err := r.docs.get(id)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		moduls = append(moduls, *modul)
+	}
+	return moduls, nil
+}
+
+func (r *memoryModulRepository) List(ctx context.Context, q ListQuery) (*Page[models.Modul], error) {
+	return r.docs.list(nil, q)
+}
+
+func (r *memoryModulRepository) Search(ctx context.Context, q SearchQuery) ([]SearchHit[models.Modul], error) {
+	return r.docs.search(modulSearchFields, q)
+}
+
+func (r *memoryModulRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error {
+	return r.docs.updateVersion(id, version, func(modul *models.Modul) error { return setFields(modul, fields) })
+}
+
+// orderModuls mengurutkan hasil $in sesuai urutan ids
+func orderModuls(moduls []models.Modul, ids []primitive.ObjectID) []models.Modul {
+	byID := map[primitive.ObjectID]models.Modul{}
+	for _, modul := range moduls {
+		byID[modul.ID] = modul
+	}
+	ordered := []models.Modul{}
+	for _, id := range ids {
+		if modul, ok := byID[id]; ok {
+			ordered = append(ordered, modul)
+		}
+	}
+	return ordered
+}
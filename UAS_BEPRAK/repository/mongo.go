@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoCollection membungkus *mongo.Collection untuk satu tipe dokumen
+// dan menerjemahkan error driver menjadi error repository
+type mongoCollection[T any] struct {
+	coll         *mongo.Collection
+	softDelete   bool
+	versioned    bool
+	searchCopies []string // Field yang disalin dalam huruf kecil, lihat withSearchCopies
+}
+
+func newMongoCollection[T any](db *mongo.Database, name string) mongoCollection[T] {
+	return mongoCollection[T]{coll: db.Collection(name)}
+}
+
+// withSoftDelete membuat findOne, find, updateOne, list dan search mengabaikan dokumen di trash
+func (m mongoCollection[T]) withSoftDelete() mongoCollection[T] {
+	m.softDelete = true
+	return m
+}
+
+// scope menambahkan syarat deleted_at kosong pada filter, kecuali filter sudah menyebut deleted_at
+func (m mongoCollection[T]) scope(filter bson.M) bson.M {
+	if !m.softDelete {
+		return filter
+	}
+	if _, ok := filter[deletedAtField]; ok {
+		return filter
+	}
+	scoped := bson.M{deletedAtField: bson.M{"$exists": false}}
+	for key, value := range filter {
+		scoped[key] = value
+	}
+	return scoped
+}
+
+func (m mongoCollection[T]) insert(ctx context.Context, doc *T) error {
+	var document interface{} = doc
+	if len(m.searchCopies) > 0 {
+		fields, err := toDocument(doc)
+		if err != nil {
+			return err
+		}
+		fields[searchCopyField] = m.searchCopiesOf(fields)
+		document = fields
+	}
+	_, err := m.coll.InsertOne(ctx, document)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (m mongoCollection[T]) findOne(ctx context.Context, filter bson.M) (*T, error) {
+	var doc T
+	if err := m.coll.FindOne(ctx, m.scope(filter)).Decode(&doc); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (m mongoCollection[T]) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]T, error) {
+	cursor, err := m.coll.Find(ctx, m.scope(filter), opts...)
+	if err != nil {
+		return nil, err
+	}
+	docs := []T{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// updateOne mengembalikan ErrNotFound jika tidak ada dokumen yang cocok dengan filter
+func (m mongoCollection[T]) updateOne(ctx context.Context, filter, update bson.M) (*mongo.UpdateResult, error) {
+	result, err := m.coll.UpdateOne(ctx, m.scope(filter), m.incVersion(m.setSearchCopies(update)))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrNotFound
+	}
+	return result, nil
+}
+
+func (m mongoCollection[T]) setFields(ctx context.Context, filter bson.M, fields Fields) error {
+	_, err := m.updateOne(ctx, filter, bson.M{"$set": bson.M(fields)})
+	return err
+}
+
+// list mengembalikan satu halaman dokumen yang cocok dengan base dan filter pada ListQuery.
+// Urutan selalu ditambah _id agar cursor tetap stabil untuk nilai sort yang sama.
+func (m mongoCollection[T]) list(ctx context.Context, base bson.M, q ListQuery) (*Page[T], error) {
+	q = q.normalize()
+
+	filter := bson.M{}
+	for key, value := range base {
+		filter[key] = value
+	}
+	for key, value := range q.Filter {
+		filter[key] = value
+	}
+	filter = m.scope(filter)
+
+	total, err := m.coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	direction, op := 1, "$gt"
+	if q.Desc {
+		direction, op = -1, "$lt"
+	}
+	opts := options.Find().SetLimit(int64(q.Limit + 1))
+	if q.Sort == "_id" {
+		opts.SetSort(bson.D{{Key: "_id", Value: direction}})
+	} else {
+		opts.SetSort(bson.D{{Key: q.Sort, Value: direction}, {Key: "_id", Value: direction}})
+	}
+
+	if q.Cursor != "" {
+		cursor, err := decodeCursor(q.Cursor, q.Sort)
+		if err != nil {
+			return nil, err
+		}
+		after := bson.M{"_id": bson.M{op: cursor.ID}}
+		if q.Sort != "_id" {
+			after = bson.M{"$or": bson.A{
+				bson.M{q.Sort: bson.M{op: cursor.Value}},
+				bson.M{q.Sort: cursor.Value, "_id": bson.M{op: cursor.ID}},
+			}}
+		}
+		// $and dari filter pemanggil tetap berlaku bersama batas cursor
+		if existing, ok := filter["$and"]; ok {
+			filter["$and"] = bson.A{bson.M{"$and": existing}, after}
+		} else {
+			filter["$and"] = bson.A{after}
+		}
+	} else {
+		opts.SetSkip(int64((q.Page - 1) * q.Limit))
+	}
+
+	docs, err := m.find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(docs, total, q)
+}
+
+// search mengambil kandidat dari index teks ($text, cocok untuk kata utuh dan bentuk dasarnya)
+// dan dari awalan field yang ber-index, lalu mengurutkannya dengan skor relevansi yang sama
+// dengan implementasi memori ditambah textScore dari MongoDB. Substring dan fuzzy hanya
+// menambah skor kandidat, tidak dicari ke seluruh koleksi.
+func (m mongoCollection[T]) search(ctx context.Context, fields []searchField, q SearchQuery) ([]SearchHit[T], error) {
+	terms := searchTerms(q.Text)
+	if len(terms) == 0 {
+		return []SearchHit[T]{}, nil
+	}
+
+	withFilter := func(extra bson.M) bson.M {
+		filter := bson.M{}
+		for key, value := range q.Filter {
+			filter[key] = value
+		}
+		for key, value := range extra {
+			filter[key] = value
+		}
+		return m.scope(filter)
+	}
+
+	type candidate struct {
+		raw       bson.Raw
+		textScore float64
+	}
+	candidates := map[string]*candidate{}
+	order := []string{}
+	collect := func(filter bson.M, opts *options.FindOptions) error {
+		cursor, err := m.coll.Find(ctx, filter, opts.SetLimit(searchCandidates))
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+			raw := make(bson.Raw, len(cursor.Current))
+			copy(raw, cursor.Current)
+			id := raw.Lookup("_id").String()
+			score, _ := raw.Lookup("_score").DoubleOK()
+			if existing, ok := candidates[id]; ok {
+				existing.textScore = maxFloat(existing.textScore, score)
+				continue
+			}
+			candidates[id] = &candidate{raw: raw, textScore: score}
+			order = append(order, id)
+		}
+		return cursor.Err()
+	}
+
+	textScore := bson.M{"$meta": "textScore"}
+	err := collect(
+		withFilter(bson.M{"$text": bson.M{"$search": strings.Join(terms, " ")}}),
+		options.Find().SetProjection(bson.M{"_score": textScore}).SetSort(bson.M{"_score": textScore}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := collect(withFilter(prefixFilter(fields, terms)), options.Find()); err != nil {
+		return nil, err
+	}
+
+	hits := []SearchHit[T]{}
+	for _, id := range order {
+		c := candidates[id]
+		var doc bson.M
+		if err := bson.Unmarshal(c.raw, &doc); err != nil {
+			return nil, err
+		}
+		score := scoreDocument(doc, fields, terms) + c.textScore
+		if score == 0 {
+			continue
+		}
+		var item T
+		if err := bson.Unmarshal(c.raw, &item); err != nil {
+			return nil, err
+		}
+		hits = append(hits, SearchHit[T]{Item: item, Score: score})
+	}
+	return rankHits(hits, searchLimit(q.Limit)), nil
+}
+
+// updateManyIDs mengembalikan _id dokumen yang cocok dengan filter lalu menjalankan
+// update pada dokumen tersebut, kecuali dryRun. Dokumen di trash ikut diperbarui
+// agar referensinya tetap konsisten saat dipulihkan.
+func (m mongoCollection[T]) updateManyIDs(ctx context.Context, filter bson.M, update interface{}, dryRun bool, opts ...*options.UpdateOptions) ([]primitive.ObjectID, error) {
+	cursor, err := m.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	if dryRun || len(ids) == 0 {
+		return ids, nil
+	}
+
+	// Batasi ke _id yang sudah dihitung agar hasil sesuai dengan ID yang dikembalikan
+	_, err = m.coll.UpdateMany(ctx, bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$in": ids}}}}, m.incVersion(m.setSearchCopies(update)), opts...)
+	return ids, err
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OAuthClientRepository mengelola registrasi client OpenID Connect milik modul
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *models.OAuthClient) error
+	FindByModul(ctx context.Context, modulID primitive.ObjectID) (*models.OAuthClient, error)
+	FindByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error)
+	// Update mengembalikan ErrVersionConflict jika version bukan AnyVersion dan berbeda dengan versi dokumen
+	Update(ctx context.Context, modulID primitive.ObjectID, version int64, fields Fields) error
+	Delete(ctx context.Context, modulID primitive.ObjectID) error
+}
+
+// OAuthCodeRepository menyimpan authorization code
+type OAuthCodeRepository interface {
+	Create(ctx context.Context, code *models.OAuthCode) error
+	// Consume menandai code sebagai terpakai secara atomik dan mengembalikannya.
+	// ErrNotFound jika code tidak dikenal, sudah dipakai atau sudah kedaluwarsa.
+	Consume(ctx context.Context, hash string, now time.Time) (*models.OAuthCode, error)
+}
+
+// OAuthConsentRepository menyimpan persetujuan user per client
+type OAuthConsentRepository interface {
+	Find(ctx context.Context, userID primitive.ObjectID, clientID string) (*models.OAuthConsent, error)
+	// Grant menambahkan scope pada persetujuan user, persetujuan dibuat jika belum ada
+	Grant(ctx context.Context, userID primitive.ObjectID, clientID string, scopes []string, now time.Time) error
+	ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.OAuthConsent, error)
+	Revoke(ctx context.Context, userID primitive.ObjectID, clientID string) error
+}
+
+// mongoOAuthClientRepository adalah OAuthClientRepository di koleksi "oauth_clients"
+type mongoOAuthClientRepository struct {
+	mongoCollection[models.OAuthClient]
+}
+
+func NewMongoOAuthClientRepository(db *mongo.Database) OAuthClientRepository {
+	return &mongoOAuthClientRepository{newMongoCollection[models.OAuthClient](db, "oauth_clients").withVersion()}
+}
+
+func (r *mongoOAuthClientRepository) Create(ctx context.Context, client *models.OAuthClient) error {
+	return r.insert(ctx, client)
+}
+
+func (r *mongoOAuthClientRepository) FindByModul(ctx context.Context, modulID primitive.ObjectID) (*models.OAuthClient, error) {
+	return r.findOne(ctx, bson.M{"_id": modulID})
+}
+
+func (r *mongoOAuthClientRepository) FindByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	return r.findOne(ctx, bson.M{"client_id": clientID})
+}
+
+func (r *mongoOAuthClientRepository) Update(ctx context.Context, modulID primitive.ObjectID, version int64, fields Fields) error {
+	return r.updateVersion(ctx, modulID, version, bson.M{"$set": bson.M(fields)})
+}
+
+func (r *mongoOAuthClientRepository) Delete(ctx context.Context, modulID primitive.ObjectID) error {
+	result, err := r.coll.DeleteOne(ctx, bson.M{"_id": modulID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// mongoOAuthCodeRepository adalah OAuthCodeRepository di koleksi "oauth_codes"
+type mongoOAuthCodeRepository struct {
+	mongoCollection[models.OAuthCode]
+}
+
+func NewMongoOAuthCodeRepository(db *mongo.Database) OAuthCodeRepository {
+	return &mongoOAuthCodeRepository{newMongoCollection[models.OAuthCode](db, "oauth_codes")}
+}
+
+func (r *mongoOAuthCodeRepository) Create(ctx context.Context, code *models.OAuthCode) error {
+	return r.insert(ctx, code)
+}
+
+func (r *mongoOAuthCodeRepository) Consume(ctx context.Context, hash string, now time.Time) (*models.OAuthCode, error) {
+	at := primitive.NewDateTimeFromTime(now)
+	var code models.OAuthCode
+	err := r.coll.FindOneAndUpdate(ctx,
+		bson.M{"code_hash": hash, "used_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": at}},
+		bson.M{"$set": bson.M{"used_at": at}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&code)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// mongoOAuthConsentRepository adalah OAuthConsentRepository di koleksi "oauth_consents"
+type mongoOAuthConsentRepository struct {
+	mongoCollection[models.OAuthConsent]
+}
+
+func NewMongoOAuthConsentRepository(db *mongo.Database) OAuthConsentRepository {
+	return &mongoOAuthConsentRepository{newMongoCollection[models.OAuthConsent](db, "oauth_consents")}
+}
+
+func (r *mongoOAuthConsentRepository) Find(ctx context.Context, userID primitive.ObjectID, clientID string) (*models.OAuthConsent, error) {
+	return r.findOne(ctx, bson.M{"user_id": userID, "client_id": clientID})
+}
+
+func (r *mongoOAuthConsentRepository) Grant(ctx context.Context, userID primitive.ObjectID, clientID string, scopes []string, now time.Time) error {
+	at := primitive.NewDateTimeFromTime(now)
+	var err error
+	// Dua upsert bersamaan untuk pasangan yang sama bisa bentrok di index unik, yang kalah cukup diulang
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err = r.coll.UpdateOne(ctx,
+			bson.M{"user_id": userID, "client_id": clientID},
+			bson.M{
+				"$addToSet":    bson.M{"scopes": bson.M{"$each": scopes}},
+				"$set":         bson.M{"updated_at": at},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": at},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (r *mongoOAuthConsentRepository) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.OAuthConsent, error) {
+	return r.find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}}))
+}
+
+func (r *mongoOAuthConsentRepository) Revoke(ctx context.Context, userID primitive.ObjectID, clientID string) error {
+	result, err := r.coll.DeleteOne(ctx, bson.M{"user_id": userID, "client_id": clientID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// memoryOAuthClientRepository adalah OAuthClientRepository di memori
+type memoryOAuthClientRepository struct {
+	docs *memoryCollection[models.OAuthClient]
+}
+
+func NewMemoryOAuthClientRepository() OAuthClientRepository {
+	return &memoryOAuthClientRepository{newMemoryCollection[models.OAuthClient]().withVersion()}
+}
+
+func (r *memoryOAuthClientRepository) Create(ctx context.Context, client *models.OAuthClient) error {
+	return r.docs.insert(client.ModulID, *client)
+}
+
+func (r *memoryOAuthClientRepository) FindByModul(ctx context.Context, modulID primitive.ObjectID) (*models.OAuthClient, error) {
+	return r.docs.get(modulID)
+}
+
+func (r *memoryOAuthClientRepository) FindByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	return r.docs.findFirst(func(client *models.OAuthClient) bool { return client.ClientID == clientID })
+}
+
+func (r *memoryOAuthClientRepository) Update(ctx context.Context, modulID primitive.ObjectID, version int64, fields Fields) error {
+	return r.docs.updateVersion(modulID, version, func(client *models.OAuthClient) error {
+		return setFields(client, fields)
+	})
+}
+
+func (r *memoryOAuthClientRepository) Delete(ctx context.Context, modulID primitive.ObjectID) error {
+	return r.docs.remove(modulID)
+}
+
+// memoryOAuthCodeRepository adalah OAuthCodeRepository di memori
+type memoryOAuthCodeRepository struct {
+	docs *memoryCollection[models.OAuthCode]
+}
+
+func NewMemoryOAuthCodeRepository() OAuthCodeRepository {
+	return &memoryOAuthCodeRepository{newMemoryCollection[models.OAuthCode]()}
+}
+
+func (r *memoryOAuthCodeRepository) Create(ctx context.Context, code *models.OAuthCode) error {
+	return r.docs.insert(code.ID, *code)
+}
+
+func (r *memoryOAuthCodeRepository) Consume(ctx context.Context, hash string, now time.Time) (*models.OAuthCode, error) {
+	usable := func(code *models.OAuthCode) bool {
+		return code.CodeHash == hash && code.UsedAt == nil && code.ExpiresAt.Time().After(now)
+	}
+	found, err := r.docs.findFirst(usable)
+	if err != nil {
+		return nil, err
+	}
+
+	// Periksa ulang di dalam update agar code yang sama tidak bisa ditukar dua kali bersamaan
+	consumed := false
+	err = r.docs.update(found.ID, func(code *models.OAuthCode) error {
+		if !usable(code) {
+			return nil
+		}
+		at := primitive.NewDateTimeFromTime(now)
+		code.UsedAt = &at
+		consumed = true
+		*found = *code
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+// memoryOAuthConsentRepository adalah OAuthConsentRepository di memori
+type memoryOAuthConsentRepository struct {
+	mu   sync.Mutex
+	docs *memoryCollection[models.OAuthConsent]
+}
+
+func NewMemoryOAuthConsentRepository() OAuthConsentRepository {
+	return &memoryOAuthConsentRepository{docs: newMemoryCollection[models.OAuthConsent]()}
+}
+
+func (r *memoryOAuthConsentRepository) Find(ctx context.Context, userID primitive.ObjectID, clientID string) (*models.OAuthConsent, error) {
+	return r.docs.findFirst(func(consent *models.OAuthConsent) bool {
+		return consent.UserID == userID && consent.ClientID == clientID
+	})
+}
+
+func (r *memoryOAuthConsentRepository) Grant(ctx context.Context, userID primitive.ObjectID, clientID string, scopes []string, now time.Time) error {
+	// Dikunci agar dua persetujuan bersamaan tidak membuat dua dokumen untuk pasangan yang sama
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	at := primitive.NewDateTimeFromTime(now)
+	existing, err := r.Find(ctx, userID, clientID)
+	if err == ErrNotFound {
+		consent := models.OAuthConsent{
+			ID: primitive.NewObjectID(), UserID: userID, ClientID: clientID, Scopes: scopes, CreatedAt: at, UpdatedAt: at,
+		}
+		return r.docs.insert(consent.ID, consent)
+	}
+	if err != nil {
+		return err
+	}
+	return r.docs.update(existing.ID, func(consent *models.OAuthConsent) error {
+		for _, scope := range scopes {
+			if !consent.Covers([]string{scope}) {
+				consent.Scopes = append(consent.Scopes, scope)
+			}
+		}
+		consent.UpdatedAt = at
+		return nil
+	})
+}
+
+func (r *memoryOAuthConsentRepository) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.OAuthConsent, error) {
+	consents, err := r.docs.filter(func(consent *models.OAuthConsent) bool { return consent.UserID == userID })
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(consents, func(i, j int) bool { return consents[i].UpdatedAt > consents[j].UpdatedAt })
+	return consents, nil
+}
+
+func (r *memoryOAuthConsentRepository) Revoke(ctx context.Context, userID primitive.ObjectID, clientID string) error {
+	consent, err := r.Find(ctx, userID, clientID)
+	if err != nil {
+		return err
+	}
+	return r.docs.remove(consent.ID)
+}
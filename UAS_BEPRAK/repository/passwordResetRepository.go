@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PasswordResetRepository menyimpan token reset password
+type PasswordResetRepository interface {
+	Create(ctx context.Context, reset *models.PasswordReset) error
+	// FindUsable mengembalikan token yang belum dipakai dan belum kedaluwarsa tanpa memakainya
+	FindUsable(ctx context.Context, hash string, now time.Time) (*models.PasswordReset, error)
+	// Consume menandai token sebagai terpakai secara atomik dan mengembalikannya.
+	// ErrNotFound jika token tidak dikenal, sudah dipakai atau sudah kedaluwarsa.
+	Consume(ctx context.Context, hash string, now time.Time) (*models.PasswordReset, error)
+	// LatestForUser mengembalikan token terakhir yang dibuat untuk user
+	LatestForUser(ctx context.Context, userID primitive.ObjectID) (*models.PasswordReset, error)
+	// InvalidateForUser membatalkan semua token user yang belum dipakai
+	InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error
+}
+
+// mongoPasswordResetRepository adalah PasswordResetRepository di koleksi "password_resets"
+type mongoPasswordResetRepository struct {
+	mongoCollection[models.PasswordReset]
+}
+
+func NewMongoPasswordResetRepository(db *mongo.Database) PasswordResetRepository {
+	return &mongoPasswordResetRepository{newMongoCollection[models.PasswordReset](db, "password_resets")}
+}
+
+func (r *mongoPasswordResetRepository) Create(ctx context.Context, reset *models.PasswordReset) error {
+	return r.insert(ctx, reset)
+}
+
+func (r *mongoPasswordResetRepository) FindUsable(ctx context.Context, hash string, now time.Time) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	err := r.coll.FindOne(ctx, bson.M{
+		"token_hash": hash,
+		"used_at":    bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": primitive.NewDateTimeFromTime(now)},
+	}).Decode(&reset)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+func (r *mongoPasswordResetRepository) Consume(ctx context.Context, hash string, now time.Time) (*models.PasswordReset, error) {
+	at := primitive.NewDateTimeFromTime(now)
+	var reset models.PasswordReset
+	err := r.coll.FindOneAndUpdate(ctx,
+		bson.M{"token_hash": hash, "used_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": at}},
+		bson.M{"$set": bson.M{"used_at": at}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&reset)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+func (r *mongoPasswordResetRepository) LatestForUser(ctx context.Context, userID primitive.ObjectID) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	err := r.coll.FindOne(ctx, bson.M{"user_id": userID}, options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})).Decode(&reset)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+func (r *mongoPasswordResetRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	_, err := r.coll.UpdateMany(ctx,
+		bson.M{"user_id": userID, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": primitive.NewDateTimeFromTime(now)}},
+	)
+	return err
+}
+
+// memoryPasswordResetRepository adalah PasswordResetRepository di memori
+type memoryPasswordResetRepository struct {
+	docs *memoryCollection[models.PasswordReset]
+}
+
+func NewMemoryPasswordResetRepository() PasswordResetRepository {
+	return &memoryPasswordResetRepository{newMemoryCollection[models.PasswordReset]()}
+}
+
+func (r *memoryPasswordResetRepository) Create(ctx context.Context, reset *models.PasswordReset) error {
+	return r.docs.insert(reset.ID, *reset)
+}
+
+func (r *memoryPasswordResetRepository) FindUsable(ctx context.Context, hash string, now time.Time) (*models.PasswordReset, error) {
+	return r.docs.findFirst(usableReset(hash, now))
+}
+
+// usableReset mencocokkan token yang belum dipakai dan belum kedaluwarsa
+func usableReset(hash string, now time.Time) func(*models.PasswordReset) bool {
+	return func(reset *models.PasswordReset) bool {
+		return reset.TokenHash == hash && reset.UsedAt == nil && reset.ExpiresAt.Time().After(now)
+	}
+}
+
+func (r *memoryPasswordResetRepository) Consume(ctx context.Context, hash string, now time.Time) (*models.PasswordReset, error) {
+	usable := usableReset(hash, now)
+	found, err := r.docs.findFirst(usable)
+	if err != nil {
+		return nil, err
+	}
+
+	// Periksa ulang di dalam update agar dua request bersamaan tidak sama-sama berhasil
+	consumed := false
+	err = r.docs.update(found.ID, func(reset *models.PasswordReset) error {
+		if !usable(reset) {
+			return nil
+		}
+		at := primitive.NewDateTimeFromTime(now)
+		reset.UsedAt = &at
+		consumed = true
+		*found = *reset
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+func (r *memoryPasswordResetRepository) LatestForUser(ctx context.Context, userID primitive.ObjectID) (*models.PasswordReset, error) {
+	resets, err := r.docs.filter(func(reset *models.PasswordReset) bool { return reset.UserID == userID })
+	if err != nil {
+		return nil, err
+	}
+	if len(resets) == 0 {
+		return nil, ErrNotFound
+	}
+	latest := resets[0]
+	for _, reset := range resets[1:] {
+		if reset.CreatedAt > latest.CreatedAt {
+			latest = reset
+		}
+	}
+	return &latest, nil
+}
+
+func (r *memoryPasswordResetRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, now time.Time) error {
+	_, err := r.docs.updateWhere(func(reset *models.PasswordReset) bool {
+		return reset.UserID == userID && reset.UsedAt == nil
+	}, func(reset *models.PasswordReset) error {
+		at := primitive.NewDateTimeFromTime(now)
+		reset.UsedAt = &at
+		return nil
+	})
+	return err
+}
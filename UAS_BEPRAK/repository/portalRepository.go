@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Nama kelompok untuk modul yang tidak memiliki kategori
+const UncategorizedName = "Lainnya"
+
+// PortalRepository menyediakan data gabungan untuk halaman portal
+type PortalRepository interface {
+	// Dashboard mengelompokkan modul milik user berdasarkan kategori. Modul diurutkan sesuai
+	// user_modul seperti /api/me/modules, kategori sesuai modul pertamanya di user_modul.
+	// Kelompok tanpa kategori selalu terakhir.
+	Dashboard(ctx context.Context, userID primitive.ObjectID) ([]models.DashboardKategori, error)
+}
+
+// mongoPortalRepository menggabungkan users, moduls dan kategori_modul dengan $lookup
+type mongoPortalRepository struct {
+	users *mongo.Collection
+}
+
+func NewMongoPortalRepository(db *mongo.Database) PortalRepository {
+	return &mongoPortalRepository{users: db.Collection("users")}
+}
+
+func (r *mongoPortalRepository) Dashboard(ctx context.Context, userID primitive.ObjectID) ([]models.DashboardKategori, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": userID, deletedAtField: bson.M{"$exists": false}}}},
+		// position adalah indeks modul di user_modul, dipakai untuk urutan akhir
+		{{Key: "$unwind", Value: bson.M{"path": "$user_modul", "includeArrayIndex": "position"}}},
+		{{Key: "$lookup", Value: activeLookup("moduls", "$user_modul.modul_id", "modul")}},
+		// Modul yang sudah dihapus (termasuk yang masih di trash) tidak ditampilkan
+		{{Key: "$unwind", Value: "$modul"}},
+		// Modul yang tercatat lebih dari sekali di user_modul hanya ditampilkan di posisi pertamanya
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$modul._id",
+			"position": bson.M{"$min": "$position"},
+			"modul":    bson.M{"$first": "$modul"},
+		}}},
+		{{Key: "$lookup", Value: activeLookup("kategori_modul", "$modul.kategori_modul", "kategori")}},
+		{{Key: "$unwind", Value: bson.M{"path": "$kategori", "preserveNullAndEmptyArrays": true}}},
+		// $push mengikuti urutan dokumen masuk, sehingga modul di setiap kategori tetap urut position
+		{{Key: "$sort", Value: bson.M{"position": 1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"$ifNull": bson.A{"$kategori._id", primitive.NilObjectID}},
+			"name":     bson.M{"$first": bson.M{"$ifNull": bson.A{"$kategori.name", UncategorizedName}}},
+			"position": bson.M{"$min": "$position"},
+			"moduls": bson.M{"$push": bson.M{
+				"_id":         "$modul._id",
+				"name":        "$modul.name",
+				"description": "$modul.description",
+				"alamat_url":  "$modul.alamat_url",
+				"gbr_icon":    "$modul.gbr_icon",
+			}},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"uncategorized": bson.M{"$eq": bson.A{"$_id", primitive.NilObjectID}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "uncategorized", Value: 1}, {Key: "position", Value: 1}}}},
+	}
+
+	cursor, err := r.users.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	groups := []models.DashboardKategori{}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// activeLookup adalah $lookup berdasarkan _id yang melewati dokumen di trash
+func activeLookup(from, localField, as string) bson.M {
+	return bson.M{
+		"from": from,
+		"let":  bson.M{"id": localField},
+		"pipeline": bson.A{bson.M{"$match": bson.M{
+			"$expr":        bson.M{"$eq": bson.A{"$_id", "$$id"}},
+			deletedAtField: bson.M{"$exists": false},
+		}}},
+		"as": as,
+	}
+}
+
+// memoryPortalRepository membangun dashboard dari repository memori lain
+type memoryPortalRepository struct {
+	users          UserRepository
+	moduls         ModulRepository
+	kategoriModuls KategoriModulRepository
+}
+
+func NewMemoryPortalRepository(users UserRepository, moduls ModulRepository, kategoriModuls KategoriModulRepository) PortalRepository {
+	return &memoryPortalRepository{users: users, moduls: moduls, kategoriModuls: kategoriModuls}
+}
+
+func (r *memoryPortalRepository) Dashboard(ctx context.Context, userID primitive.ObjectID) ([]models.DashboardKategori, error) {
+	// Sama seperti $match pada MongoDB, user yang tidak ada menghasilkan dashboard kosong
+	user, err := r.users.FindByID(ctx, userID)
+	if err == ErrNotFound {
+		return []models.DashboardKategori{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []primitive.ObjectID{}
+	for _, userModul := range user.UserModul {
+		ids = append(ids, userModul.ModulID)
+	}
+	// FindByIDs mempertahankan urutan ids, yaitu urutan user_modul
+	moduls, err := r.moduls.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []models.DashboardKategori{}
+	index := map[primitive.ObjectID]int{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, modul := range moduls {
+		if seen[modul.ID] {
+			continue
+		}
+		seen[modul.ID] = true
+
+		kategoriID, name := primitive.NilObjectID, UncategorizedName
+		if kategori, err := r.kategoriModuls.FindByID(ctx, modul.KategoriModul); err == nil {
+			kategoriID, name = kategori.ID, kategori.Name
+		} else if err != ErrNotFound {
+			return nil, err
+		}
+
+		i, ok := index[kategoriID]
+		if !ok {
+			i = len(groups)
+			index[kategoriID] = i
+			groups = append(groups, models.DashboardKategori{ID: kategoriID, Name: name})
+		}
+		groups[i].Moduls = append(groups[i].Moduls, models.DashboardModul{
+			ID:          modul.ID,
+			Name:        modul.Name,
+			Description: modul.Description,
+			AlamatURL:   modul.AlamatURL,
+			GbrIcon:     modul.GbrIcon,
+		})
+	}
+	// Kelompok sudah urut sesuai modul pertamanya, hanya kelompok tanpa kategori dipindah ke akhir
+	sort.SliceStable(groups, func(i, j int) bool {
+		return !groups[i].ID.IsZero() && groups[j].ID.IsZero()
+	})
+	return groups, nil
+}
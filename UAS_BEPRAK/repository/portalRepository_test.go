@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"project-crud/models"
+)
+
+func TestMemoryDashboardOrder(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	kategori := map[string]primitive.ObjectID{}
+	for _, name := range []string{"Akademik", "Keuangan"} {
+		k := models.KategoriModul{ID: primitive.NewObjectID(), Name: name}
+		if err := store.KategoriModuls.Create(ctx, &k); err != nil {
+			t.Fatal(err)
+		}
+		kategori[name] = k.ID
+	}
+	moduls := map[string]primitive.ObjectID{}
+	for _, m := range []struct{ name, kategori string }{
+		{"Zakat", "Keuangan"}, {"SIAKAD", "Akademik"}, {"Lain", ""}, {"Beasiswa", "Keuangan"}, {"KRS", "Akademik"}, {"Dihapus", "Akademik"},
+	} {
+		modul := models.Modul{ID: primitive.NewObjectID(), Name: m.name, KategoriModul: kategori[m.kategori]}
+		if err := store.Moduls.Create(ctx, &modul); err != nil {
+			t.Fatal(err)
+		}
+		moduls[m.name] = modul.ID
+	}
+	if err := store.Moduls.SoftDelete(ctx, moduls["Dihapus"], "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Urutan user_modul, bukan urutan nama; KRS tercatat dua kali
+	user := models.User{ID: primitive.NewObjectID(), Username: "budi"}
+	for _, name := range []string{"Lain", "Zakat", "KRS", "Dihapus", "SIAKAD", "KRS", "Beasiswa"} {
+		user.UserModul = append(user.UserModul, models.UserModul{ModulID: moduls[name]})
+	}
+	if err := store.Users.Create(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := store.Portal.Dashboard(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [][]string{}
+	for _, group := range groups {
+		names := []string{group.Name}
+		for _, modul := range group.Moduls {
+			names = append(names, modul.Name)
+		}
+		got = append(got, names)
+	}
+	want := [][]string{
+		{"Keuangan", "Zakat", "Beasiswa"},
+		{"Akademik", "KRS", "SIAKAD"},
+		{UncategorizedName, "Lain"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Dashboard() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Batas jumlah data per halaman
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListQuery adalah parameter untuk daftar data dengan filter, urutan dan paginasi.
+// Jika Cursor diisi maka paginasi berbasis cursor dipakai dan Page diabaikan.
+type ListQuery struct {
+	Filter Fields // Filter kesamaan berdasarkan nama field bson
+	Sort   string // Nama field bson untuk pengurutan, default "_id"
+	Desc   bool   // Urutan menurun
+	Page   int    // Nomor halaman, dimulai dari 1
+	Limit  int    // Jumlah data per halaman
+	Cursor string // Cursor dari Page.NextCursor sebelumnya
+}
+
+// Page adalah hasil satu halaman data
+type Page[T any] struct {
+	Items      []T
+	Total      int64  // Jumlah seluruh data yang cocok dengan filter
+	HasMore    bool   // Masih ada data setelah halaman ini
+	NextCursor string // Cursor untuk mengambil halaman berikutnya
+}
+
+// normalize mengisi nilai bawaan ListQuery
+func (q ListQuery) normalize() ListQuery {
+	if q.Sort == "" {
+		q.Sort = "_id"
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	return q
+}
+
+// pageCursor adalah posisi terakhir pada urutan (nilai field sort dan _id)
+type pageCursor struct {
+	Value interface{}
+	ID    primitive.ObjectID
+}
+
+// encodeCursor menyimpan juga nama field sort agar cursor tidak dipakai dengan urutan lain
+func encodeCursor(sort string, value interface{}, id primitive.ObjectID) (string, error) {
+	raw, err := bson.Marshal(bson.D{{Key: "s", Value: sort}, {Key: "v", Value: value}, {Key: "id", Value: id}})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor membaca cursor dari client. Nilai sort hanya boleh skalar karena dipakai
+// langsung sebagai operand filter; dokumen atau array bisa berisi operator seperti $ne.
+func decodeCursor(cursor, sort string) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, ok := doc["id"].(primitive.ObjectID)
+	if !ok || doc["s"] != sort || !isScalar(doc["v"]) {
+		return nil, ErrInvalidCursor
+	}
+	return &pageCursor{Value: doc["v"], ID: id}, nil
+}
+
+// isScalar bernilai true untuk tipe BSON yang bisa menjadi nilai field sort
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, bool, int32, int64, float64,
+		primitive.ObjectID, primitive.DateTime, primitive.Timestamp, primitive.Decimal128:
+		return true
+	}
+	return false
+}
+
+// toDocument mengubah dokumen menjadi bson.M agar field-nya bisa dibaca berdasarkan nama bson
+func toDocument(doc interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var m bson.M
+	err = bson.Unmarshal(raw, &m)
+	return m, err
+}
+
+// lookupField membaca field bson, termasuk field bertingkat seperti "a.b"
+func lookupField(doc bson.M, path string) interface{} {
+	var current interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(bson.M)
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+	return current
+}
+
+// compareValues membandingkan dua nilai BSON untuk pengurutan di memori
+func compareValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case nil:
+		if b == nil {
+			return 0
+		}
+		return -1
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv)
+		}
+	case primitive.ObjectID:
+		if bv, ok := b.(primitive.ObjectID); ok {
+			return bytes.Compare(av[:], bv[:])
+		}
+	case primitive.DateTime:
+		if bv, ok := b.(primitive.DateTime); ok {
+			return compareNumbers(float64(av), float64(bv))
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			if av == bv {
+				return 0
+			}
+			if !av {
+				return -1
+			}
+			return 1
+		}
+	}
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return compareNumbers(af, bf)
+		}
+	}
+	if b == nil {
+		return 1
+	}
+	return 0
+}
+
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// normalizeValue menyamakan tipe nilai Go dengan tipe hasil decode BSON
+func normalizeValue(v interface{}) interface{} {
+	m, err := toDocument(bson.M{"v": v})
+	if err != nil {
+		return v
+	}
+	return m["v"]
+}
+
+// newPage memotong hasil query (diambil Limit+1 dokumen) menjadi satu halaman
+// dan membuat cursor dari dokumen terakhir jika masih ada halaman berikutnya
+func newPage[T any](docs []T, total int64, q ListQuery) (*Page[T], error) {
+	page := &Page[T]{Items: docs, Total: total}
+	if len(docs) <= q.Limit {
+		return page, nil
+	}
+	page.Items = docs[:q.Limit]
+	page.HasMore = true
+
+	last, err := toDocument(page.Items[len(page.Items)-1])
+	if err != nil {
+		return nil, err
+	}
+	id, _ := last["_id"].(primitive.ObjectID)
+	page.NextCursor, err = encodeCursor(q.Sort, lookupField(last, q.Sort), id)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// matchFilter memeriksa filter kesamaan pada representasi BSON dokumen
+func matchFilter(doc bson.M, filter Fields) bool {
+	for key, value := range filter {
+		if !reflect.DeepEqual(lookupField(doc, key), normalizeValue(value)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rawCursor membuat cursor dengan isi bebas, seperti yang bisa dikirim client
+func rawCursor(t *testing.T, doc bson.D) string {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func TestDecodeCursor(t *testing.T) {
+	id := primitive.NewObjectID()
+	when := primitive.NewDateTimeFromTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	tests := []struct {
+		name      string
+		cursor    string
+		sort      string
+		wantValue interface{}
+		wantErr   bool
+	}{
+		{"string", rawCursor(t, bson.D{{Key: "s", Value: "username"}, {Key: "v", Value: "budi"}, {Key: "id", Value: id}}), "username", "budi", false},
+		{"int32", rawCursor(t, bson.D{{Key: "s", Value: "urutan"}, {Key: "v", Value: int32(7)}, {Key: "id", Value: id}}), "urutan", int32(7), false},
+		{"int64", rawCursor(t, bson.D{{Key: "s", Value: "urutan"}, {Key: "v", Value: int64(7)}, {Key: "id", Value: id}}), "urutan", int64(7), false},
+		{"tanggal", rawCursor(t, bson.D{{Key: "s", Value: "created_at"}, {Key: "v", Value: when}, {Key: "id", Value: id}}), "created_at", when, false},
+		{"object id", rawCursor(t, bson.D{{Key: "s", Value: "role_id"}, {Key: "v", Value: id}, {Key: "id", Value: id}}), "role_id", id, false},
+		{"null", rawCursor(t, bson.D{{Key: "s", Value: "phone"}, {Key: "v", Value: nil}, {Key: "id", Value: id}}), "phone", nil, false},
+		{"sort berbeda", rawCursor(t, bson.D{{Key: "s", Value: "username"}, {Key: "v", Value: "budi"}, {Key: "id", Value: id}}), "email", nil, true},
+		{"operator dalam dokumen", rawCursor(t, bson.D{{Key: "s", Value: "username"}, {Key: "v", Value: bson.D{{Key: "$ne", Value: ""}}}, {Key: "id", Value: id}}), "username", nil, true},
+		{"array", rawCursor(t, bson.D{{Key: "s", Value: "username"}, {Key: "v", Value: bson.A{"a", "b"}}, {Key: "id", Value: id}}), "username", nil, true},
+		{"regex", rawCursor(t, bson.D{{Key: "s", Value: "username"}, {Key: "v", Value: primitive.Regex{Pattern: ".*"}}, {Key: "id", Value: id}}), "username", nil, true},
+		{"id bukan object id", rawCursor(t, bson.D{{Key: "s", Value: "username"}, {Key: "v", Value: "budi"}, {Key: "id", Value: "abc"}}), "username", nil, true},
+		{"tanpa id", rawCursor(t, bson.D{{Key: "s", Value: "username"}, {Key: "v", Value: "budi"}}), "username", nil, true},
+		{"bukan base64", "!!!", "username", nil, true},
+		{"bukan bson", base64.RawURLEncoding.EncodeToString([]byte("hello")), "username", nil, true},
+		{"kosong", "", "username", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := decodeCursor(tt.cursor, tt.sort)
+			if tt.wantErr {
+				if err != ErrInvalidCursor {
+					t.Fatalf("decodeCursor() error = %v, want ErrInvalidCursor", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if cursor.ID != id || cursor.Value != tt.wantValue {
+				t.Errorf("decodeCursor() = (%v, %v), want (%v, %v)", cursor.Value, cursor.ID, tt.wantValue, id)
+			}
+		})
+	}
+}
+
+func TestEncodeCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	encoded, err := encodeCursor("nm_user", "Budi Santoso", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := decodeCursor(encoded, "nm_user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor.Value != "Budi Santoso" || cursor.ID != id {
+		t.Errorf("round trip = (%v, %v), want (Budi Santoso, %v)", cursor.Value, cursor.ID, id)
+	}
+	if _, err := decodeCursor(encoded, "username"); err != ErrInvalidCursor {
+		t.Errorf("cursor reused with another sort: error = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RefField adalah field yang menyimpan ID dokumen dari koleksi lain
+type RefField struct {
+	Field string // Nama field bson, atau nama array untuk referensi di dalam array of object
+	Key   string // Nama field ID di dalam elemen array, kosong jika Field langsung berisi ID
+}
+
+// Referrer diimplementasikan repository yang menyimpan ID dokumen dari koleksi lain.
+// Semua method mengembalikan ID dokumen yang terdampak.
+type Referrer interface {
+	// FindReferencing mencari dokumen yang mereferensikan id
+	FindReferencing(ctx context.Context, ref RefField, id primitive.ObjectID) ([]primitive.ObjectID, error)
+	// ClearReference mengosongkan field (menjadi ObjectID nol) atau mencabut elemen array yang mereferensikan id
+	ClearReference(ctx context.Context, ref RefField, id primitive.ObjectID) ([]primitive.ObjectID, error)
+	// ReplaceReference memindahkan referensi dari oldID ke newID dan men-$set extra pada dokumen
+	// atau elemen array yang sama. Elemen array yang menjadi duplikat newID dicabut.
+	ReplaceReference(ctx context.Context, ref RefField, oldID, newID primitive.ObjectID, extra Fields) ([]primitive.ObjectID, error)
+}
+
+func refFilter(ref RefField, id primitive.ObjectID) bson.M {
+	if ref.Key == "" {
+		return bson.M{ref.Field: id}
+	}
+	return bson.M{ref.Field + "." + ref.Key: id}
+}
+
+func (m mongoCollection[T]) FindReferencing(ctx context.Context, ref RefField, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return m.updateManyIDs(ctx, refFilter(ref, id), nil, true)
+}
+
+func (m mongoCollection[T]) ClearReference(ctx context.Context, ref RefField, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	update := bson.M{"$pull": bson.M{ref.Field: bson.M{ref.Key: id}}}
+	if ref.Key == "" {
+		update = bson.M{"$set": bson.M{ref.Field: primitive.NilObjectID}}
+	}
+	return m.updateManyIDs(ctx, refFilter(ref, id), update, false)
+}
+
+func (m mongoCollection[T]) ReplaceReference(ctx context.Context, ref RefField, oldID, newID primitive.ObjectID, extra Fields) ([]primitive.ObjectID, error) {
+	if ref.Key == "" {
+		set := bson.M{ref.Field: newID}
+		for key, value := range extra {
+			set[key] = value
+		}
+		return m.updateManyIDs(ctx, refFilter(ref, oldID), bson.M{"$set": set}, false)
+	}
+
+	// Dokumen yang sudah memiliki newID cukup mencabut elemen lama agar tidak duplikat
+	pulled, err := m.updateManyIDs(ctx,
+		bson.M{"$and": bson.A{refFilter(ref, oldID), refFilter(ref, newID)}},
+		bson.M{"$pull": bson.M{ref.Field: bson.M{ref.Key: oldID}}},
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := ref.Field + ".$[ref]."
+	set := bson.M{prefix + ref.Key: newID}
+	for key, value := range extra {
+		set[prefix+key] = value
+	}
+	replaced, err := m.updateManyIDs(ctx, refFilter(ref, oldID), bson.M{"$set": set}, false,
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"ref." + ref.Key: oldID}},
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return append(pulled, replaced...), nil
+}
+
+func (m *memoryCollection[T]) FindReferencing(ctx context.Context, ref RefField, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return m.updateRaw(func(doc bson.M) bool {
+		return len(referencingElements(doc, ref, id)) > 0 || (ref.Key == "" && doc[ref.Field] == id)
+	}, true)
+}
+
+func (m *memoryCollection[T]) ClearReference(ctx context.Context, ref RefField, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return m.updateRaw(func(doc bson.M) bool {
+		if ref.Key == "" {
+			if doc[ref.Field] != id {
+				return false
+			}
+			doc[ref.Field] = primitive.NilObjectID
+			return true
+		}
+		return pullElements(doc, ref, id)
+	}, false)
+}
+
+func (m *memoryCollection[T]) ReplaceReference(ctx context.Context, ref RefField, oldID, newID primitive.ObjectID, extra Fields) ([]primitive.ObjectID, error) {
+	return m.updateRaw(func(doc bson.M) bool {
+		if ref.Key == "" {
+			if doc[ref.Field] != oldID {
+				return false
+			}
+			doc[ref.Field] = newID
+			for key, value := range extra {
+				doc[key] = value
+			}
+			return true
+		}
+
+		elements := referencingElements(doc, ref, oldID)
+		if len(elements) == 0 {
+			return false
+		}
+		if len(referencingElements(doc, ref, newID)) > 0 {
+			return pullElements(doc, ref, oldID)
+		}
+		for _, element := range elements {
+			element[ref.Key] = newID
+			for key, value := range extra {
+				element[key] = value
+			}
+		}
+		return true
+	}, false)
+}
+
+// referencingElements mengembalikan elemen array ref.Field yang ref.Key-nya sama dengan id
+func referencingElements(doc bson.M, ref RefField, id primitive.ObjectID) []bson.M {
+	if ref.Key == "" {
+		return nil
+	}
+	elements := []bson.M{}
+	array, _ := doc[ref.Field].(bson.A)
+	for _, item := range array {
+		if element, ok := item.(bson.M); ok && element[ref.Key] == id {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// pullElements mencabut elemen array ref.Field yang ref.Key-nya sama dengan id
+func pullElements(doc bson.M, ref RefField, id primitive.ObjectID) bool {
+	array, _ := doc[ref.Field].(bson.A)
+	kept := bson.A{}
+	for _, item := range array {
+		if element, ok := item.(bson.M); ok && element[ref.Key] == id {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if len(kept) == len(array) {
+		return false
+	}
+	doc[ref.Field] = kept
+	return true
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Error umum yang dikembalikan oleh semua implementasi repository
+var (
+	ErrNotFound        = errors.New("document not found")
+	ErrDuplicate       = errors.New("duplicate document")
+	ErrVersionConflict = errors.New("document version has changed")
+)
+
+// Fields adalah kumpulan field (nama field bson) yang akan di-$set pada update
+type Fields map[string]interface{}
+
+// Store mengumpulkan semua repository yang dipakai controller dan middleware
+type Store struct {
+	Users          UserRepository
+	Roles          RoleRepository
+	Moduls         ModulRepository
+	KategoriModuls KategoriModulRepository
+	JenisUsers     JenisUserRepository
+	Sessions       SessionRepository
+	Portal         PortalRepository
+	Audit          AuditRepository
+	Tx             Transactor
+
+	// Proteksi brute-force login
+	LoginThrottles LoginThrottleRepository
+	LoginRecords   LoginRecordRepository
+
+	// Token reset password sekali pakai
+	PasswordResets PasswordResetRepository
+
+	// Provider OpenID Connect untuk aplikasi modul
+	OAuthClients  OAuthClientRepository
+	OAuthCodes    OAuthCodeRepository
+	OAuthConsents OAuthConsentRepository
+
+	// Riwayat versi dokumen yang sering berubah
+	ModulRevisions     RevisionRepository[models.Modul]
+	JenisUserRevisions RevisionRepository[models.JenisUser]
+}
+
+// NewMongoStore membuat Store yang menyimpan data di MongoDB
+func NewMongoStore(db *mongo.Database) *Store {
+	return &Store{
+		Users:          NewMongoUserRepository(db),
+		Roles:          NewMongoRoleRepository(db),
+		Moduls:         NewMongoModulRepository(db),
+		KategoriModuls: NewMongoKategoriModulRepository(db),
+		JenisUsers:     NewMongoJenisUserRepository(db),
+		Sessions:       NewMongoSessionRepository(db),
+		Portal:         NewMongoPortalRepository(db),
+		Audit:          NewMongoAuditRepository(db),
+		Tx:             NewMongoTransactor(db.Client()),
+
+		LoginThrottles: NewMongoLoginThrottleRepository(db),
+		LoginRecords:   NewMongoLoginRecordRepository(db),
+		PasswordResets: NewMongoPasswordResetRepository(db),
+
+		OAuthClients:  NewMongoOAuthClientRepository(db),
+		OAuthCodes:    NewMongoOAuthCodeRepository(db),
+		OAuthConsents: NewMongoOAuthConsentRepository(db),
+
+		ModulRevisions:     NewMongoRevisionRepository[models.Modul](db, "modul_revisions"),
+		JenisUserRevisions: NewMongoRevisionRepository[models.JenisUser](db, "jenis_user_revisions"),
+	}
+}
+
+// NewMemoryStore membuat Store yang menyimpan data di memori (untuk pengujian dan pengembangan lokal)
+func NewMemoryStore() *Store {
+	store := &Store{
+		Users:          NewMemoryUserRepository(),
+		Roles:          NewMemoryRoleRepository(),
+		Moduls:         NewMemoryModulRepository(),
+		KategoriModuls: NewMemoryKategoriModulRepository(),
+		JenisUsers:     NewMemoryJenisUserRepository(),
+		Sessions:       NewMemorySessionRepository(),
+		Audit:          NewMemoryAuditRepository(),
+		Tx:             NewMemoryTransactor(),
+
+		LoginThrottles: NewMemoryLoginThrottleRepository(),
+		LoginRecords:   NewMemoryLoginRecordRepository(),
+		PasswordResets: NewMemoryPasswordResetRepository(),
+
+		OAuthClients:  NewMemoryOAuthClientRepository(),
+		OAuthCodes:    NewMemoryOAuthCodeRepository(),
+		OAuthConsents: NewMemoryOAuthConsentRepository(),
+
+		ModulRevisions:     NewMemoryRevisionRepository[models.Modul](),
+		JenisUserRevisions: NewMemoryRevisionRepository[models.JenisUser](),
+	}
+	store.Portal = NewMemoryPortalRepository(store.Users, store.Moduls, store.KategoriModuls)
+	return store
+}
+
+// timeNow dapat diganti saat pengujian
+var timeNow = time.Now
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Batas percobaan ulang saat dua snapshot berebut nomor revisi yang sama
+const revisionRetries = 5
+
+// RevisionRepository menyimpan riwayat versi dokumen T. Revisi hanya ditambah, tidak pernah diubah.
+type RevisionRepository[T any] interface {
+	// Record mengisi rev.Revision dengan nomor berikutnya untuk rev.EntityID lalu menyimpannya
+	Record(ctx context.Context, rev *models.Revision[T]) error
+	Find(ctx context.Context, entityID primitive.ObjectID, revision int) (*models.Revision[T], error)
+	List(ctx context.Context, entityID primitive.ObjectID, q ListQuery) (*Page[models.Revision[T]], error)
+}
+
+// mongoRevisionRepository adalah RevisionRepository di koleksi riwayat per entitas,
+// dengan index unik (entity_id, revision) untuk mencegah nomor ganda
+type mongoRevisionRepository[T any] struct {
+	mongoCollection[models.Revision[T]]
+}
+
+func NewMongoRevisionRepository[T any](db *mongo.Database, collection string) RevisionRepository[T] {
+	return &mongoRevisionRepository[T]{newMongoCollection[models.Revision[T]](db, collection)}
+}
+
+func (r *mongoRevisionRepository[T]) Record(ctx context.Context, rev *models.Revision[T]) error {
+	for attempt := 0; ; attempt++ {
+		latest, err := r.find(ctx, bson.M{"entity_id": rev.EntityID},
+			options.Find().SetSort(bson.M{"revision": -1}).SetLimit(1))
+		if err != nil {
+			return err
+		}
+		rev.Revision = 1
+		if len(latest) > 0 {
+			rev.Revision = latest[0].Revision + 1
+		}
+		err = r.insert(ctx, rev)
+		if err != ErrDuplicate || attempt == revisionRetries {
+			return err
+		}
+	}
+}
+
+func (r *mongoRevisionRepository[T]) Find(ctx context.Context, entityID primitive.ObjectID, revision int) (*models.Revision[T], error) {
+	return r.findOne(ctx, bson.M{"entity_id": entityID, "revision": revision})
+}
+
+func (r *mongoRevisionRepository[T]) List(ctx context.Context, entityID primitive.ObjectID, q ListQuery) (*Page[models.Revision[T]], error) {
+	return r.list(ctx, bson.M{"entity_id": entityID}, q)
+}
+
+// memoryRevisionRepository adalah RevisionRepository di memori
+type memoryRevisionRepository[T any] struct {
+	mu   sync.Mutex // Menjaga penomoran revisi tetap berurutan
+	docs *memoryCollection[models.Revision[T]]
+}
+
+func NewMemoryRevisionRepository[T any]() RevisionRepository[T] {
+	return &memoryRevisionRepository[T]{docs: newMemoryCollection[models.Revision[T]]()}
+}
+
+func (r *memoryRevisionRepository[T]) Record(ctx context.Context, rev *models.Revision[T]) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	revisions, err := r.docs.filter(func(doc *models.Revision[T]) bool { return doc.EntityID == rev.EntityID })
+	if err != nil {
+		return err
+	}
+	rev.Revision = 1
+	for _, existing := range revisions {
+		if existing.Revision >= rev.Revision {
+			rev.Revision = existing.Revision + 1
+		}
+	}
+	return r.docs.insert(rev.ID, *rev)
+}
+
+func (r *memoryRevisionRepository[T]) Find(ctx context.Context, entityID primitive.ObjectID, revision int) (*models.Revision[T], error) {
+	return r.docs.findFirst(func(doc *models.Revision[T]) bool {
+		return doc.EntityID == entityID && doc.Revision == revision
+	})
+}
+
+func (r *memoryRevisionRepository[T]) List(ctx context.Context, entityID primitive.ObjectID, q ListQuery) (*Page[models.Revision[T]], error) {
+	return r.docs.list(func(doc *models.Revision[T]) bool { return doc.EntityID == entityID }, q)
+}
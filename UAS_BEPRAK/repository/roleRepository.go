@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RoleRepository mengelola data role
+type RoleRepository interface {
+	Trash[models.Role]
+
+	Create(ctx context.Context, role *models.Role) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Role, error)
+	FindByName(ctx context.Context, name string) (*models.Role, error)
+	List(ctx context.Context, q ListQuery) (*Page[models.Role], error)
+	// Update mengembalikan ErrVersionConflict jika version bukan AnyVersion dan berbeda dengan versi dokumen
+	Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error
+}
+
+// mongoRoleRepository adalah RoleRepository di koleksi "roles"
+type mongoRoleRepository struct {
+	mongoCollection[models.Role]
+}
+
+func NewMongoRoleRepository(db *mongo.Database) RoleRepository {
+	return &mongoRoleRepository{newMongoCollection[models.Role](db, "roles").withSoftDelete().withVersion()}
+}
+
+func (r *mongoRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	return r.insert(ctx, role)
+}
+
+func (r *mongoRoleRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Role, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoRoleRepository) FindByName(ctx context.Context, name string) (*models.Role, error) {
+	return r.findOne(ctx, bson.M{"name": name})
+}
+
+func (r *mongoRoleRepository) List(ctx context.Context, q ListQuery) (*Page[models.Role], error) {
+	return r.list(ctx, bson.M{}, q)
+}
+
+func (r *mongoRoleRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error {
+	return r.updateVersion(ctx, id, version, bson.M{"$set": bson.M(fields)})
+}
+
+// memoryRoleRepository adalah RoleRepository di memori
+type memoryRoleRepository struct {
+	docs *memoryCollection[models.Role]
+	Trash[models.Role]
+}
+
+func NewMemoryRoleRepository() RoleRepository {
+	docs := newMemoryCollection[models.Role]().withSoftDelete().withVersion()
+	return &memoryRoleRepository{docs: docs, Trash: docs}
+}
+
+func (r *memoryRoleRepository) Create(ctx context.Context, role *models.Role) error {
+	return r.docs.insert(role.ID, *role)
+}
+
+func (r *memoryRoleRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Role, error) {
+	return r.docs.get(id)
+}
+
+func (r *memoryRoleRepository) FindByName(ctx context.Context, name string) (*models.Role, error) {
+	return r.docs.findFirst(func(role *models.Role) bool { return role.Name == name })
+}
+
+func (r *memoryRoleRepository) List(ctx context.Context, q ListQuery) (*Page[models.Role], error) {
+	return r.docs.list(nil, q)
+}
+
+func (r *memoryRoleRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error {
+	return r.docs.updateVersion(id, version, func(role *models.Role) error { return setFields(role, fields) })
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Batas pencarian agar regex yang dikirim ke MongoDB tetap kecil
+const (
+	maxSearchTerms      = 5
+	maxSearchTermLength = 50
+	minFuzzyTermLength  = 4
+	searchCandidates    = 200
+)
+
+// SearchQuery adalah parameter pencarian teks
+type SearchQuery struct {
+	Text   string
+	Limit  int
+	Filter Fields // Filter kesamaan tambahan, sama seperti ListQuery.Filter
+}
+
+// SearchHit adalah satu hasil pencarian beserta skor relevansinya
+type SearchHit[T any] struct {
+	Item  T       `json:"item"`
+	Score float64 `json:"score"`
+}
+
+// searchField adalah field yang ikut dicari beserta bobotnya pada skor. Prefix menandai field
+// yang disalin dalam huruf kecil ke searchCopyField sehingga bisa dicari dengan awalan di MongoDB.
+type searchField struct {
+	Name   string
+	Weight float64
+	Prefix bool
+}
+
+// Field pencarian untuk user dan modul
+var (
+	userSearchFields = []searchField{
+		{"username", 3, true},
+		{"nm_user", 3, true},
+		{"email", 2, true},
+		{"phone", 1, false},
+	}
+	modulSearchFields = []searchField{
+		{"name", 3, true},
+		{"description", 1, false},
+	}
+)
+
+// Skor kecocokan satu kata pada satu field
+const (
+	scoreExact     = 1.0
+	scorePrefix    = 0.75
+	scoreSubstring = 0.5
+	scoreFuzzy     = 0.3
+)
+
+// tokenize memecah teks menjadi kata huruf kecil (pemisahnya semua karakter selain huruf dan angka)
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchTerms mengambil kata pencarian yang unik dengan batas jumlah dan panjang
+func searchTerms(text string) []string {
+	terms := []string{}
+	seen := map[string]bool{}
+	for _, term := range tokenize(text) {
+		// Dipotong per rune agar karakter multibyte tidak terbelah
+		if runes := []rune(term); len(runes) > maxSearchTermLength {
+			term = string(runes[:maxSearchTermLength])
+		}
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// matchTerm memberi skor kecocokan term terhadap kata-kata dalam satu field
+func matchTerm(words []string, value, term string) float64 {
+	best := 0.0
+	for _, word := range words {
+		switch {
+		case word == term:
+			return scoreExact
+		case strings.HasPrefix(word, term):
+			best = maxFloat(best, scorePrefix)
+		case len(term) >= minFuzzyTermLength && fuzzyMatch(word, term):
+			best = maxFloat(best, scoreFuzzy)
+		}
+	}
+	if best < scoreSubstring && strings.Contains(value, term) {
+		best = scoreSubstring
+	}
+	return best
+}
+
+// fuzzyMatch bernilai true jika term berjarak edit satu dari kata atau dari awalan kata
+func fuzzyMatch(word, term string) bool {
+	w, t := []rune(word), []rune(term)
+	for _, n := range []int{len(t) - 1, len(t), len(t) + 1} {
+		if n > 0 && n <= len(w) && editDistance(w[:n], t) <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance menghitung jarak Levenshtein
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = prev[j-1] + cost
+			if prev[j]+1 < current[j] {
+				current[j] = prev[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		prev = current
+	}
+	return prev[len(b)]
+}
+
+// scoreDocument menjumlahkan skor setiap term pada field terbaiknya.
+// Semua term harus cocok dengan minimal satu field, jika tidak skornya 0.
+func scoreDocument(doc bson.M, fields []searchField, terms []string) float64 {
+	total := 0.0
+	for _, term := range terms {
+		best := 0.0
+		for _, field := range fields {
+			value, _ := lookupField(doc, field.Name).(string)
+			value = strings.ToLower(value)
+			if value == "" {
+				continue
+			}
+			best = maxFloat(best, field.Weight*matchTerm(tokenize(value), value, term))
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// searchCopyField adalah sub-dokumen berisi salinan huruf kecil setiap field Prefix.
+// Regex ^ dengan opsi "i" tetap harus memeriksa seluruh key index karena awalan huruf besar
+// dan kecil tersebar di index, dan collation tidak berlaku untuk $regex. Pada salinan huruf
+// kecil, regex ^ tanpa opsi cukup membaca rentang index untuk awalan tersebut.
+const searchCopyField = "search"
+
+func searchCopyPath(field string) string {
+	return searchCopyField + "." + field
+}
+
+// withSearchCopies membuat insert dan update ikut menulis salinan huruf kecil field Prefix
+func (m mongoCollection[T]) withSearchCopies(fields []searchField) mongoCollection[T] {
+	m.searchCopies = nil
+	for _, field := range fields {
+		if field.Prefix {
+			m.searchCopies = append(m.searchCopies, field.Name)
+		}
+	}
+	return m
+}
+
+// searchCopiesOf mengembalikan salinan huruf kecil field Prefix dari dokumen
+func (m mongoCollection[T]) searchCopiesOf(doc bson.M) bson.M {
+	copies := bson.M{}
+	for _, name := range m.searchCopies {
+		value, _ := doc[name].(string)
+		copies[name] = strings.ToLower(value)
+	}
+	return copies
+}
+
+// setSearchCopies menambahkan $set salinan huruf kecil untuk setiap field Prefix yang di-$set
+// oleh update. Update berbentuk pipeline dan field yang tidak di-$set tidak diubah.
+func (m mongoCollection[T]) setSearchCopies(update interface{}) interface{} {
+	doc, ok := update.(bson.M)
+	if !ok || len(m.searchCopies) == 0 {
+		return update
+	}
+	var set bson.M
+	switch value := doc["$set"].(type) {
+	case bson.M:
+		set = value
+	case Fields:
+		set = bson.M(value)
+	default:
+		return update
+	}
+
+	withCopies := bson.M{}
+	for key, value := range set {
+		withCopies[key] = value
+	}
+	changed := false
+	for _, name := range m.searchCopies {
+		if value, ok := set[name].(string); ok {
+			withCopies[searchCopyPath(name)] = strings.ToLower(value)
+			changed = true
+		}
+	}
+	if !changed {
+		return update
+	}
+	out := bson.M{}
+	for key, value := range doc {
+		out[key] = value
+	}
+	out["$set"] = withCopies
+	return out
+}
+
+// prefixFilter mensyaratkan setiap term menjadi awalan salinan huruf kecil salah satu field
+// Prefix. Term dari searchTerms sudah huruf kecil, sehingga regex tidak perlu opsi "i".
+func prefixFilter(fields []searchField, terms []string) bson.M {
+	and := bson.A{}
+	for _, term := range terms {
+		pattern := "^" + regexp.QuoteMeta(term)
+		or := bson.A{}
+		for _, field := range fields {
+			if field.Prefix {
+				or = append(or, bson.M{searchCopyPath(field.Name): bson.M{"$regex": pattern}})
+			}
+		}
+		and = append(and, bson.M{"$or": or})
+	}
+	return bson.M{"$and": and}
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// rankHits mengurutkan hasil berdasarkan skor tertinggi lalu memotongnya sesuai limit
+func rankHits[T any](hits []SearchHit[T], limit int) []SearchHit[T] {
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// searchLimit mengisi nilai bawaan limit pencarian
+func searchLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"project-crud/models"
+)
+
+func TestPrefixFilter(t *testing.T) {
+	got := prefixFilter(modulSearchFields, searchTerms("Sistem C++"))
+	want := bson.M{"$and": bson.A{
+		bson.M{"$or": bson.A{bson.M{"search.name": bson.M{"$regex": "^sistem"}}}},
+		bson.M{"$or": bson.A{bson.M{"search.name": bson.M{"$regex": "^c"}}}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("prefixFilter() = %#v, want %#v", got, want)
+	}
+}
+
+func TestSetSearchCopies(t *testing.T) {
+	users := mongoCollection[models.User]{}.withSearchCopies(userSearchFields)
+
+	tests := []struct {
+		name   string
+		update interface{}
+		want   interface{}
+	}{
+		{
+			name:   "field prefix disalin huruf kecil",
+			update: bson.M{"$set": bson.M{"username": "Budi", "phone": "0812"}},
+			want:   bson.M{"$set": bson.M{"username": "Budi", "phone": "0812", "search.username": "budi"}},
+		},
+		{
+			name:   "Fields",
+			update: bson.M{"$set": Fields{"email": "Budi@Unair.ac.id"}, "$inc": bson.M{"login": 1}},
+			want:   bson.M{"$set": bson.M{"email": "Budi@Unair.ac.id", "search.email": "budi@unair.ac.id"}, "$inc": bson.M{"login": 1}},
+		},
+		{
+			name:   "tanpa field prefix",
+			update: bson.M{"$set": bson.M{"phone": "0812"}},
+			want:   bson.M{"$set": bson.M{"phone": "0812"}},
+		},
+		{
+			name:   "tanpa $set",
+			update: bson.M{"$push": bson.M{"user_modul": "a"}},
+			want:   bson.M{"$push": bson.M{"user_modul": "a"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := users.setSearchCopies(tt.update); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("setSearchCopies() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchCopiesOf(t *testing.T) {
+	moduls := mongoCollection[models.Modul]{}.withSearchCopies(modulSearchFields)
+	doc, err := toDocument(&models.Modul{Name: "Ölçme Dasar", Description: "Tidak Disalin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bson.M{"name": "ölçme dasar"}
+	if got := moduls.searchCopiesOf(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("searchCopiesOf() = %#v, want %#v", got, want)
+	}
+}
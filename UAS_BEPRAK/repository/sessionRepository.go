@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SessionRepository mengelola sesi login dan refresh token
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	// FindByRefreshHash mencari sesi berdasarkan hash refresh token saat ini maupun yang lama
+	FindByRefreshHash(ctx context.Context, hash string) (*models.Session, error)
+	// Rotate mengganti hash refresh token; false jika oldHash sudah tidak berlaku
+	Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt primitive.DateTime) (bool, error)
+	Revoke(ctx context.Context, id primitive.ObjectID) error
+	RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) error
+	// RevokeOthersForUser mencabut semua sesi aktif milik user kecuali keepID
+	RevokeOthersForUser(ctx context.Context, userID, keepID primitive.ObjectID) error
+}
+
+// maxPreviousHashes membatasi jumlah hash refresh token lama yang disimpan per sesi untuk
+// deteksi reuse. Token yang lebih tua dari ini sudah lama tergantikan dan tidak dikenali lagi.
+const maxPreviousHashes = 20
+
+// mongoSessionRepository adalah SessionRepository di koleksi "sessions"
+type mongoSessionRepository struct {
+	mongoCollection[models.Session]
+}
+
+func NewMongoSessionRepository(db *mongo.Database) SessionRepository {
+	return &mongoSessionRepository{newMongoCollection[models.Session](db, "sessions")}
+}
+
+func (r *mongoSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return r.insert(ctx, session)
+}
+
+func (r *mongoSessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoSessionRepository) FindByRefreshHash(ctx context.Context, hash string) (*models.Session, error) {
+	return r.findOne(ctx, bson.M{
+		"$or": []bson.M{{"refresh_token_hash": hash}, {"previous_hashes": hash}},
+	})
+}
+
+func (r *mongoSessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt primitive.DateTime) (bool, error) {
+	result, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "refresh_token_hash": oldHash, "revoked_at": bson.M{"$exists": false}},
+		bson.M{
+			"$set": bson.M{
+				"refresh_token_hash": newHash,
+				"expires_at":         expiresAt,
+				"updated_at":         primitive.NewDateTimeFromTime(timeNow()),
+			},
+			"$push": bson.M{"previous_hashes": bson.M{"$each": bson.A{oldHash}, "$slice": -maxPreviousHashes}},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *mongoSessionRepository) Revoke(ctx context.Context, id primitive.ObjectID) error {
+	now := primitive.NewDateTimeFromTime(timeNow())
+	_, err := r.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": now, "updated_at": now}},
+	)
+	return err
+}
+
+func (r *mongoSessionRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) error {
+	now := primitive.NewDateTimeFromTime(timeNow())
+	_, err := r.coll.UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": now, "updated_at": now}},
+	)
+	return err
+}
+
+func (r *mongoSessionRepository) RevokeOthersForUser(ctx context.Context, userID, keepID primitive.ObjectID) error {
+	now := primitive.NewDateTimeFromTime(timeNow())
+	_, err := r.coll.UpdateMany(ctx,
+		bson.M{"user_id": userID, "_id": bson.M{"$ne": keepID}, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": now, "updated_at": now}},
+	)
+	return err
+}
+
+// memorySessionRepository adalah SessionRepository di memori
+type memorySessionRepository struct {
+	docs *memoryCollection[models.Session]
+}
+
+func NewMemorySessionRepository() SessionRepository {
+	return &memorySessionRepository{newMemoryCollection[models.Session]()}
+}
+
+func (r *memorySessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return r.docs.insert(session.ID, *session)
+}
+
+func (r *memorySessionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	return r.docs.get(id)
+}
+
+func (r *memorySessionRepository) FindByRefreshHash(ctx context.Context, hash string) (*models.Session, error) {
+	return r.docs.findFirst(func(session *models.Session) bool {
+		if session.RefreshTokenHash == hash {
+			return true
+		}
+		for _, previous := range session.PreviousHashes {
+			if previous == hash {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *memorySessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, oldHash, newHash string, expiresAt primitive.DateTime) (bool, error) {
+	rotated := false
+	err := r.docs.update(id, func(session *models.Session) error {
+		if session.RefreshTokenHash != oldHash || session.RevokedAt != nil {
+			return nil
+		}
+		session.PreviousHashes = append(session.PreviousHashes, oldHash)
+		if len(session.PreviousHashes) > maxPreviousHashes {
+			session.PreviousHashes = session.PreviousHashes[len(session.PreviousHashes)-maxPreviousHashes:]
+		}
+		session.RefreshTokenHash = newHash
+		session.ExpiresAt = expiresAt
+		session.UpdatedAt = primitive.NewDateTimeFromTime(timeNow())
+		rotated = true
+		return nil
+	})
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return rotated, err
+}
+
+func (r *memorySessionRepository) Revoke(ctx context.Context, id primitive.ObjectID) error {
+	err := r.docs.update(id, revokeSession)
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (r *memorySessionRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.docs.updateWhere(func(session *models.Session) bool { return session.UserID == userID }, revokeSession)
+	return err
+}
+
+func (r *memorySessionRepository) RevokeOthersForUser(ctx context.Context, userID, keepID primitive.ObjectID) error {
+	_, err := r.docs.updateWhere(func(session *models.Session) bool {
+		return session.UserID == userID && session.ID != keepID
+	}, revokeSession)
+	return err
+}
+
+func revokeSession(session *models.Session) error {
+	if session.RevokedAt == nil {
+		now := primitive.NewDateTimeFromTime(timeNow())
+		session.RevokedAt = &now
+		session.UpdatedAt = now
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactor menjalankan beberapa operasi repository dalam satu transaksi.
+// ctx yang diterima fn harus diteruskan ke setiap pemanggilan repository di dalamnya.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// mongoTransactor memakai transaksi multi-dokumen MongoDB. Transaksi membutuhkan replica set
+// atau sharded cluster; pada mongod standalone fn dijalankan tanpa transaksi.
+type mongoTransactor struct {
+	client *mongo.Client
+
+	checkOnce sync.Once
+	supported bool
+}
+
+func NewMongoTransactor(client *mongo.Client) Transactor {
+	return &mongoTransactor{client: client}
+}
+
+// supportsTransactions memeriksa topologi server sekali pada pemakaian pertama
+func (t *mongoTransactor) supportsTransactions(ctx context.Context) bool {
+	t.checkOnce.Do(func() {
+		var hello struct {
+			SetName string `bson:"setName"`
+			Msg     string `bson:"msg"`
+		}
+		err := t.client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello)
+		if err != nil {
+			// Anggap mendukung, error sebenarnya akan muncul saat transaksi dijalankan
+			t.supported = true
+			return
+		}
+		t.supported = hello.SetName != "" || hello.Msg == "isdbgrid"
+		if !t.supported {
+			log.Println("MongoDB is a standalone server, multi-document operations run without transactions")
+		}
+	})
+	return t.supported
+}
+
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !t.supportsTransactions(ctx) {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// memoryTransactor hanya menjalankan transaksi satu per satu.
+// Penyimpanan memori tidak mendukung rollback jika fn gagal di tengah jalan.
+type memoryTransactor struct {
+	mu sync.Mutex
+}
+
+func NewMemoryTransactor() Transactor {
+	return &memoryTransactor{}
+}
+
+func (t *memoryTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return fn(ctx)
+}
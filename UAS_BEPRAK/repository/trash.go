@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Field penanda soft delete pada setiap entitas
+const (
+	deletedAtField = "deleted_at"
+	deletedByField = "deleted_by"
+)
+
+// Trash diimplementasikan repository yang mendukung soft delete.
+// Dokumen di trash tidak muncul pada FindByID, List, Search dan tidak bisa di-Update.
+type Trash[T any] interface {
+	// SoftDelete memindahkan dokumen ke trash, ErrNotFound jika tidak ada atau sudah di trash
+	SoftDelete(ctx context.Context, id primitive.ObjectID, deletedBy string) error
+	// Restore mengeluarkan dokumen dari trash, ErrNotFound jika dokumen tidak ada di trash
+	Restore(ctx context.Context, id primitive.ObjectID) error
+	// FindDeleted mengembalikan dokumen di trash berdasarkan ID
+	FindDeleted(ctx context.Context, id primitive.ObjectID) (*T, error)
+	// ListDeleted mengembalikan satu halaman dokumen di trash
+	ListDeleted(ctx context.Context, q ListQuery) (*Page[T], error)
+	// PurgeDeleted menghapus permanen dokumen yang masuk trash sebelum before
+	PurgeDeleted(ctx context.Context, before time.Time) ([]primitive.ObjectID, error)
+}
+
+func (m mongoCollection[T]) SoftDelete(ctx context.Context, id primitive.ObjectID, deletedBy string) error {
+	_, err := m.updateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		deletedAtField: primitive.NewDateTimeFromTime(timeNow()),
+		deletedByField: deletedBy,
+	}})
+	return err
+}
+
+func (m mongoCollection[T]) Restore(ctx context.Context, id primitive.ObjectID) error {
+	result, err := m.coll.UpdateOne(ctx,
+		bson.M{"_id": id, deletedAtField: bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{deletedAtField: "", deletedByField: ""}},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m mongoCollection[T]) FindDeleted(ctx context.Context, id primitive.ObjectID) (*T, error) {
+	return m.findOne(ctx, bson.M{"_id": id, deletedAtField: bson.M{"$exists": true}})
+}
+
+func (m mongoCollection[T]) ListDeleted(ctx context.Context, q ListQuery) (*Page[T], error) {
+	return m.list(ctx, bson.M{deletedAtField: bson.M{"$exists": true}}, q)
+}
+
+func (m mongoCollection[T]) PurgeDeleted(ctx context.Context, before time.Time) ([]primitive.ObjectID, error) {
+	filter := bson.M{deletedAtField: bson.M{"$lt": primitive.NewDateTimeFromTime(before)}}
+	cursor, err := m.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	_, err = m.coll.DeleteMany(ctx, bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$in": ids}}}})
+	return ids, err
+}
+
+func (m *memoryCollection[T]) SoftDelete(ctx context.Context, id primitive.ObjectID, deletedBy string) error {
+	deletedAt := primitive.NewDateTimeFromTime(timeNow())
+	return m.update(id, func(doc *T) error {
+		return setFields(doc, Fields{deletedAtField: deletedAt, deletedByField: deletedBy})
+	})
+}
+
+func (m *memoryCollection[T]) Restore(ctx context.Context, id primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	doc, ok := m.docs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if deleted, err := m.inTrash(doc); err != nil || !deleted {
+		return notFoundOr(err)
+	}
+	return m.apply(id, doc, func(doc *T) error {
+		return setFields(doc, Fields{deletedAtField: nil, deletedByField: nil})
+	})
+}
+
+func (m *memoryCollection[T]) FindDeleted(ctx context.Context, id primitive.ObjectID) (*T, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	doc, ok := m.docs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if deleted, err := m.inTrash(doc); err != nil || !deleted {
+		return nil, notFoundOr(err)
+	}
+	cloned, err := clone(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &cloned, nil
+}
+
+func (m *memoryCollection[T]) ListDeleted(ctx context.Context, q ListQuery) (*Page[T], error) {
+	docs, err := m.scan(nil, true)
+	if err != nil {
+		return nil, err
+	}
+	return paginate(docs, q)
+}
+
+func (m *memoryCollection[T]) PurgeDeleted(ctx context.Context, before time.Time) ([]primitive.ObjectID, error) {
+	cutoff := primitive.NewDateTimeFromTime(before)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := []primitive.ObjectID{}
+	kept := []primitive.ObjectID{}
+	for _, id := range m.ids {
+		fields, err := toDocument(m.docs[id])
+		if err != nil {
+			return ids, err
+		}
+		if deletedAt, ok := fields[deletedAtField].(primitive.DateTime); ok && deletedAt < cutoff {
+			ids = append(ids, id)
+			delete(m.docs, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	m.ids = kept
+	return ids, nil
+}
+
+// notFoundOr mengembalikan err jika terisi, selain itu ErrNotFound
+func notFoundOr(err error) error {
+	if err != nil {
+		return err
+	}
+	return ErrNotFound
+}
+
+// purger adalah bagian Trash yang tidak bergantung pada tipe dokumen
+type purger interface {
+	PurgeDeleted(ctx context.Context, before time.Time) ([]primitive.ObjectID, error)
+}
+
+// PurgeDeleted menghapus permanen dokumen semua entitas yang sudah berada di trash
+// lebih lama dari retention, lalu mengembalikan jumlahnya per koleksi
+func (s *Store) PurgeDeleted(ctx context.Context, retention time.Duration) (map[string]int, error) {
+	before := timeNow().Add(-retention)
+	purgers := []struct {
+		name string
+		repo purger
+	}{
+		{"users", s.Users},
+		{"jenis_users", s.JenisUsers},
+		{"moduls", s.Moduls},
+		{"kategori_modul", s.KategoriModuls},
+		{"roles", s.Roles},
+	}
+	purged := map[string]int{}
+	for _, p := range purgers {
+		ids, err := p.repo.PurgeDeleted(ctx, before)
+		if err != nil {
+			return purged, err
+		}
+		if len(ids) > 0 {
+			purged[p.name] = len(ids)
+		}
+	}
+	return purged, nil
+}
+
+// StartPurgeJob menjalankan PurgeDeleted setiap interval di background
+func StartPurgeJob(store *Store, retention, every time.Duration) {
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			purged, err := store.PurgeDeleted(ctx, retention)
+			cancel()
+			if err != nil {
+				log.Println("Trash purge failed:", err)
+				continue
+			}
+			if len(purged) > 0 {
+				log.Println("Purged deleted documents:", purged)
+			}
+		}
+	}()
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+
+	"project-crud/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UserRepository mengelola data user beserta user_modul-nya
+type UserRepository interface {
+	Referrer
+	Trash[models.User]
+
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	Exists(ctx context.Context, id primitive.ObjectID) (bool, error)
+	List(ctx context.Context, q ListQuery) (*Page[models.User], error)
+	// Search mencari user berdasarkan teks dan mengurutkannya sesuai relevansi
+	Search(ctx context.Context, q SearchQuery) ([]SearchHit[models.User], error)
+	// Update mengembalikan ErrVersionConflict jika version bukan AnyVersion dan berbeda dengan versi dokumen
+	Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error
+
+	// AddModul menambahkan modul ke user_modul
+	AddModul(ctx context.Context, userID primitive.ObjectID, modul models.UserModul) error
+	// RemoveModul menghapus modul dari user_modul
+	RemoveModul(ctx context.Context, userID, modulID primitive.ObjectID) error
+	// UpdateModul men-$set fields pada elemen user_modul dengan modul_id tertentu
+	UpdateModul(ctx context.Context, userID, modulID primitive.ObjectID, fields Fields) error
+
+	// Operasi massal untuk menyamakan user_modul dengan template_modul jenis user.
+	// Semuanya mengembalikan ID user yang terdampak dan tidak mengubah data jika dryRun.
+	// Hanya modul dengan source template yang dicabut. Modul manual dan modul data lama yang
+	// belum memiliki source dianggap pemberian khusus dan tidak pernah dicabut.
+
+	// AddTemplateModul menambahkan modul ke semua user dengan jenis user tersebut yang belum memilikinya
+	AddTemplateModul(ctx context.Context, jenisUserID primitive.ObjectID, modul models.UserModul, dryRun bool) ([]primitive.ObjectID, error)
+	// RemoveTemplateModul mencabut modul bersumber template dari semua user dengan jenis user tersebut
+	RemoveTemplateModul(ctx context.Context, jenisUserID, modulID primitive.ObjectID, dryRun bool) ([]primitive.ObjectID, error)
+	// PruneTemplateModuls mencabut modul bersumber template yang tidak ada lagi di keep
+	PruneTemplateModuls(ctx context.Context, jenisUserID primitive.ObjectID, keep []primitive.ObjectID, dryRun bool) ([]primitive.ObjectID, error)
+}
+
+// mongoUserRepository adalah UserRepository di koleksi "users"
+type mongoUserRepository struct {
+	mongoCollection[models.User]
+}
+
+func NewMongoUserRepository(db *mongo.Database) UserRepository {
+	return &mongoUserRepository{newMongoCollection[models.User](db, "users").withSoftDelete().withVersion().withSearchCopies(userSearchFields)}
+}
+
+func (r *mongoUserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.insert(ctx, user)
+}
+
+func (r *mongoUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoUserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"username": username})
+}
+
+func (r *mongoUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) Exists(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	count, err := r.coll.CountDocuments(ctx, r.scope(bson.M{"_id": id}), options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+func (r *mongoUserRepository) List(ctx context.Context, q ListQuery) (*Page[models.User], error) {
+	return r.list(ctx, bson.M{}, q)
+}
+
+func (r *mongoUserRepository) Search(ctx context.Context, q SearchQuery) ([]SearchHit[models.User], error) {
+	return r.search(ctx, userSearchFields, q)
+}
+
+func (r *mongoUserRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error {
+	return r.updateVersion(ctx, id, version, bson.M{"$set": bson.M(fields)})
+}
+
+func (r *mongoUserRepository) AddModul(ctx context.Context, userID primitive.ObjectID, modul models.UserModul) error {
+	_, err := r.updateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$addToSet": bson.M{"user_modul": modul},
+	})
+	return err
+}
+
+func (r *mongoUserRepository) RemoveModul(ctx context.Context, userID, modulID primitive.ObjectID) error {
+	_, err := r.updateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$pull": bson.M{"user_modul": bson.M{"modul_id": modulID}},
+	})
+	return err
+}
+
+func (r *mongoUserRepository) UpdateModul(ctx context.Context, userID, modulID primitive.ObjectID, fields Fields) error {
+	set := bson.M{}
+	for key, value := range fields {
+		set["user_modul.$."+key] = value
+	}
+	_, err := r.updateOne(ctx, bson.M{"_id": userID, "user_modul.modul_id": modulID}, bson.M{"$set": set})
+	return err
+}
+
+func (r *mongoUserRepository) AddTemplateModul(ctx context.Context, jenisUserID primitive.ObjectID, modul models.UserModul, dryRun bool) ([]primitive.ObjectID, error) {
+	filter := bson.M{"jenis_user_id": jenisUserID, "user_modul.modul_id": bson.M{"$ne": modul.ModulID}}
+	// Pipeline update agar user_modul yang masih null pada data lama tetap bisa ditambah
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"user_modul": bson.M{"$concatArrays": bson.A{
+			bson.M{"$ifNull": bson.A{"$user_modul", bson.A{}}},
+			bson.A{bson.M{"$literal": modul}},
+		}},
+	}}}}
+	return r.updateManyIDs(ctx, filter, update, dryRun)
+}
+
+func (r *mongoUserRepository) RemoveTemplateModul(ctx context.Context, jenisUserID, modulID primitive.ObjectID, dryRun bool) ([]primitive.ObjectID, error) {
+	match := bson.M{"modul_id": modulID, "source": models.UserModulSourceTemplate}
+	filter := bson.M{"jenis_user_id": jenisUserID, "user_modul": bson.M{"$elemMatch": match}}
+	return r.updateManyIDs(ctx, filter, bson.M{"$pull": bson.M{"user_modul": match}}, dryRun)
+}
+
+func (r *mongoUserRepository) PruneTemplateModuls(ctx context.Context, jenisUserID primitive.ObjectID, keep []primitive.ObjectID, dryRun bool) ([]primitive.ObjectID, error) {
+	if keep == nil {
+		keep = []primitive.ObjectID{}
+	}
+	match := bson.M{"source": models.UserModulSourceTemplate, "modul_id": bson.M{"$nin": keep}}
+	filter := bson.M{"jenis_user_id": jenisUserID, "user_modul": bson.M{"$elemMatch": match}}
+	return r.updateManyIDs(ctx, filter, bson.M{"$pull": bson.M{"user_modul": match}}, dryRun)
+}
+
+// memoryUserRepository adalah UserRepository di memori
+type memoryUserRepository struct {
+	docs *memoryCollection[models.User]
+	Referrer
+	Trash[models.User]
+}
+
+func NewMemoryUserRepository() UserRepository {
+	docs := newMemoryCollection[models.User]().withSoftDelete().withVersion()
+	return &memoryUserRepository{docs: docs, Referrer: docs, Trash: docs}
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.docs.insert(user.ID, *user)
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return r.docs.get(id)
+}
+
+func (r *memoryUserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.docs.findFirst(func(user *models.User) bool { return user.Username == username })
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.docs.findFirst(func(user *models.User) bool { return user.Email == email })
+}
+
+func (r *memoryUserRepository) Exists(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	_, err := r.docs.get(id)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, q ListQuery) (*Page[models.User], error) {
+	return r.docs.list(nil, q)
+}
+
+func (r *memoryUserRepository) Search(ctx context.Context, q SearchQuery) ([]SearchHit[models.User], error) {
+	return r.docs.search(userSearchFields, q)
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, id primitive.ObjectID, version int64, fields Fields) error {
+	return r.docs.updateVersion(id, version, func(user *models.User) error { return setFields(user, fields) })
+}
+
+func (r *memoryUserRepository) AddModul(ctx context.Context, userID primitive.ObjectID, modul models.UserModul) error {
+	return r.docs.update(userID, func(user *models.User) error {
+		user.UserModul = append(user.UserModul, modul)
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) RemoveModul(ctx context.Context, userID, modulID primitive.ObjectID) error {
+	return r.docs.update(userID, func(user *models.User) error {
+		kept := []models.UserModul{}
+		for _, modul := range user.UserModul {
+			if modul.ModulID != modulID {
+				kept = append(kept, modul)
+			}
+		}
+		user.UserModul = kept
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) UpdateModul(ctx context.Context, userID, modulID primitive.ObjectID, fields Fields) error {
+	return r.docs.update(userID, func(user *models.User) error {
+		for i := range user.UserModul {
+			if user.UserModul[i].ModulID == modulID {
+				return setFields(&user.UserModul[i], fields)
+			}
+		}
+		return ErrNotFound
+	})
+}
+
+func (r *memoryUserRepository) AddTemplateModul(ctx context.Context, jenisUserID primitive.ObjectID, modul models.UserModul, dryRun bool) ([]primitive.ObjectID, error) {
+	return r.docs.updateMatching(func(user *models.User) bool {
+		return user.JenisUserID == jenisUserID && !hasUserModul(user.UserModul, modul.ModulID)
+	}, func(user *models.User) error {
+		user.UserModul = append(user.UserModul, modul)
+		return nil
+	}, dryRun)
+}
+
+func (r *memoryUserRepository) RemoveTemplateModul(ctx context.Context, jenisUserID, modulID primitive.ObjectID, dryRun bool) ([]primitive.ObjectID, error) {
+	remove := func(modul models.UserModul) bool {
+		return modul.ModulID == modulID && modul.Source == models.UserModulSourceTemplate
+	}
+	return r.removeModulsWhere(jenisUserID, remove, dryRun)
+}
+
+func (r *memoryUserRepository) PruneTemplateModuls(ctx context.Context, jenisUserID primitive.ObjectID, keep []primitive.ObjectID, dryRun bool) ([]primitive.ObjectID, error) {
+	remove := func(modul models.UserModul) bool {
+		if modul.Source != models.UserModulSourceTemplate {
+			return false
+		}
+		for _, id := range keep {
+			if modul.ModulID == id {
+				return false
+			}
+		}
+		return true
+	}
+	return r.removeModulsWhere(jenisUserID, remove, dryRun)
+}
+
+// removeModulsWhere mencabut elemen user_modul yang cocok dengan remove (seperti $pull bersyarat)
+func (r *memoryUserRepository) removeModulsWhere(jenisUserID primitive.ObjectID, remove func(models.UserModul) bool, dryRun bool) ([]primitive.ObjectID, error) {
+	return r.docs.updateMatching(func(user *models.User) bool {
+		if user.JenisUserID != jenisUserID {
+			return false
+		}
+		for _, modul := range user.UserModul {
+			if remove(modul) {
+				return true
+			}
+		}
+		return false
+	}, func(user *models.User) error {
+		kept := []models.UserModul{}
+		for _, modul := range user.UserModul {
+			if !remove(modul) {
+				kept = append(kept, modul)
+			}
+		}
+		user.UserModul = kept
+		return nil
+	}, dryRun)
+}
+
+func hasUserModul(moduls []models.UserModul, modulID primitive.ObjectID) bool {
+	for _, modul := range moduls {
+		if modul.ModulID == modulID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Field versi dokumen untuk optimistic concurrency
+const versionField = "version"
+
+// AnyVersion dipakai sebagai versi yang diharapkan jika update tidak bersyarat
+const AnyVersion int64 = -1
+
+// versionOf membaca field version dari dokumen BSON, dokumen lama tanpa version dianggap versi 0
+func versionOf(doc bson.M) int64 {
+	switch v := doc[versionField].(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return 0
+}
+
+// withVersion membuat setiap update menaikkan field version sebanyak 1
+func (m mongoCollection[T]) withVersion() mongoCollection[T] {
+	m.versioned = true
+	return m
+}
+
+// incVersion menambahkan $inc version pada update jika koleksi memakai versi. Update berbentuk
+// pipeline tidak mengenal $inc, sehingga version dinaikkan lewat stage $set tambahan.
+func (m mongoCollection[T]) incVersion(update interface{}) interface{} {
+	if !m.versioned {
+		return update
+	}
+	if pipeline, ok := update.(mongo.Pipeline); ok {
+		return append(append(mongo.Pipeline{}, pipeline...), bson.D{{Key: "$set", Value: bson.M{
+			versionField: bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + versionField, 0}}, 1}},
+		}}})
+	}
+	doc, ok := update.(bson.M)
+	if !ok {
+		return update
+	}
+	withInc := bson.M{}
+	for key, value := range doc {
+		withInc[key] = value
+	}
+	inc := bson.M{versionField: 1}
+	if existing, ok := doc["$inc"].(bson.M); ok {
+		for key, value := range existing {
+			inc[key] = value
+		}
+	}
+	withInc["$inc"] = inc
+	return withInc
+}
+
+// updateVersion menjalankan update pada dokumen id hanya jika versinya sama dengan version.
+// Mengembalikan ErrVersionConflict jika dokumen ada tetapi versinya sudah berubah.
+func (m mongoCollection[T]) updateVersion(ctx context.Context, id primitive.ObjectID, version int64, update bson.M) error {
+	filter := bson.M{"_id": id}
+	if version == 0 {
+		// Dokumen lama belum memiliki field version
+		filter[versionField] = bson.M{"$in": bson.A{0, nil}}
+	} else if version != AnyVersion {
+		filter[versionField] = version
+	}
+
+	_, err := m.updateOne(ctx, filter, update)
+	if err != ErrNotFound || version == AnyVersion {
+		return err
+	}
+	if _, err := m.findOne(ctx, bson.M{"_id": id}); err != nil {
+		return err
+	}
+	return ErrVersionConflict
+}
+
+// withVersion membuat setiap update menaikkan field version sebanyak 1
+func (m *memoryCollection[T]) withVersion() *memoryCollection[T] {
+	m.versioned = true
+	return m
+}
+
+// bumpVersion menaikkan version pada representasi BSON dokumen
+func (m *memoryCollection[T]) bumpVersion(doc bson.M) {
+	if m.versioned {
+		doc[versionField] = versionOf(doc) + 1
+	}
+}
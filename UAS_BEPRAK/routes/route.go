@@ -3,63 +3,130 @@ package routes
 import (
 	"project-crud/controllers"
 	"project-crud/middleware"
+	"project-crud/models"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/gofiber/fiber/v2/middleware/etag"
 )
 
-func RouterApp(app *fiber.App) {
+func RouterApp(app *fiber.App, ctrl *controllers.Controller, auth *middleware.Auth) {
+
+    // Public key untuk verifikasi token oleh aplikasi modul
+    app.Get("/.well-known/jwks.json", ctrl.JWKS)
+
+    // Provider OpenID Connect untuk aplikasi modul, format endpoint mengikuti standar OAuth
+    app.Get("/.well-known/openid-configuration", ctrl.OIDCDiscovery)
+    app.Get("/oauth/authorize", ctrl.OIDCAuthorize)
+    app.Post("/oauth/token", ctrl.OIDCToken)
+    app.Get("/oauth/userinfo", ctrl.OIDCUserInfo)
+    app.Post("/oauth/userinfo", ctrl.OIDCUserInfo)
 
     // API (Format)
     api := app.Group("/api")
 
     // Route untuk login (ALL USER)
-    api.Post("/login", controllers.Login)
+    api.Post("/login", ctrl.Login)
+    api.Post("/login/mfa", ctrl.LoginMFA)
+    api.Post("/login/mfa/enroll", ctrl.LoginMFAEnroll)
+    api.Post("/password/forgot", ctrl.ForgotPassword)
+    api.Post("/password/reset", ctrl.ResetPassword)
+    api.Post("/refresh", ctrl.RefreshToken)
+    api.Post("/logout", auth.JWTAuth, ctrl.Logout)
+
+    // Halaman persetujuan portal untuk permintaan authorize aplikasi modul
+    api.Get("/oauth/authorize", auth.JWTAuth, ctrl.GetOIDCAuthorization)
+    api.Post("/oauth/authorize", auth.JWTAuth, ctrl.DecideOIDCAuthorization)
 
-    // Grup route untuk admin
-    objectID, _ := primitive.ObjectIDFromHex("675d1cd023322aa0cdbdfdbd")
-    adminGroup := api.Group("/admin", middleware.JWTAuth,middleware.CheckRole(objectID))
+    // Grup route untuk admin, setiap route memeriksa permission role masing-masing
+    adminGroup := api.Group("/admin", auth.JWTAuth)
+    can := auth.CheckPermission
     
-    // CRUD ROLE (5 ROUTE)
-    adminGroup.Post("/create-roles", controllers.CreateRole)
-    adminGroup.Get("/get-roles", controllers.GetRoles)
-    adminGroup.Get("/get-roles/:id", controllers.GetRole)
-    adminGroup.Put("/edit-roles/:id", controllers.EditRole)
-    adminGroup.Delete("/delete-roles/:id", controllers.DeleteRole)
-
-    // CRUD KATEGORI MODUL (5 ROUTE)
-    adminGroup.Post("/create-kategorimoduls", controllers.CreateKategoriModul)
-    adminGroup.Get("/get-kategorimoduls", controllers.GetAllKategoriModul)
-    adminGroup.Get("/get-kategorimodul/:id", controllers.GetKategoriModulByID)
-    adminGroup.Put("/edit-kategorimoduls/:id", controllers.EditKategoriModul)
-    adminGroup.Delete("/delete-kategorimoduls/:id", controllers.DeleteKategoriModul)
-
-    // CRUD MODUL (5 ROUTE)
-    adminGroup.Post("/create-moduls", controllers.CreateModul)
-    adminGroup.Get("/get-moduls", controllers.GetAllModul)
-    adminGroup.Get("/get-modul/:id", controllers.GetModulByID)
-    adminGroup.Put("/edit-moduls/:id", controllers.EditModul)
-    adminGroup.Delete("/delete-moduls/:id", controllers.DeleteModul)
-
-    // CRUD JENIS USER (6 ROUTE)
-    adminGroup.Post("/create-jenis-user", controllers.CreateJenisUser)
-    adminGroup.Get("/get-jenis-users", controllers.GetAllJenisUser)
-    adminGroup.Get("/get-jenis-user/:id", controllers.GetJenisUserByID)
-    adminGroup.Put("/edit-jenis-user/:id", controllers.EditJenisUser)
-    adminGroup.Delete("/delete-templatemodul-jenisuser/:id", controllers.DeleteTemplateModul)
-    adminGroup.Delete("/delete-jenis-user/:id", controllers.DeleteJenisUser)
-
-    // CRUD USER (8 ROUTE)
-    adminGroup.Post("/create-user", controllers.CreateUser)
-    adminGroup.Get("/get-users", controllers.GetAllUsers)
-    adminGroup.Get("/get-user/:id", controllers.GetUserByID)
-    adminGroup.Put("/edit-user/:id", controllers.EditUser)
-    adminGroup.Put("/update-jenisuser/:id", controllers.EditJenisUserFromUser)
-    adminGroup.Post("/add-moduluser-tertentu", controllers.AddUserModule)
-    adminGroup.Delete("/delete-moduluser-tertentu", controllers.RemoveUserModule)
-    adminGroup.Delete("/delete-user/:id", controllers.DeleteUser)
-
-
-    // Grup route untuk CIVITAS
+    // CRUD ROLE (8 ROUTE)
+    adminGroup.Post("/create-roles", can(models.PermRoleCreate), ctrl.CreateRole)
+    adminGroup.Get("/get-roles", can(models.PermRoleRead), ctrl.GetRoles)
+    adminGroup.Get("/get-roles/:id", can(models.PermRoleRead), ctrl.GetRole)
+    adminGroup.Put("/edit-roles/:id", can(models.PermRoleUpdate), ctrl.EditRole)
+    adminGroup.Delete("/delete-roles/:id", can(models.PermRoleDelete), ctrl.DeleteRole)
+    adminGroup.Get("/get-trash-roles", can(models.PermRoleDelete), ctrl.GetTrashRoles)
+    adminGroup.Post("/restore-roles/:id", can(models.PermRoleDelete), ctrl.RestoreRole)
+    adminGroup.Get("/get-permissions", can(models.PermRoleRead), ctrl.GetPermissions)
+
+    // CRUD KATEGORI MODUL (7 ROUTE)
+    adminGroup.Post("/create-kategorimoduls", can(models.PermKategoriModulCreate), ctrl.CreateKategoriModul)
+    adminGroup.Get("/get-kategorimoduls", can(models.PermKategoriModulRead), ctrl.GetAllKategoriModul)
+    adminGroup.Get("/get-kategorimodul/:id", can(models.PermKategoriModulRead), ctrl.GetKategoriModulByID)
+    adminGroup.Put("/edit-kategorimoduls/:id", can(models.PermKategoriModulUpdate), ctrl.EditKategoriModul)
+    adminGroup.Delete("/delete-kategorimoduls/:id", can(models.PermKategoriModulDelete), ctrl.DeleteKategoriModul)
+    adminGroup.Get("/get-trash-kategorimoduls", can(models.PermKategoriModulDelete), ctrl.GetTrashKategoriModuls)
+    adminGroup.Post("/restore-kategorimoduls/:id", can(models.PermKategoriModulDelete), ctrl.RestoreKategoriModul)
+
+    // CRUD MODUL (11 ROUTE)
+    adminGroup.Post("/create-moduls", can(models.PermModulCreate), ctrl.CreateModul)
+    adminGroup.Get("/get-moduls", can(models.PermModulRead), ctrl.GetAllModul)
+    adminGroup.Get("/get-modul/:id", can(models.PermModulRead), ctrl.GetModulByID)
+    adminGroup.Get("/search-moduls", can(models.PermModulRead), ctrl.SearchModuls)
+    adminGroup.Put("/edit-moduls/:id", can(models.PermModulUpdate), ctrl.EditModul)
+    adminGroup.Delete("/delete-moduls/:id", can(models.PermModulDelete), ctrl.DeleteModul)
+    adminGroup.Get("/get-trash-moduls", can(models.PermModulDelete), ctrl.GetTrashModuls)
+    adminGroup.Post("/restore-moduls/:id", can(models.PermModulDelete), ctrl.RestoreModul)
+    adminGroup.Get("/get-modul-revisions/:id", can(models.PermModulRead), ctrl.GetModulRevisions)
+    adminGroup.Get("/diff-modul-revisions/:id", can(models.PermModulRead), ctrl.DiffModulRevisions)
+    adminGroup.Post("/rollback-moduls/:id", can(models.PermModulUpdate), ctrl.RollbackModul)
+
+    // CRUD JENIS USER (12 ROUTE)
+    adminGroup.Post("/create-jenis-user", can(models.PermJenisUserCreate), ctrl.CreateJenisUser)
+    adminGroup.Get("/get-jenis-users", can(models.PermJenisUserRead), ctrl.GetAllJenisUser)
+    adminGroup.Get("/get-jenis-user/:id", can(models.PermJenisUserRead), ctrl.GetJenisUserByID)
+    adminGroup.Put("/edit-jenis-user/:id", can(models.PermJenisUserUpdate), ctrl.EditJenisUser)
+    adminGroup.Delete("/delete-templatemodul-jenisuser/:id", can(models.PermJenisUserUpdate), ctrl.DeleteTemplateModul)
+    adminGroup.Post("/sync-jenis-user/:id", can(models.PermJenisUserUpdate), ctrl.SyncJenisUser)
+    adminGroup.Delete("/delete-jenis-user/:id", can(models.PermJenisUserDelete), ctrl.DeleteJenisUser)
+    adminGroup.Get("/get-trash-jenis-users", can(models.PermJenisUserDelete), ctrl.GetTrashJenisUsers)
+    adminGroup.Post("/restore-jenis-user/:id", can(models.PermJenisUserDelete), ctrl.RestoreJenisUser)
+    adminGroup.Get("/get-jenis-user-revisions/:id", can(models.PermJenisUserRead), ctrl.GetJenisUserRevisions)
+    adminGroup.Get("/diff-jenis-user-revisions/:id", can(models.PermJenisUserRead), ctrl.DiffJenisUserRevisions)
+    adminGroup.Post("/rollback-jenis-user/:id", can(models.PermJenisUserUpdate), ctrl.RollbackJenisUser)
+
+    // CRUD USER (14 ROUTE)
+    adminGroup.Post("/create-user", can(models.PermUserCreate), ctrl.CreateUser)
+    adminGroup.Get("/get-users", can(models.PermUserRead), ctrl.GetAllUsers)
+    adminGroup.Get("/get-user/:id", can(models.PermUserRead), ctrl.GetUserByID)
+    adminGroup.Get("/search-users", can(models.PermUserRead), ctrl.SearchUsers)
+    adminGroup.Put("/edit-user/:id", can(models.PermUserUpdate), ctrl.EditUser)
+    adminGroup.Put("/update-jenisuser/:id", can(models.PermUserUpdate), ctrl.EditJenisUserFromUser)
+    adminGroup.Put("/update-roleuser/:id", can(models.PermUserUpdate), can(models.PermRoleUpdate), ctrl.EditRoleFromUser)
+    adminGroup.Post("/add-moduluser-tertentu", can(models.PermUserUpdate), ctrl.AddUserModule)
+    adminGroup.Delete("/delete-moduluser-tertentu", can(models.PermUserUpdate), ctrl.RemoveUserModule)
+    adminGroup.Delete("/delete-user/:id", can(models.PermUserDelete), ctrl.DeleteUser)
+    adminGroup.Get("/get-trash-users", can(models.PermUserDelete), ctrl.GetTrashUsers)
+    adminGroup.Post("/restore-user/:id", can(models.PermUserDelete), ctrl.RestoreUser)
+    adminGroup.Post("/unlock-user/:id", can(models.PermUserUpdate), ctrl.UnlockUser)
+    adminGroup.Post("/reset-mfa-user/:id", can(models.PermMFAReset), ctrl.ResetUserMFA)
+
+    // OAUTH CLIENT MODUL (5 ROUTE)
+    adminGroup.Post("/create-oauth-client/:id", can(models.PermModulUpdate), ctrl.CreateOAuthClient)
+    adminGroup.Get("/get-oauth-client/:id", can(models.PermModulRead), ctrl.GetOAuthClient)
+    adminGroup.Put("/edit-oauth-client/:id", can(models.PermModulUpdate), ctrl.EditOAuthClient)
+    adminGroup.Post("/rotate-oauth-client-secret/:id", can(models.PermModulUpdate), ctrl.RotateOAuthClientSecret)
+    adminGroup.Delete("/delete-oauth-client/:id", can(models.PermModulUpdate), ctrl.DeleteOAuthClient)
+
+    // AUDIT LOG (2 ROUTE)
+    adminGroup.Get("/audit", can(models.PermAuditRead), ctrl.GetAuditLogs)
+    adminGroup.Get("/login-records", can(models.PermAuditRead), ctrl.GetLoginRecords)
+
 
+    // Grup route untuk CIVITAS, cukup login karena hanya mengakses data milik sendiri
+    meGroup := api.Group("/me", auth.JWTAuth)
+    meGroup.Get("/", ctrl.GetMe)
+    meGroup.Patch("/", ctrl.UpdateMe)
+    meGroup.Post("/password", ctrl.ChangeMyPassword)
+    meGroup.Get("/modules", ctrl.GetMyModules)
+    meGroup.Get("/dashboard", etag.New(), ctrl.GetMyDashboard)
+    meGroup.Get("/mfa", ctrl.GetMyMFA)
+    meGroup.Post("/mfa/enroll", ctrl.StartMyMFAEnrollment)
+    meGroup.Post("/mfa/confirm", ctrl.ConfirmMyMFA)
+    meGroup.Post("/mfa/recovery-codes", ctrl.RegenerateMyRecoveryCodes)
+    meGroup.Delete("/mfa", ctrl.DisableMyMFA)
+    meGroup.Get("/oauth-consents", ctrl.GetMyOAuthConsents)
+    meGroup.Delete("/oauth-consents/:client_id", ctrl.RevokeMyOAuthConsent)
 }
\ No newline at end of file
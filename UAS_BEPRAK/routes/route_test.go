@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"project-crud/apperror"
+	"project-crud/controllers"
+	"project-crud/middleware"
+	"project-crud/models"
+	"project-crud/password"
+	"project-crud/repository"
+)
+
+// newTestApp merakit app seperti main, tetapi di atas repository memori dan folder kunci sementara
+func newTestApp(t *testing.T, cfg middleware.Config) (*fiber.App, *repository.Store) {
+	t.Helper()
+	cfg.KeyDir = t.TempDir()
+	store := repository.NewMemoryStore()
+	auth := middleware.NewAuth(store, cfg)
+	if err := auth.LoadSigningKeys(); err != nil {
+		t.Fatal(err)
+	}
+	ctrl := controllers.NewController(store, auth)
+	ctrl.Hasher = password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost})
+
+	app := fiber.New(fiber.Config{ErrorHandler: apperror.Handler(false)})
+	app.Use(middleware.TraceID)
+	RouterApp(app, ctrl, auth)
+	return app, store
+}
+
+// seedLoginUser menyimpan user "budi" dengan password "Rahasia#123" dan role berisi permissions
+func seedLoginUser(t *testing.T, store *repository.Store, permissions ...string) {
+	t.Helper()
+	ctx := context.Background()
+	role := models.Role{ID: primitive.NewObjectID(), Name: "staf", Permissions: permissions}
+	if err := store.Roles.Create(ctx, &role); err != nil {
+		t.Fatal(err)
+	}
+	jenisUser := models.JenisUser{ID: primitive.NewObjectID(), NmJenisUser: "tendik", TemplateModul: []models.TemplateModul{}}
+	if err := store.JenisUsers.Create(ctx, &jenisUser); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := password.Bcrypt{Cost: bcrypt.MinCost}.Hash("Rahasia#123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{
+		ID:          primitive.NewObjectID(),
+		Username:    "budi",
+		Email:       "budi@unair.ac.id",
+		Pass:        hash,
+		RoleID:      role.ID,
+		JenisUserID: jenisUser.ID,
+		UserModul:   []models.UserModul{},
+	}
+	if err := store.Users.Create(ctx, &user); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// send mengirim request JSON dengan bearer token (jika ada) dan mengembalikan status serta body
+func send(t *testing.T, app *fiber.App, method, path, token string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	out := map[string]interface{}{}
+	json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+// login masuk sebagai budi dan mengembalikan access token
+func login(t *testing.T, app *fiber.App) string {
+	t.Helper()
+	status, body := send(t, app, "POST", "/api/login", "", fiber.Map{"username": "budi", "pass": "Rahasia#123"})
+	token, _ := body["token"].(string)
+	if status != fiber.StatusOK || token == "" {
+		t.Fatalf("login = %d %v, want 200 with a token", status, body)
+	}
+	return token
+}
+
+func TestRouterAppWiring(t *testing.T) {
+	app, store := newTestApp(t, middleware.DefaultConfig())
+	seedLoginUser(t, store, models.PermUserRead)
+	token := login(t, app)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"jwks publik", "GET", "/.well-known/jwks.json", "", fiber.StatusOK},
+		{"tanpa token", "GET", "/api/me/", "", fiber.StatusUnauthorized},
+		{"profil sendiri", "GET", "/api/me/", token, fiber.StatusOK},
+		{"permission dimiliki", "GET", "/api/admin/get-users", token, fiber.StatusOK},
+		{"permission tidak dimiliki", "GET", "/api/admin/get-roles", token, fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, body := send(t, app, tt.method, tt.path, tt.token, nil); status != tt.wantStatus {
+				t.Errorf("%s %s = %d %v, want %d", tt.method, tt.path, status, body, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRouterAppIndependentAuth(t *testing.T) {
+	// Dua app dengan konfigurasi berbeda di proses yang sama tidak boleh saling memengaruhi
+	cfgA := middleware.DefaultConfig()
+	cfgB := middleware.DefaultConfig()
+	cfgB.TokenIssuer = "lain"
+	cfgB.TokenAlgorithm = middleware.AlgRS256
+
+	appA, storeA := newTestApp(t, cfgA)
+	appB, storeB := newTestApp(t, cfgB)
+	seedLoginUser(t, storeA)
+	seedLoginUser(t, storeB)
+
+	tokenA, tokenB := login(t, appA), login(t, appB)
+	for _, tt := range []struct {
+		name       string
+		app        *fiber.App
+		token      string
+		wantStatus int
+	}{
+		{"token A di app A", appA, tokenA, fiber.StatusOK},
+		{"token B di app B", appB, tokenB, fiber.StatusOK},
+		{"token A di app B", appB, tokenA, fiber.StatusUnauthorized},
+		{"token B di app A", appA, tokenB, fiber.StatusUnauthorized},
+	} {
+		if status, _ := send(t, tt.app, "GET", "/api/me/", tt.token, nil); status != tt.wantStatus {
+			t.Errorf("%s = %d, want %d", tt.name, status, tt.wantStatus)
+		}
+	}
+}
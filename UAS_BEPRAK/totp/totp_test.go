@@ -0,0 +1,89 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// Secret ASCII "12345678901234567890" dari test vector RFC 6238 (SHA-1) dalam base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// Kode RFC 6238 memakai 8 digit, yang dibandingkan 6 digit terakhirnya
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := Step(now)
+	code := func(offset int64) string {
+		c, err := Code(rfcSecret, step+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		skew     int
+		wantOK   bool
+		wantStep int64
+	}{
+		{"periode sekarang", rfcSecret, code(0), 1, true, step},
+		{"periode sebelumnya dalam skew", rfcSecret, code(-1), 1, true, step - 1},
+		{"periode berikutnya dalam skew", rfcSecret, code(1), 1, true, step + 1},
+		{"di luar skew", rfcSecret, code(-2), 1, false, 0},
+		{"tanpa skew hanya periode sekarang", rfcSecret, code(1), 0, false, 0},
+		{"spasi diabaikan", rfcSecret, code(0)[:3] + " " + code(0)[3:], 1, true, step},
+		{"secret huruf kecil dan padding", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq====", code(0), 1, true, step},
+		{"kode salah", rfcSecret, "000000", 1, false, 0},
+		{"kode terlalu pendek", rfcSecret, code(0)[:5], 1, false, 0},
+		{"kode terlalu panjang", rfcSecret, code(0) + "1", 1, false, 0},
+		{"kode kosong", rfcSecret, "", 1, false, 0},
+		{"secret tidak valid", "not base32!", code(0), 1, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := Verify(tt.secret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("Verify() = (%d, %v), want (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Code(secret, 1); err != nil {
+		t.Errorf("generated secret is not usable: %v", err)
+	}
+	other, _ := GenerateSecret()
+	if secret == other {
+		t.Error("two generated secrets are equal")
+	}
+}
@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"context"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// phonePattern menerima awalan + dan pemisah spasi atau tanda hubung
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 -]{5,19}$`)
+
+var builtinRules = map[string]ruleDef{
+	"min":      {check: checkMin, message: "must be at least %s"},
+	"max":      {check: checkMax, message: "must be at most %s"},
+	"email":    {check: stringRule(ValidEmail), message: "must be a valid email address"},
+	"url":      {check: stringRule(validURL), message: "must be an absolute http or https URL"},
+	"objectid": {check: checkObjectID, message: "must be a valid ObjectID"},
+	"oneof":    {check: checkOneOf, message: "must be one of: %s"},
+	"phone":    {check: stringRule(ValidPhone), message: "must be a valid phone number"},
+}
+
+// ObjectID mengambil ObjectID dari field bertipe primitive.ObjectID atau string hex.
+// Dipakai juga oleh rule kustom seperti pemeriksaan keberadaan dokumen.
+func ObjectID(value reflect.Value) (primitive.ObjectID, bool) {
+	if id, ok := value.Interface().(primitive.ObjectID); ok {
+		return id, !id.IsZero()
+	}
+	if value.Kind() == reflect.String {
+		id, err := primitive.ObjectIDFromHex(value.String())
+		return id, err == nil
+	}
+	return primitive.NilObjectID, false
+}
+
+func stringRule(check func(string) bool) Rule {
+	return func(ctx context.Context, value reflect.Value, param string) (bool, error) {
+		if value.Kind() != reflect.String {
+			return false, nil
+		}
+		return check(value.String()), nil
+	}
+}
+
+// size adalah panjang string (dalam karakter), panjang slice, atau nilai angka
+func size(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+func checkMin(ctx context.Context, value reflect.Value, param string) (bool, error) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false, err
+	}
+	n, ok := size(value)
+	return ok && n >= limit, nil
+}
+
+func checkMax(ctx context.Context, value reflect.Value, param string) (bool, error) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false, err
+	}
+	n, ok := size(value)
+	return ok && n <= limit, nil
+}
+
+func checkObjectID(ctx context.Context, value reflect.Value, param string) (bool, error) {
+	_, ok := ObjectID(value)
+	return ok, nil
+}
+
+// checkOneOf menerima daftar nilai yang dipisah spasi, misalnya oneof=L P
+func checkOneOf(ctx context.Context, value reflect.Value, param string) (bool, error) {
+	if value.Kind() != reflect.String {
+		return false, nil
+	}
+	for _, allowed := range strings.Fields(param) {
+		if value.String() == allowed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ValidEmail hanya menerima alamat tunggal tanpa nama tampilan
+func ValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// ValidPhone memeriksa format nomor telepon
+func ValidPhone(phone string) bool {
+	return phonePattern.MatchString(phone)
+}
+
+func validURL(raw string) bool {
+	parsed, err := url.ParseRequestURI(raw)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
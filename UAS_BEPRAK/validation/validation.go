@@ -0,0 +1,271 @@
+// Package validation memeriksa DTO request berdasarkan tag `validate` pada field struct.
+//
+// Tag berisi daftar rule dipisah koma, parameter ditulis setelah tanda sama dengan:
+//
+//	Email string `json:"email" validate:"required,email,unique=users.email"`
+//
+// Field yang kosong (nil, string kosong, nilai nol) hanya diperiksa oleh rule required,
+// rule lain dilewati. Untuk DTO update dengan field pointer, notblank menolak field yang
+// dikirim tetapi kosong. Rule setelah dive diterapkan ke setiap elemen slice, dan field
+// bertipe struct atau slice of struct diperiksa secara rekursif.
+package validation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// FieldError menjelaskan satu field yang gagal validasi. Code adalah nama rule
+// sehingga bisa dipakai klien tanpa membaca Message.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors adalah kumpulan FieldError dari satu kali validasi
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		parts = append(parts, fieldErr.Field+": "+fieldErr.Code)
+	}
+	return "validation failed: " + strings.Join(parts, ", ")
+}
+
+// Rule memeriksa satu nilai field yang tidak kosong. Pointer sudah di-dereference.
+// Error dikembalikan untuk kegagalan di luar validasi, misalnya database tidak bisa diakses.
+type Rule func(ctx context.Context, value reflect.Value, param string) (bool, error)
+
+type ruleDef struct {
+	check   Rule
+	message string
+}
+
+// Validator menyimpan rule yang bisa dipakai pada tag. Aman dipakai bersamaan
+// setelah semua rule didaftarkan.
+type Validator struct {
+	mu    sync.RWMutex
+	rules map[string]ruleDef
+}
+
+// New membuat Validator dengan rule bawaan: required, min, max, email, url,
+// objectid, oneof dan phone
+func New() *Validator {
+	v := &Validator{rules: map[string]ruleDef{}}
+	for name, def := range builtinRules {
+		v.rules[name] = def
+	}
+	return v
+}
+
+// Register menambahkan rule baru. message boleh berisi %s untuk parameter rule.
+func (v *Validator) Register(name string, rule Rule, message string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules[name] = ruleDef{check: rule, message: message}
+}
+
+// Struct memeriksa semua field s (struct atau pointer ke struct). Hasilnya nil,
+// Errors jika ada field yang tidak valid, atau error lain dari rule.
+func (v *Validator) Struct(ctx context.Context, s interface{}) error {
+	value := reflect.ValueOf(s)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return fmt.Errorf("validation: nil %s", value.Type())
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validation: %s is not a struct", value.Type())
+	}
+
+	errs := Errors{}
+	if err := v.validateStruct(ctx, value, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (v *Validator) validateStruct(ctx context.Context, value reflect.Value, prefix string, errs *Errors) error {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+		name := prefix + fieldName(field)
+		if err := v.validateField(ctx, value.Field(i), name, tag, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Validator) validateField(ctx context.Context, value reflect.Value, name, tag string, errs *Errors) error {
+	rules, elemRules := splitDive(orderedRules(tag))
+
+	if isEmpty(value) {
+		switch {
+		case hasRule(rules, "required"):
+			*errs = append(*errs, FieldError{Field: name, Code: "required", Message: "is required"})
+		case hasRule(rules, "notblank") && value.Kind() == reflect.Pointer && !value.IsNil():
+			*errs = append(*errs, FieldError{Field: name, Code: "notblank", Message: "cannot be empty"})
+		}
+		return nil
+	}
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+
+	for _, rule := range rules {
+		if rule.name == "required" || rule.name == "notblank" {
+			continue
+		}
+		v.mu.RLock()
+		def, ok := v.rules[rule.name]
+		v.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("validation: unknown rule %q on %s", rule.name, name)
+		}
+		valid, err := def.check(ctx, value, rule.param)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			*errs = append(*errs, FieldError{Field: name, Code: rule.name, Message: ruleMessage(def.message, rule.param)})
+			// Cukup satu error per field, rule berikutnya biasanya bergantung pada rule sebelumnya
+			return nil
+		}
+	}
+
+	if elemRules != "" && value.Kind() == reflect.Slice {
+		for i := 0; i < value.Len(); i++ {
+			if err := v.validateField(ctx, value.Index(i), fmt.Sprintf("%s[%d]", name, i), elemRules, errs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Struct dan slice of struct diperiksa per field
+	switch {
+	case value.Kind() == reflect.Struct && !isScalarStruct(value.Type()):
+		return v.validateStruct(ctx, value, name+".", errs)
+	case value.Kind() == reflect.Slice && isNestedStruct(value.Type().Elem()):
+		for i := 0; i < value.Len(); i++ {
+			item := value.Index(i)
+			for item.Kind() == reflect.Pointer {
+				if item.IsNil() {
+					break
+				}
+				item = item.Elem()
+			}
+			if item.Kind() != reflect.Struct {
+				continue
+			}
+			if err := v.validateStruct(ctx, item, fmt.Sprintf("%s[%d].", name, i), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type tagRule struct {
+	name  string
+	param string
+}
+
+func orderedRules(tag string) []tagRule {
+	rules := []tagRule{}
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, tagRule{name: name, param: param})
+	}
+	return rules
+}
+
+// splitDive memisahkan rule untuk field dan rule untuk elemen slice (setelah dive)
+func splitDive(rules []tagRule) ([]tagRule, string) {
+	for i, rule := range rules {
+		if rule.name == "dive" {
+			elem := make([]string, 0, len(rules)-i-1)
+			for _, r := range rules[i+1:] {
+				if r.param != "" {
+					elem = append(elem, r.name+"="+r.param)
+				} else {
+					elem = append(elem, r.name)
+				}
+			}
+			return rules[:i], strings.Join(elem, ",")
+		}
+	}
+	return rules, ""
+}
+
+func hasRule(rules []tagRule, name string) bool {
+	for _, rule := range rules {
+		if rule.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleMessage(message, param string) string {
+	if strings.Contains(message, "%s") {
+		return fmt.Sprintf(message, param)
+	}
+	return message
+}
+
+// fieldName memakai nama dari tag json agar sama dengan yang dikirim klien
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// isEmpty menganggap string berisi spasi saja sebagai kosong. Pointer kosong jika nil
+// atau menunjuk ke nilai kosong.
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return value.IsNil() || isEmpty(value.Elem())
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+// isScalarStruct menandai struct yang diperlakukan sebagai satu nilai, misalnya ObjectID dan waktu
+func isScalarStruct(typ reflect.Type) bool {
+	return typ.PkgPath() == "time" || typ.NumField() == 0
+}
+
+func isNestedStruct(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && !isScalarStruct(typ)
+}